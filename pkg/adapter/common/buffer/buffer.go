@@ -0,0 +1,342 @@
+/*
+Copyright (c) 2020 TriggerMesh Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package buffer queues the events sent by adapters in a durable on-disk
+// buffer, and delivers them to the sink asynchronously, so that events
+// aren't lost while the sink is unavailable.
+package buffer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/cloudevents/sdk-go/v2/event"
+	"github.com/cloudevents/sdk-go/v2/protocol"
+	cehttp "github.com/cloudevents/sdk-go/v2/protocol/http"
+	"go.uber.org/zap"
+
+	"knative.dev/eventing/pkg/adapter/v2"
+
+	"github.com/triggermesh/knative-sources/pkg/adapter/common/stats"
+	"github.com/triggermesh/knative-sources/pkg/adapter/common/tracing"
+)
+
+// Config contains the settings of an event buffer. It can be included in the
+// environment configuration of an adapter.
+type Config struct {
+	// Directory in which events are buffered. Events are sent to the sink
+	// synchronously when empty.
+	Dir string `envconfig:"BUFFER_DIR"`
+
+	// Maximum total size of the buffered events, in bytes. Events are
+	// rejected once the buffer is full.
+	MaxBytes int64 `envconfig:"BUFFER_MAX_BYTES" default:"104857600"`
+
+	// Maximum duration during which the delivery of an event is retried.
+	MaxAge time.Duration `envconfig:"BUFFER_MAX_AGE" default:"24h"`
+
+	// Bounds of the exponential backoff between two delivery attempts.
+	MinBackoff time.Duration `envconfig:"BUFFER_MIN_BACKOFF" default:"1s"`
+	MaxBackoff time.Duration `envconfig:"BUFFER_MAX_BACKOFF" default:"5m"`
+}
+
+// Enabled returns whether events should be buffered.
+func (c Config) Enabled() bool {
+	return c.Dir != ""
+}
+
+// Reasons for discarding buffered events, reported in metrics.
+const (
+	dropReasonExpired  = "expired"
+	dropReasonRejected = "rejected"
+	dropReasonCorrupt  = "corrupt"
+)
+
+// Client is a cloudevents.Client which acknowledges events once they are
+// queued in the buffer, and delivers them to the sink asynchronously, in
+// order, through the wrapped client.
+//
+// Events which expect a response, sent with Request, are not buffered.
+type Client struct {
+	cloudevents.Client
+
+	cfg      Config
+	queue    *queue
+	reporter *stats.Reporter
+	logger   *zap.SugaredLogger
+}
+
+var _ cloudevents.Client = (*Client)(nil)
+
+// NewClient returns a Client which delivers events through the given client.
+// The buffer must be opened with Open before events are sent.
+func NewClient(cfg Config, c cloudevents.Client, reporter *stats.Reporter, logger *zap.SugaredLogger) *Client {
+	return &Client{
+		Client:   c,
+		cfg:      cfg,
+		reporter: reporter,
+		logger:   logger,
+	}
+}
+
+// Open opens the buffer. Events left in the buffer by a previous run of the
+// adapter are delivered first.
+func (c *Client) Open() error {
+	q, err := openQueue(c.cfg.Dir, c.cfg.MaxBytes)
+	if err != nil {
+		return err
+	}
+	c.queue = q
+
+	if n, _ := q.stats(); n > 0 {
+		c.logger.Infof("Resuming the delivery of %d buffered events", n)
+	}
+	c.reportDepth()
+
+	return nil
+}
+
+// record is the representation of an event in the buffer.
+type record struct {
+	// Target of the event, if different from the client's sink.
+	Target string      `json:"target,omitempty"`
+	Event  event.Event `json:"event"`
+}
+
+// Send implements cloudevents.Client.
+//
+// The returned result is an ACK once the event is durably queued, regardless
+// of its later delivery.
+func (c *Client) Send(ctx context.Context, e event.Event) protocol.Result {
+	if c.queue == nil {
+		return errors.New("the event buffer is not open")
+	}
+
+	if err := e.Validate(); err != nil {
+		return err
+	}
+
+	rec := record{Event: e}
+	if target := cloudevents.TargetFromContext(ctx); target != nil {
+		rec.Target = target.String()
+	}
+
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return fmt.Errorf("serializing event: %w", err)
+	}
+
+	if err := c.queue.push(data, time.Now()); err != nil {
+		return fmt.Errorf("buffering event: %w", err)
+	}
+	c.reportDepth()
+
+	return nil
+}
+
+// Run delivers buffered events until the given context is cancelled.
+// Deliveries which fail transiently, and entries which can not be removed
+// from the buffer, are retried with an exponential backoff until the event
+// expires. Events may therefore be delivered more than once.
+func (c *Client) Run(ctx context.Context) {
+	backoff := c.cfg.MinBackoff
+
+	for {
+		ent, ok := c.queue.peek()
+		if !ok {
+			select {
+			case <-ctx.Done():
+				return
+			case <-c.queue.notify:
+			}
+			continue
+		}
+
+		err := c.process(ctx, ent)
+		if err == nil {
+			backoff = c.cfg.MinBackoff
+			continue
+		}
+
+		c.logger.Warnw("Could not process buffered event, retrying",
+			zap.Duration("backoff", backoff), zap.Error(err))
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+
+		if backoff *= 2; backoff > c.cfg.MaxBackoff {
+			backoff = c.cfg.MaxBackoff
+		}
+	}
+}
+
+// process delivers the buffered event of the given queue entry, and removes
+// the entry from the queue unless the delivery should be retried. Events
+// which expired, can not be read, or are rejected by the sink are discarded.
+func (c *Client) process(ctx context.Context, ent entry) error {
+	if c.cfg.MaxAge > 0 && time.Since(ent.time) > c.cfg.MaxAge {
+		c.logger.Warnw("Discarding buffered event which could not be delivered in time",
+			zap.Time("buffered", ent.time))
+		return c.drop(ent, dropReasonExpired)
+	}
+
+	rec, err := c.read(ent)
+	if err != nil {
+		c.logger.Errorw("Discarding unreadable buffered event", zap.Error(err))
+		return c.drop(ent, dropReasonCorrupt)
+	}
+
+	res := c.deliver(ctx, rec)
+
+	switch {
+	case cloudevents.IsACK(res):
+		return c.remove(ent)
+
+	case !isRetryable(res):
+		c.logger.Errorw("Discarding buffered event rejected by the sink",
+			zap.String("event", rec.Event.ID()), zap.Error(res))
+		return c.drop(ent, dropReasonRejected)
+	}
+
+	return fmt.Errorf("delivering event %s: %w", rec.Event.ID(), res)
+}
+
+// deliver sends the given buffered event through the wrapped client.
+func (c *Client) deliver(ctx context.Context, rec *record) protocol.Result {
+	if rec.Target != "" {
+		ctx = cloudevents.ContextWithTarget(ctx, rec.Target)
+	}
+
+	ctx, span := tracing.StartDeliverSpan(ctx, rec.Event)
+	res := c.Client.Send(ctx, rec.Event)
+	if cloudevents.IsACK(res) {
+		res = nil
+	}
+	tracing.EndSpan(span, res)
+
+	return res
+}
+
+// read returns the buffered event of the given queue entry.
+func (c *Client) read(ent entry) (*record, error) {
+	data, err := c.queue.read(ent)
+	if err != nil {
+		return nil, err
+	}
+
+	rec := &record{}
+	if err := json.Unmarshal(data, rec); err != nil {
+		return nil, fmt.Errorf("deserializing event: %w", err)
+	}
+	return rec, nil
+}
+
+// drop discards the given queue entry without delivering it.
+func (c *Client) drop(ent entry, reason string) error {
+	if err := c.remove(ent); err != nil {
+		return err
+	}
+	c.reporter.ReportBufferDrop(reason)
+	return nil
+}
+
+// remove removes the given entry from the queue.
+func (c *Client) remove(ent entry) error {
+	if err := c.queue.remove(ent); err != nil {
+		return fmt.Errorf("removing event from the buffer: %w", err)
+	}
+	c.reportDepth()
+	return nil
+}
+
+// reportDepth reports the current depth of the queue.
+func (c *Client) reportDepth() {
+	c.reporter.ReportBufferDepth(c.queue.stats())
+}
+
+// isRetryable returns whether the delivery of an event which failed with the
+// given result should be retried.
+func isRetryable(res protocol.Result) bool {
+	var retriesRes *cehttp.RetriesResult
+	if cloudevents.ResultAs(res, &retriesRes) {
+		res = retriesRes.Result
+	}
+
+	var httpRes *cehttp.Result
+	if !cloudevents.ResultAs(res, &httpRes) {
+		// e.g. the sink could not be reached
+		return true
+	}
+
+	switch httpRes.StatusCode {
+	case http.StatusNotFound,
+		http.StatusRequestTimeout,
+		http.StatusConflict,
+		http.StatusTooManyRequests:
+		return true
+	}
+	return httpRes.StatusCode >= http.StatusInternalServerError
+}
+
+// WithDelivery returns an adapter which opens the buffer of the given client,
+// and delivers buffered events while the given adapter runs. The given
+// adapter is returned as is if the client is nil.
+func WithDelivery(a adapter.Adapter, c *Client) adapter.Adapter {
+	if c == nil {
+		return a
+	}
+	return &deliveringAdapter{
+		Adapter: a,
+		client:  c,
+	}
+}
+
+// deliveringAdapter is an adapter.Adapter which delivers buffered events.
+type deliveringAdapter struct {
+	adapter.Adapter
+	client *Client
+}
+
+// Start implements adapter.Adapter.
+func (a *deliveringAdapter) Start(ctx context.Context) error {
+	if err := a.client.Open(); err != nil {
+		return fmt.Errorf("opening event buffer: %w", err)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		a.client.Run(ctx)
+	}()
+
+	err := a.Adapter.Start(ctx)
+
+	cancel()
+	<-done
+
+	return err
+}
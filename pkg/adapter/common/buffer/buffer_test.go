@@ -0,0 +1,222 @@
+/*
+Copyright (c) 2020 TriggerMesh Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package buffer
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/cloudevents/sdk-go/v2/event"
+	"github.com/cloudevents/sdk-go/v2/protocol"
+	cehttp "github.com/cloudevents/sdk-go/v2/protocol/http"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	zapt "go.uber.org/zap/zaptest"
+)
+
+func TestClientDelivery(t *testing.T) {
+	sink := &stubClient{
+		results: []protocol.Result{
+			errors.New("connection refused"),
+			cehttp.NewResult(http.StatusServiceUnavailable, "unavailable"),
+			nil,
+			cehttp.NewResult(http.StatusBadRequest, "bad request"),
+			nil,
+		},
+		delivered: make(chan string, 5),
+	}
+
+	c := NewClient(testConfig(t), sink, nil, zapt.NewLogger(t).Sugar())
+	require.NoError(t, c.Open())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go c.Run(ctx)
+
+	sendCtx := cloudevents.ContextWithTarget(context.Background(), "http://other.sink")
+
+	for _, id := range []string{"1", "2", "3"} {
+		res := c.Send(sendCtx, newEvent(id))
+		require.True(t, cloudevents.IsACK(res), "Buffered events should be acknowledged")
+	}
+
+	var attempts []string
+	for i := 0; i < 5; i++ {
+		select {
+		case id := <-sink.delivered:
+			attempts = append(attempts, id)
+		case <-time.After(time.Second):
+			t.Fatalf("Timed out waiting for deliveries, got %v", attempts)
+		}
+	}
+
+	// event 1 is retried until delivered, event 2 is rejected permanently
+	assert.Equal(t, []string{"1", "1", "1", "2", "3"}, attempts)
+	assert.Equal(t, "http://other.sink", sink.lastTarget, "The target of events should be preserved")
+
+	assert.Eventually(t, func() bool {
+		n, _ := c.queue.stats()
+		return n == 0
+	}, time.Second, 10*time.Millisecond, "Delivered and rejected events should be removed from the buffer")
+}
+
+func TestClientResumesDelivery(t *testing.T) {
+	cfg := testConfig(t)
+
+	c := NewClient(cfg, &stubClient{}, nil, zapt.NewLogger(t).Sugar())
+	require.NoError(t, c.Open())
+	require.True(t, cloudevents.IsACK(c.Send(context.Background(), newEvent("1"))))
+
+	// a new client stands for a restarted adapter
+	sink := &stubClient{delivered: make(chan string, 1)}
+	c = NewClient(cfg, sink, nil, zapt.NewLogger(t).Sugar())
+	require.NoError(t, c.Open())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go c.Run(ctx)
+
+	select {
+	case id := <-sink.delivered:
+		assert.Equal(t, "1", id)
+	case <-time.After(time.Second):
+		t.Fatal("Timed out waiting for the delivery of the buffered event")
+	}
+}
+
+func TestClientDropsExpiredEvents(t *testing.T) {
+	cfg := testConfig(t)
+	cfg.MaxAge = time.Millisecond
+
+	sink := &stubClient{delivered: make(chan string, 1)}
+
+	c := NewClient(cfg, sink, nil, zapt.NewLogger(t).Sugar())
+	require.NoError(t, c.Open())
+	require.True(t, cloudevents.IsACK(c.Send(context.Background(), newEvent("1"))))
+
+	time.Sleep(2 * cfg.MaxAge)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go c.Run(ctx)
+
+	assert.Eventually(t, func() bool {
+		n, _ := c.queue.stats()
+		return n == 0
+	}, time.Second, 10*time.Millisecond, "Expired events should be removed from the buffer")
+	assert.Empty(t, sink.delivered, "Expired events should not be delivered")
+}
+
+func TestClientRetriesRemoval(t *testing.T) {
+	c := NewClient(testConfig(t), &stubClient{}, nil, zapt.NewLogger(t).Sugar())
+	require.NoError(t, c.Open())
+	require.True(t, cloudevents.IsACK(c.Send(context.Background(), newEvent("1"))))
+
+	// a non-empty directory in place of the entry's file can neither be
+	// read nor removed
+	ent, ok := c.queue.peek()
+	require.True(t, ok)
+	entPath := c.queue.path(ent.seq)
+	require.NoError(t, os.Remove(entPath))
+	require.NoError(t, os.Mkdir(entPath, 0700))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(entPath, "blocker"), nil, 0600))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go c.Run(ctx)
+
+	time.Sleep(10 * time.Millisecond)
+	n, _ := c.queue.stats()
+	require.Equal(t, 1, n, "Entries which can not be removed should remain in the buffer")
+
+	require.NoError(t, os.Remove(filepath.Join(entPath, "blocker")))
+
+	assert.Eventually(t, func() bool {
+		n, _ := c.queue.stats()
+		return n == 0
+	}, time.Second, 10*time.Millisecond, "The removal of entries should be retried")
+}
+
+func TestIsRetryable(t *testing.T) {
+	assert.True(t, isRetryable(errors.New("connection refused")))
+	assert.True(t, isRetryable(cehttp.NewResult(http.StatusTooManyRequests, "")))
+	assert.True(t, isRetryable(cehttp.NewResult(http.StatusBadGateway, "")))
+	assert.True(t, isRetryable(cehttp.NewRetriesResult(cehttp.NewResult(http.StatusNotFound, ""), 3, time.Now(), nil)))
+	assert.False(t, isRetryable(cehttp.NewResult(http.StatusBadRequest, "")))
+	assert.False(t, isRetryable(cehttp.NewResult(http.StatusForbidden, "")))
+}
+
+func testConfig(t *testing.T) Config {
+	return Config{
+		Dir:        t.TempDir(),
+		MaxBytes:   1 << 20,
+		MaxAge:     time.Hour,
+		MinBackoff: time.Millisecond,
+		MaxBackoff: 5 * time.Millisecond,
+	}
+}
+
+func newEvent(id string) event.Event {
+	e := cloudevents.NewEvent()
+	e.SetID(id)
+	e.SetType("com.example.test")
+	e.SetSource("test")
+	return e
+}
+
+// stubClient is a cloudevents.Client which returns a sequence of preset
+// results for the events sent, and succeeds once the sequence is exhausted.
+type stubClient struct {
+	cloudevents.Client
+
+	mu         sync.Mutex
+	results    []protocol.Result
+	lastTarget string
+	delivered  chan string
+}
+
+// Send implements cloudevents.Client.
+func (c *stubClient) Send(ctx context.Context, e event.Event) protocol.Result {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if t := cloudevents.TargetFromContext(ctx); t != nil {
+		c.lastTarget = t.String()
+	}
+	if c.delivered != nil {
+		c.delivered <- e.ID()
+	}
+
+	if len(c.results) == 0 {
+		return nil
+	}
+	res := c.results[0]
+	c.results = c.results[1:]
+	return res
+}
@@ -0,0 +1,251 @@
+/*
+Copyright (c) 2020 TriggerMesh Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package buffer
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrFull is returned when queuing an entry would exceed the maximum size of
+// the queue.
+var ErrFull = errors.New("buffer is full")
+
+// Suffixes of the files written in the directory of a queue.
+const (
+	entryFileSuffix = ".json"
+	tmpFileSuffix   = ".tmp"
+)
+
+// queue is a FIFO queue which persists each of its entries to its own file
+// in a directory. An entry is durable once it has been pushed, and survives
+// restarts of the adapter until it is removed.
+type queue struct {
+	dir      string
+	maxBytes int64
+
+	mu      sync.Mutex
+	entries []entry
+	size    int64
+	nextSeq uint64
+
+	// receives a value whenever an entry is pushed
+	notify chan struct{}
+}
+
+// entry is the reference to a queued entry.
+type entry struct {
+	seq  uint64
+	size int64
+	// time at which the entry was queued
+	time time.Time
+}
+
+// openQueue opens the queue persisted in the given directory, which is
+// created if it doesn't exist.
+func openQueue(dir string, maxBytes int64) (*queue, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("creating buffer directory: %w", err)
+	}
+
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("reading buffer directory: %w", err)
+	}
+
+	q := &queue{
+		dir:      dir,
+		maxBytes: maxBytes,
+		notify:   make(chan struct{}, 1),
+	}
+
+	for _, f := range files {
+		name := f.Name()
+
+		// leftover of an entry which was never fully written
+		if strings.HasSuffix(name, tmpFileSuffix) {
+			if err := os.Remove(filepath.Join(dir, name)); err != nil {
+				return nil, fmt.Errorf("removing incomplete buffer entry: %w", err)
+			}
+			continue
+		}
+
+		if f.IsDir() || !strings.HasSuffix(name, entryFileSuffix) {
+			continue
+		}
+
+		seq, err := strconv.ParseUint(strings.TrimSuffix(name, entryFileSuffix), 10, 64)
+		if err != nil {
+			continue
+		}
+
+		q.entries = append(q.entries, entry{
+			seq:  seq,
+			size: f.Size(),
+			time: f.ModTime(),
+		})
+		q.size += f.Size()
+	}
+
+	sort.Slice(q.entries, func(i, j int) bool {
+		return q.entries[i].seq < q.entries[j].seq
+	})
+
+	if n := len(q.entries); n > 0 {
+		q.nextSeq = q.entries[n-1].seq + 1
+	}
+
+	return q, nil
+}
+
+// push appends the given data to the queue. The data is synced to disk
+// before push returns.
+func (q *queue) push(data []byte, now time.Time) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	size := int64(len(data))
+	if q.maxBytes > 0 && q.size+size > q.maxBytes {
+		return ErrFull
+	}
+
+	seq := q.nextSeq
+
+	// the entry is written to a temporary file, which is renamed once
+	// synced, so that an entry is never read partially written
+	path := q.path(seq)
+	tmpPath := path + tmpFileSuffix
+
+	if err := writeFileSync(tmpPath, data); err != nil {
+		_ = os.Remove(tmpPath)
+		return fmt.Errorf("writing buffer entry: %w", err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		_ = os.Remove(tmpPath)
+		return fmt.Errorf("committing buffer entry: %w", err)
+	}
+	if err := syncDir(q.dir); err != nil {
+		return fmt.Errorf("syncing buffer directory: %w", err)
+	}
+
+	q.nextSeq++
+	q.entries = append(q.entries, entry{
+		seq:  seq,
+		size: size,
+		time: now,
+	})
+	q.size += size
+
+	select {
+	case q.notify <- struct{}{}:
+	default:
+	}
+
+	return nil
+}
+
+// peek returns the oldest entry of the queue, if any.
+func (q *queue) peek() (entry, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if len(q.entries) == 0 {
+		return entry{}, false
+	}
+	return q.entries[0], true
+}
+
+// read returns the data of the given entry.
+func (q *queue) read(e entry) ([]byte, error) {
+	return ioutil.ReadFile(q.path(e.seq))
+}
+
+// remove removes the given entry, which must be the oldest entry of the
+// queue, from the queue.
+func (q *queue) remove(e entry) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if len(q.entries) == 0 || q.entries[0].seq != e.seq {
+		return fmt.Errorf("entry %d is not the head of the queue", e.seq)
+	}
+
+	if err := os.Remove(q.path(e.seq)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("removing buffer entry: %w", err)
+	}
+
+	q.entries = q.entries[1:]
+	q.size -= e.size
+
+	return nil
+}
+
+// stats returns the number of entries in the queue, and their total size.
+func (q *queue) stats() (int, int64) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return len(q.entries), q.size
+}
+
+// path returns the path of the file of the entry with the given sequence
+// number. Sequence numbers are zero-padded so that files sort in queue order.
+func (q *queue) path(seq uint64) string {
+	return filepath.Join(q.dir, fmt.Sprintf("%020d%s", seq, entryFileSuffix))
+}
+
+// writeFileSync writes the given data to a new file, and syncs the file to
+// disk.
+func writeFileSync(path string, data []byte) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return err
+	}
+
+	if _, err := f.Write(data); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// syncDir syncs the given directory to disk, which persists the creation and
+// renaming of its files.
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+
+	if err := d.Sync(); err != nil {
+		_ = d.Close()
+		return err
+	}
+	return d.Close()
+}
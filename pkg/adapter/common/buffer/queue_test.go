@@ -0,0 +1,77 @@
+/*
+Copyright (c) 2020 TriggerMesh Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package buffer
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueuePersistence(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+
+	q, err := openQueue(dir, 10)
+	require.NoError(t, err)
+
+	require.NoError(t, q.push([]byte("aaaa"), now))
+	require.NoError(t, q.push([]byte("bbbb"), now))
+	assert.Equal(t, ErrFull, q.push([]byte("cccc"), now), "The queue should be bounded in size")
+
+	ent, ok := q.peek()
+	require.True(t, ok)
+	require.NoError(t, q.remove(ent))
+
+	require.NoError(t, q.push([]byte("dd"), now))
+
+	// simulates an entry which was never fully written
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "00000000000000000042.json.tmp"), []byte("x"), 0600))
+
+	q, err = openQueue(dir, 10)
+	require.NoError(t, err)
+
+	n, size := q.stats()
+	assert.Equal(t, 2, n)
+	assert.Equal(t, int64(6), size)
+
+	var data []string
+	for {
+		ent, ok := q.peek()
+		if !ok {
+			break
+		}
+		d, err := q.read(ent)
+		require.NoError(t, err)
+		data = append(data, string(d))
+		require.NoError(t, q.remove(ent))
+	}
+	assert.Equal(t, []string{"bbbb", "dd"}, data, "Entries should be read in the order they were pushed")
+
+	files, err := ioutil.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Empty(t, files, "Removed and incomplete entries should be deleted from disk")
+
+	require.NoError(t, q.push([]byte("eeee"), now))
+	_, err = os.Stat(q.path(3))
+	assert.NoError(t, err, "Sequence numbers should continue after reopening the queue")
+}
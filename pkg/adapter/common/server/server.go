@@ -0,0 +1,355 @@
+/*
+Copyright (c) 2020 TriggerMesh Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package server implements the HTTP server through which adapters receive
+// webhook requests.
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"runtime/debug"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+
+	"knative.dev/pkg/tracing"
+
+	"github.com/triggermesh/knative-sources/pkg/adapter/common/stats"
+)
+
+// Paths of the health endpoints served alongside the webhook handler.
+const (
+	// LivenessPath responds with a success status as long as the server
+	// is running.
+	LivenessPath = "/health"
+	// ReadinessPath responds with a success status while the server
+	// accepts requests, and with a failure status once it is shutting
+	// down.
+	ReadinessPath = "/ready"
+)
+
+// Config contains the settings of a Server. It can be embedded in the
+// environment configuration of an adapter.
+type Config struct {
+	// Port on which the server listens.
+	Port int `envconfig:"PORT" default:"8080"`
+
+	// Maximum durations for reading a request, writing its response,
+	// and keeping an idle connection open.
+	ReadTimeout  time.Duration `envconfig:"SERVER_READ_TIMEOUT" default:"30s"`
+	WriteTimeout time.Duration `envconfig:"SERVER_WRITE_TIMEOUT" default:"30s"`
+	IdleTimeout  time.Duration `envconfig:"SERVER_IDLE_TIMEOUT" default:"2m"`
+
+	// Maximum duration to wait for requests in flight to complete upon
+	// shutdown.
+	ShutdownTimeout time.Duration `envconfig:"SERVER_SHUTDOWN_TIMEOUT" default:"10s"`
+
+	// Maximum size of request bodies, in bytes.
+	MaxBodyBytes int64 `envconfig:"SERVER_MAX_BODY_BYTES" default:"10485760"`
+}
+
+// DefaultConfig returns a Config with the same values as the defaults of the
+// environment configuration.
+func DefaultConfig() Config {
+	return Config{
+		Port:            8080,
+		ReadTimeout:     30 * time.Second,
+		WriteTimeout:    30 * time.Second,
+		IdleTimeout:     2 * time.Minute,
+		ShutdownTimeout: 10 * time.Second,
+		MaxBodyBytes:    10 << 20,
+	}
+}
+
+// Server serves a webhook handler, along with health endpoints. Requests
+// passed to the handler are traced, logged and measured, have a limited body
+// size, and can't crash the server by panicking.
+type Server struct {
+	cfg      Config
+	handler  http.Handler
+	reporter *stats.Reporter
+	logger   *zap.SugaredLogger
+
+	// set to 1 once the server is shutting down
+	shuttingDown int32
+}
+
+// New returns a Server which passes webhook requests to the given handler.
+// Metrics about requests are recorded with the given reporter, if not nil.
+func New(cfg Config, handler http.Handler, reporter *stats.Reporter, logger *zap.SugaredLogger) *Server {
+	return &Server{
+		cfg:      cfg,
+		handler:  handler,
+		reporter: reporter,
+		logger:   logger,
+	}
+}
+
+// Handler returns the root handler of the server.
+func (s *Server) Handler() http.Handler {
+	m := http.NewServeMux()
+	m.HandleFunc(LivenessPath, s.handleLiveness)
+	m.HandleFunc(ReadinessPath, s.handleReadiness)
+	// the span of a request continues the trace propagated by the sender,
+	// if any, and is accessible through the request's context
+	m.Handle("/", tracing.HTTPSpanMiddleware(
+		s.logRequests(s.reportRequests(s.recoverPanics(s.limitBody(s.handler)))),
+	))
+	return m
+}
+
+// Run listens on the configured port and serves requests until the given
+// context is cancelled, at which point requests in flight are given a chance
+// to complete before it returns.
+func (s *Server) Run(ctx context.Context) error {
+	ln, err := net.Listen("tcp", ":"+strconv.Itoa(s.cfg.Port))
+	if err != nil {
+		return fmt.Errorf("could not listen on port %d: %w", s.cfg.Port, err)
+	}
+	return s.Serve(ctx, ln)
+}
+
+// Serve serves requests on the given listener until the given context is
+// cancelled. It behaves like Run otherwise.
+func (s *Server) Serve(ctx context.Context, ln net.Listener) error {
+	srv := &http.Server{
+		Handler:      s.Handler(),
+		ReadTimeout:  s.cfg.ReadTimeout,
+		WriteTimeout: s.cfg.WriteTimeout,
+		IdleTimeout:  s.cfg.IdleTimeout,
+	}
+
+	serveErrCh := make(chan error, 1)
+	go func() {
+		serveErrCh <- srv.Serve(ln)
+	}()
+
+	s.logger.Infof("Server is ready to handle requests at %s", ln.Addr())
+
+	select {
+	case err := <-serveErrCh:
+		// the server stopped serving on its own, there is nothing
+		// left to shut down
+		return fmt.Errorf("failure while serving requests: %w", err)
+	case <-ctx.Done():
+	}
+
+	s.logger.Info("Server is shutting down")
+	atomic.StoreInt32(&s.shuttingDown, 1)
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), s.cfg.ShutdownTimeout)
+	defer cancel()
+
+	srv.SetKeepAlivesEnabled(false)
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		return fmt.Errorf("could not gracefully shut down the server: %w", err)
+	}
+
+	if err := <-serveErrCh; err != http.ErrServerClosed {
+		return fmt.Errorf("failure while serving requests: %w", err)
+	}
+
+	s.logger.Info("Server stopped")
+	return nil
+}
+
+// handleLiveness serves the liveness endpoint.
+func (s *Server) handleLiveness(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+}
+
+// handleReadiness serves the readiness endpoint.
+func (s *Server) handleReadiness(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+
+	if atomic.LoadInt32(&s.shuttingDown) == 1 {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// limitBody limits the size of the bodies of requests passed to the given
+// handler. Reading beyond the limit returns ErrBodyTooLarge.
+func (s *Server) limitBody(h http.Handler) http.Handler {
+	if s.cfg.MaxBodyBytes <= 0 {
+		return h
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Body != nil {
+			r.Body = &limitedBody{ReadCloser: r.Body, remaining: s.cfg.MaxBodyBytes}
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
+// recoverPanics responds with a server error to requests which cause the
+// given handler to panic.
+func (s *Server) recoverPanics(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			if p := recover(); p != nil {
+				if p == http.ErrAbortHandler {
+					panic(p)
+				}
+
+				s.logger.Errorw("Panic while handling request", zap.Any("panic", p),
+					zap.ByteString("stack", debug.Stack()))
+				http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			}
+		}()
+
+		h.ServeHTTP(w, r)
+	})
+}
+
+// logRequests logs the outcome of the requests passed to the given handler.
+func (s *Server) logRequests(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+
+		h.ServeHTTP(rec, r)
+
+		s.logger.Debugw("Handled request",
+			zap.String("method", r.Method),
+			zap.String("path", r.URL.Path),
+			zap.Int("status", rec.status),
+			zap.Duration("duration", time.Since(start)))
+	})
+}
+
+// reportRequests records metrics about the requests passed to the given
+// handler.
+func (s *Server) reportRequests(h http.Handler) http.Handler {
+	if s.reporter == nil {
+		return h
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+
+		var body *countingBody
+		if r.Body != nil {
+			body = &countingBody{ReadCloser: r.Body}
+			r.Body = body
+		}
+
+		h.ServeHTTP(rec, r)
+
+		var bodySize int64
+		if body != nil {
+			bodySize = body.count
+		}
+
+		s.reporter.ReportRequest(rec.status, bodySize)
+		if rec.status == http.StatusUnauthorized {
+			s.reporter.ReportAuthFailure()
+		}
+	})
+}
+
+// statusRecorder is a http.ResponseWriter which records the status code of
+// the response.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+// WriteHeader implements http.ResponseWriter.
+func (r *statusRecorder) WriteHeader(code int) {
+	r.status = code
+	r.ResponseWriter.WriteHeader(code)
+}
+
+// ErrBodyTooLarge is returned when reading a request body larger than the
+// limit set in the server's Config.
+var ErrBodyTooLarge = errors.New("request body too large")
+
+// limitedBody is a request body which returns ErrBodyTooLarge once more than
+// the remaining number of bytes are read.
+type limitedBody struct {
+	io.ReadCloser
+	remaining int64
+}
+
+// Read implements io.Reader.
+func (b *limitedBody) Read(p []byte) (int, error) {
+	if b.remaining < 0 {
+		return 0, ErrBodyTooLarge
+	}
+
+	// read one more byte than allowed to detect bodies above the limit
+	if int64(len(p)) > b.remaining+1 {
+		p = p[:b.remaining+1]
+	}
+
+	n, err := b.ReadCloser.Read(p)
+	b.remaining -= int64(n)
+	if b.remaining < 0 {
+		return n + int(b.remaining), ErrBodyTooLarge
+	}
+	return n, err
+}
+
+// countingBody is a request body which counts the number of bytes read from
+// it.
+type countingBody struct {
+	io.ReadCloser
+	count int64
+}
+
+// Read implements io.Reader.
+func (b *countingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.count += int64(n)
+	return n, err
+}
+
+// ReadBody reads the body of the given request. When an error is returned,
+// the returned status code is the one the request should be responded with.
+func ReadBody(r *http.Request) ([]byte, int, error) {
+	if r.Body == nil {
+		return nil, http.StatusBadRequest, errors.New("request without body not supported")
+	}
+	defer r.Body.Close()
+
+	body, err := ioutil.ReadAll(r.Body)
+	switch {
+	case errors.Is(err, ErrBodyTooLarge):
+		return nil, http.StatusRequestEntityTooLarge, err
+	case err != nil:
+		return nil, http.StatusInternalServerError, fmt.Errorf("could not read request body: %w", err)
+	}
+
+	return body, http.StatusOK, nil
+}
+
+// Error responds to a request with the given status code and the message of
+// the given error.
+func Error(w http.ResponseWriter, err error, code int) {
+	http.Error(w, err.Error(), code)
+}
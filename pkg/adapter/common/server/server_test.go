@@ -0,0 +1,256 @@
+/*
+Copyright (c) 2020 TriggerMesh Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"context"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opencensus.io/trace"
+	zapt "go.uber.org/zap/zaptest"
+
+	"knative.dev/eventing/pkg/adapter/v2"
+	"knative.dev/pkg/metrics/metricskey"
+	"knative.dev/pkg/metrics/metricstest"
+	_ "knative.dev/pkg/metrics/testing"
+
+	"github.com/triggermesh/knative-sources/pkg/adapter/common/stats"
+)
+
+func TestHandler(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.MaxBodyBytes = 8
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/panic" {
+			panic("boom")
+		}
+
+		body, code, err := ReadBody(r)
+		if err != nil {
+			Error(w, err, code)
+			return
+		}
+		_, _ = w.Write(body)
+	})
+
+	s := New(cfg, handler, nil, zapt.NewLogger(t).Sugar())
+
+	tc := map[string]struct {
+		path string
+		body string
+
+		expectCode int
+		expectBody string
+	}{
+		"liveness": {
+			path:       LivenessPath,
+			expectCode: http.StatusOK,
+		},
+		"readiness": {
+			path:       ReadinessPath,
+			expectCode: http.StatusOK,
+		},
+		"body within limit": {
+			path:       "/",
+			body:       "12345678",
+			expectCode: http.StatusOK,
+			expectBody: "12345678",
+		},
+		"body above limit": {
+			path:       "/",
+			body:       "123456789",
+			expectCode: http.StatusRequestEntityTooLarge,
+			expectBody: ErrBodyTooLarge.Error() + "\n",
+		},
+		"handler panics": {
+			path:       "/panic",
+			expectCode: http.StatusInternalServerError,
+			expectBody: http.StatusText(http.StatusInternalServerError) + "\n",
+		},
+	}
+
+	for name, c := range tc {
+		//nolint:scopelint
+		t.Run(name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, c.path, strings.NewReader(c.body))
+			rec := httptest.NewRecorder()
+
+			s.Handler().ServeHTTP(rec, req)
+
+			assert.Equal(t, c.expectCode, rec.Code, "Unexpected response code")
+			assert.Equal(t, c.expectBody, rec.Body.String(), "Unexpected response body")
+		})
+	}
+}
+
+func TestHandlerReportsRequests(t *testing.T) {
+	const (
+		requestCountMetric = "webhook_request_count"
+		authFailureMetric  = "webhook_auth_failure_count"
+		requestSizeMetric  = "webhook_request_size_bytes"
+	)
+
+	t.Cleanup(func() {
+		metricstest.Unregister(requestCountMetric, authFailureMetric, requestSizeMetric)
+	})
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = ioutil.ReadAll(r.Body)
+		w.WriteHeader(http.StatusUnauthorized)
+	})
+
+	reporter := stats.NewReporter(adapter.EnvConfig{
+		Namespace:     "testns",
+		Name:          "test",
+		ResourceGroup: "testsources.example.com",
+	})
+
+	s := New(DefaultConfig(), handler, reporter, zapt.NewLogger(t).Sugar())
+
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader("12345678"))
+	s.Handler().ServeHTTP(httptest.NewRecorder(), req)
+
+	tags := map[string]string{
+		metricskey.LabelNamespaceName: "testns",
+		metricskey.LabelName:          "test",
+		metricskey.LabelResourceGroup: "testsources.example.com",
+	}
+
+	metricstest.CheckCountData(t, requestCountMetric, map[string]string{
+		metricskey.LabelNamespaceName:     "testns",
+		metricskey.LabelName:              "test",
+		metricskey.LabelResourceGroup:     "testsources.example.com",
+		metricskey.LabelResponseCode:      "401",
+		metricskey.LabelResponseCodeClass: "4xx",
+	}, 1)
+	metricstest.CheckCountData(t, authFailureMetric, tags, 1)
+	metricstest.CheckDistributionData(t, requestSizeMetric, tags, 1, 8, 8)
+}
+
+func TestHandlerContinuesTrace(t *testing.T) {
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+
+	var span *trace.Span
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		span = trace.FromContext(r.Context())
+	})
+
+	s := New(DefaultConfig(), handler, nil, zapt.NewLogger(t).Sugar())
+
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader("{}"))
+	req.Header.Set("traceparent", "00-"+traceID+"-00f067aa0ba902b7-01")
+
+	s.Handler().ServeHTTP(httptest.NewRecorder(), req)
+
+	require.NotNil(t, span, "The request's context should carry a span")
+	assert.Equal(t, traceID, span.SpanContext().TraceID.String())
+}
+
+func TestReadBodyWithoutBody(t *testing.T) {
+	req, err := http.NewRequest(http.MethodPost, "/", nil)
+	require.NoError(t, err)
+
+	_, code, err := ReadBody(req)
+	assert.EqualError(t, err, "request without body not supported")
+	assert.Equal(t, http.StatusBadRequest, code)
+}
+
+func TestServeUntilCancelled(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	// blocks until released, to observe the server while it shuts down
+	started, release := make(chan struct{}), make(chan struct{})
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	s := New(DefaultConfig(), handler, nil, zapt.NewLogger(t).Sugar())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	errCh := make(chan error)
+	go func() {
+		errCh <- s.Serve(ctx, ln)
+	}()
+
+	url := "http://" + ln.Addr().String()
+
+	require.Eventually(t, func() bool {
+		resp, err := http.Get(url + ReadinessPath)
+		if err != nil {
+			return false
+		}
+		_ = resp.Body.Close()
+		return resp.StatusCode == http.StatusOK
+	}, time.Second, 10*time.Millisecond, "server is not ready")
+
+	respCh := make(chan *http.Response)
+	go func() {
+		resp, err := http.Post(url, "text/plain", strings.NewReader("hello"))
+		if err != nil {
+			close(respCh)
+			return
+		}
+		respCh <- resp
+	}()
+
+	select {
+	case <-started:
+	case <-time.After(time.Second):
+		require.Fail(t, "request was not received")
+	}
+
+	cancel()
+
+	// the readiness endpoint is queried directly through the handler,
+	// since the server stops accepting connections once it shuts down
+	assert.Eventually(t, func() bool {
+		rec := httptest.NewRecorder()
+		s.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, ReadinessPath, nil))
+		return rec.Code == http.StatusServiceUnavailable
+	}, time.Second, 10*time.Millisecond, "server is still ready while shutting down")
+
+	// the request in flight completes during the shutdown
+	close(release)
+
+	resp, ok := <-respCh
+	if assert.True(t, ok, "request in flight failed") {
+		_, _ = ioutil.ReadAll(resp.Body)
+		_ = resp.Body.Close()
+		assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+	}
+
+	select {
+	case err := <-errCh:
+		assert.NoError(t, err)
+	case <-time.After(time.Second):
+		assert.Fail(t, "server did not stop")
+	}
+}
@@ -0,0 +1,376 @@
+/*
+Copyright (c) 2020 TriggerMesh Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package stats records metrics about the webhook requests received, and the
+// events filtered and sent by adapters. Metrics are recorded through the Knative metrics
+// backend, alongside the metrics of the Knative adapter framework.
+package stats
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/cloudevents/sdk-go/v2/event"
+	"github.com/cloudevents/sdk-go/v2/protocol"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+
+	"knative.dev/eventing/pkg/adapter/v2"
+	"knative.dev/pkg/metrics"
+	"knative.dev/pkg/metrics/metricskey"
+)
+
+var (
+	// requestCountM is a counter which records the number of webhook
+	// requests received by the adapter.
+	requestCountM = stats.Int64(
+		"webhook_request_count",
+		"Number of webhook requests received",
+		stats.UnitDimensionless,
+	)
+
+	// authFailureCountM is a counter which records the number of webhook
+	// requests rejected because they failed authentication.
+	authFailureCountM = stats.Int64(
+		"webhook_auth_failure_count",
+		"Number of webhook requests which failed authentication",
+		stats.UnitDimensionless,
+	)
+
+	// requestSizeM is a distribution of the sizes of the bodies of webhook
+	// requests.
+	requestSizeM = stats.Int64(
+		"webhook_request_size_bytes",
+		"Size of the bodies of webhook requests",
+		stats.UnitBytes,
+	)
+
+	// eventDispatchCountM is a counter which records the number of events
+	// sent by the adapter.
+	eventDispatchCountM = stats.Int64(
+		"event_dispatch_count",
+		"Number of events sent to the sink",
+		stats.UnitDimensionless,
+	)
+
+	// eventDispatchLatencyM is a distribution of the durations of the
+	// deliveries of events to the sink.
+	eventDispatchLatencyM = stats.Float64(
+		"event_dispatch_latencies",
+		"Time spent delivering events to the sink",
+		stats.UnitMilliseconds,
+	)
+
+	// filteredEventCountM is a counter which records the number of events
+	// dropped by the filters of the adapter.
+	filteredEventCountM = stats.Int64(
+		"filtered_event_count",
+		"Number of events dropped by filters",
+		stats.UnitDimensionless,
+	)
+
+	// bufferDepthM is a gauge which records the number of events queued
+	// in the adapter's buffer.
+	bufferDepthM = stats.Int64(
+		"buffer_queue_depth",
+		"Number of events queued in the buffer",
+		stats.UnitDimensionless,
+	)
+
+	// bufferSizeM is a gauge which records the total size of the events
+	// queued in the adapter's buffer.
+	bufferSizeM = stats.Int64(
+		"buffer_queue_size_bytes",
+		"Total size of the events queued in the buffer",
+		stats.UnitBytes,
+	)
+
+	// bufferDropCountM is a counter which records the number of events
+	// discarded from the adapter's buffer without being delivered.
+	bufferDropCountM = stats.Int64(
+		"buffer_dropped_count",
+		"Number of buffered events discarded without being delivered",
+		stats.UnitDimensionless,
+	)
+
+	namespaceKey         = tag.MustNewKey(metricskey.LabelNamespaceName)
+	nameKey              = tag.MustNewKey(metricskey.LabelName)
+	resourceGroupKey     = tag.MustNewKey(metricskey.LabelResourceGroup)
+	responseCodeKey      = tag.MustNewKey(metricskey.LabelResponseCode)
+	responseCodeClassKey = tag.MustNewKey(metricskey.LabelResponseCodeClass)
+	eventTypeKey         = tag.MustNewKey(metricskey.LabelEventType)
+	resultKey            = tag.MustNewKey("result")
+	dropReasonKey        = tag.MustNewKey("reason")
+	filterReasonKey      = tag.MustNewKey("filter_reason")
+)
+
+// unknownTagValue is the value of the attributes of the metric tag returned by
+// the Knative adapter framework for contexts which don't carry any.
+const unknownTagValue = "unknown"
+
+// Values of the "result" tag of event metrics.
+const (
+	resultSent   = "sent"
+	resultFailed = "failed"
+)
+
+func init() {
+	mustRegisterStatsViews()
+}
+
+// Reporter records metrics about an adapter. A nil Reporter records nothing.
+type Reporter struct {
+	tag *adapter.MetricTag
+}
+
+// NewReporter returns a Reporter which tags metrics with the identity of the
+// adapter's source, as found in the given adapter configuration.
+func NewReporter(env adapter.EnvConfig) *Reporter {
+	return &Reporter{
+		tag: &adapter.MetricTag{
+			Namespace:     env.Namespace,
+			Name:          env.Name,
+			ResourceGroup: env.ResourceGroup,
+		},
+	}
+}
+
+// ReportRequest records a webhook request which was responded with the given
+// status code, and which body had the given size.
+func (r *Reporter) ReportRequest(code int, bodySize int64) {
+	if r == nil {
+		return
+	}
+
+	ctx, err := r.tagged(
+		tag.Insert(responseCodeKey, strconv.Itoa(code)),
+		tag.Insert(responseCodeClassKey, metrics.ResponseCodeClass(code)),
+	)
+	if err != nil {
+		return
+	}
+	metrics.Record(ctx, requestCountM.M(1))
+	metrics.Record(ctx, requestSizeM.M(bodySize))
+}
+
+// ReportAuthFailure records a webhook request which failed authentication.
+func (r *Reporter) ReportAuthFailure() {
+	if r == nil {
+		return
+	}
+
+	ctx, err := r.tagged()
+	if err != nil {
+		return
+	}
+	metrics.Record(ctx, authFailureCountM.M(1))
+}
+
+// ReportFilteredEvent records an event dropped by a filter of the adapter for
+// the given reason.
+func (r *Reporter) ReportFilteredEvent(reason string) {
+	if r == nil {
+		return
+	}
+
+	ctx, err := r.tagged(tag.Insert(filterReasonKey, reason))
+	if err != nil {
+		return
+	}
+	metrics.Record(ctx, filteredEventCountM.M(1))
+}
+
+// ReportBufferDepth records the number and total size of the events queued in
+// the adapter's buffer.
+func (r *Reporter) ReportBufferDepth(events int, bytes int64) {
+	if r == nil {
+		return
+	}
+
+	ctx, err := r.tagged()
+	if err != nil {
+		return
+	}
+	metrics.Record(ctx, bufferDepthM.M(int64(events)))
+	metrics.Record(ctx, bufferSizeM.M(bytes))
+}
+
+// ReportBufferDrop records an event discarded from the adapter's buffer for
+// the given reason.
+func (r *Reporter) ReportBufferDrop(reason string) {
+	if r == nil {
+		return
+	}
+
+	ctx, err := r.tagged(tag.Insert(dropReasonKey, reason))
+	if err != nil {
+		return
+	}
+	metrics.Record(ctx, bufferDropCountM.M(1))
+}
+
+// reportDispatch records the delivery of an event of the given type, which
+// took the given duration.
+func (r *Reporter) reportDispatch(eventType string, sent bool, d time.Duration) {
+	result := resultSent
+	if !sent {
+		result = resultFailed
+	}
+
+	ctx, err := r.tagged(
+		tag.Insert(eventTypeKey, eventType),
+		tag.Insert(resultKey, result),
+	)
+	if err != nil {
+		return
+	}
+	metrics.Record(ctx, eventDispatchCountM.M(1))
+	metrics.Record(ctx, eventDispatchLatencyM.M(float64(d)/float64(time.Millisecond)))
+}
+
+// tagged returns a context containing the tags of the adapter's source, in
+// addition to the given tags.
+func (r *Reporter) tagged(mutators ...tag.Mutator) (context.Context, error) {
+	return tag.New(context.Background(), append([]tag.Mutator{
+		tag.Insert(namespaceKey, r.tag.Namespace),
+		tag.Insert(nameKey, r.tag.Name),
+		tag.Insert(resourceGroupKey, r.tag.ResourceGroup),
+	}, mutators...)...)
+}
+
+// InstrumentClient returns a CloudEvents client which records metrics about
+// the events sent with the given client. The identity of the adapter's source
+// is also passed to the client created by the Knative adapter framework, so
+// that its own metrics aren't reported for an unknown source.
+func InstrumentClient(c cloudevents.Client, r *Reporter) cloudevents.Client {
+	if r == nil {
+		return c
+	}
+	return &instrumentedClient{Client: c, reporter: r}
+}
+
+// instrumentedClient is a cloudevents.Client which records metrics about the
+// events it sends.
+type instrumentedClient struct {
+	cloudevents.Client
+	reporter *Reporter
+}
+
+var _ cloudevents.Client = (*instrumentedClient)(nil)
+
+// Send implements cloudevents.Client.
+func (c *instrumentedClient) Send(ctx context.Context, e event.Event) protocol.Result {
+	start := time.Now()
+	res := c.Client.Send(c.withMetricTag(ctx), e)
+	c.reporter.reportDispatch(e.Type(), cloudevents.IsACK(res), time.Since(start))
+	return res
+}
+
+// Request implements cloudevents.Client.
+func (c *instrumentedClient) Request(ctx context.Context, e event.Event) (*event.Event, protocol.Result) {
+	start := time.Now()
+	resp, res := c.Client.Request(c.withMetricTag(ctx), e)
+	c.reporter.reportDispatch(e.Type(), cloudevents.IsACK(res), time.Since(start))
+	return resp, res
+}
+
+// withMetricTag returns a context which carries the metric tag of the
+// adapter's source, unless the given context already carries one.
+func (c *instrumentedClient) withMetricTag(ctx context.Context) context.Context {
+	// MetricTagFromContext returns a tag with "unknown" attributes when the
+	// context doesn't carry any.
+	if adapter.MetricTagFromContext(ctx).Name == unknownTagValue {
+		return adapter.ContextWithMetricTag(ctx, c.reporter.tag)
+	}
+	return ctx
+}
+
+func mustRegisterStatsViews() {
+	tagKeys := []tag.Key{namespaceKey, nameKey, resourceGroupKey}
+
+	if err := view.Register(
+		&view.View{
+			Description: requestCountM.Description(),
+			Measure:     requestCountM,
+			Aggregation: view.Count(),
+			TagKeys:     append(tagKeys, responseCodeKey, responseCodeClassKey),
+		},
+		&view.View{
+			Description: authFailureCountM.Description(),
+			Measure:     authFailureCountM,
+			Aggregation: view.Count(),
+			TagKeys:     tagKeys,
+		},
+		&view.View{
+			Description: requestSizeM.Description(),
+			Measure:     requestSizeM,
+			// from 64B to 16MiB
+			Aggregation: view.Distribution(bucketsPowerOf4(64, 16<<20)...),
+			TagKeys:     tagKeys,
+		},
+		&view.View{
+			Description: eventDispatchCountM.Description(),
+			Measure:     eventDispatchCountM,
+			Aggregation: view.Count(),
+			TagKeys:     append(tagKeys, eventTypeKey, resultKey),
+		},
+		&view.View{
+			Description: eventDispatchLatencyM.Description(),
+			Measure:     eventDispatchLatencyM,
+			Aggregation: view.Distribution(metrics.Buckets125(1, 10000)...),
+			TagKeys:     append(tagKeys, eventTypeKey, resultKey),
+		},
+		&view.View{
+			Description: filteredEventCountM.Description(),
+			Measure:     filteredEventCountM,
+			Aggregation: view.Count(),
+			TagKeys:     append(tagKeys, filterReasonKey),
+		},
+		&view.View{
+			Description: bufferDepthM.Description(),
+			Measure:     bufferDepthM,
+			Aggregation: view.LastValue(),
+			TagKeys:     tagKeys,
+		},
+		&view.View{
+			Description: bufferSizeM.Description(),
+			Measure:     bufferSizeM,
+			Aggregation: view.LastValue(),
+			TagKeys:     tagKeys,
+		},
+		&view.View{
+			Description: bufferDropCountM.Description(),
+			Measure:     bufferDropCountM,
+			Aggregation: view.Count(),
+			TagKeys:     append(tagKeys, dropReasonKey),
+		},
+	); err != nil {
+		panic(err)
+	}
+}
+
+// bucketsPowerOf4 returns distribution buckets which bounds are powers of 4
+// from low to high.
+func bucketsPowerOf4(low, high float64) []float64 {
+	var buckets []float64
+	for b := low; b <= high; b *= 4 {
+		buckets = append(buckets, b)
+	}
+	return buckets
+}
@@ -0,0 +1,207 @@
+/*
+Copyright (c) 2020 TriggerMesh Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package stats
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/cloudevents/sdk-go/v2/event"
+	"github.com/cloudevents/sdk-go/v2/protocol"
+	"github.com/stretchr/testify/assert"
+
+	"knative.dev/eventing/pkg/adapter/v2"
+	"knative.dev/pkg/metrics/metricskey"
+	"knative.dev/pkg/metrics/metricstest"
+	_ "knative.dev/pkg/metrics/testing"
+)
+
+func TestReportRequest(t *testing.T) {
+	t.Cleanup(resetMetrics)
+
+	r := NewReporter(testEnvConfig())
+
+	r.ReportRequest(401, 64)
+	r.ReportRequest(401, 1024)
+	r.ReportAuthFailure()
+
+	metricstest.CheckCountData(t, requestCountM.Name(), map[string]string{
+		metricskey.LabelNamespaceName:     "testns",
+		metricskey.LabelName:              "test",
+		metricskey.LabelResourceGroup:     "testsources.example.com",
+		metricskey.LabelResponseCode:      "401",
+		metricskey.LabelResponseCodeClass: "4xx",
+	}, 2)
+	metricstest.CheckDistributionData(t, requestSizeM.Name(), map[string]string{
+		metricskey.LabelNamespaceName: "testns",
+		metricskey.LabelName:          "test",
+		metricskey.LabelResourceGroup: "testsources.example.com",
+	}, 2, 64, 1024)
+	metricstest.CheckCountData(t, authFailureCountM.Name(), map[string]string{
+		metricskey.LabelNamespaceName: "testns",
+		metricskey.LabelName:          "test",
+		metricskey.LabelResourceGroup: "testsources.example.com",
+	}, 1)
+}
+
+func TestNilReporter(t *testing.T) {
+	t.Cleanup(resetMetrics)
+
+	var r *Reporter
+
+	r.ReportRequest(200, 64)
+	r.ReportAuthFailure()
+
+	metricstest.CheckStatsNotReported(t, requestCountM.Name(), requestSizeM.Name(), authFailureCountM.Name())
+
+	c := &stubClient{}
+	assert.Same(t, c, InstrumentClient(c, nil))
+}
+
+func TestInstrumentClient(t *testing.T) {
+	e := cloudevents.NewEvent()
+	e.SetType("com.example.test")
+
+	tagsWithResult := func(result string) map[string]string {
+		return map[string]string{
+			metricskey.LabelNamespaceName: "testns",
+			metricskey.LabelName:          "test",
+			metricskey.LabelResourceGroup: "testsources.example.com",
+			metricskey.LabelEventType:     "com.example.test",
+			resultKey.Name():              result,
+		}
+	}
+
+	t.Run("sent events", func(t *testing.T) {
+		t.Cleanup(resetMetrics)
+
+		r := NewReporter(testEnvConfig())
+		c := &stubClient{}
+
+		res := InstrumentClient(c, r).Send(context.Background(), e)
+		assert.True(t, cloudevents.IsACK(res))
+
+		assert.Equal(t, r.tag, adapter.MetricTagFromContext(c.lastCtx),
+			"The metric tag of the source should be passed to the client")
+
+		metricstest.CheckCountData(t, eventDispatchCountM.Name(), tagsWithResult(resultSent), 1)
+		metricstest.CheckDistributionCount(t, eventDispatchLatencyM.Name(), tagsWithResult(resultSent), 1)
+	})
+
+	t.Run("failed events", func(t *testing.T) {
+		t.Cleanup(resetMetrics)
+
+		r := NewReporter(testEnvConfig())
+		c := &stubClient{result: errors.New("fake error")}
+
+		// a metric tag set by the caller takes precedence
+		callerTag := &adapter.MetricTag{Name: "caller", Namespace: "callerns", ResourceGroup: "callergroup"}
+
+		_, res := InstrumentClient(c, r).Request(adapter.ContextWithMetricTag(context.Background(), callerTag), e)
+		assert.False(t, cloudevents.IsACK(res))
+
+		assert.Equal(t, callerTag, adapter.MetricTagFromContext(c.lastCtx),
+			"The metric tag set by the caller should be passed to the client")
+
+		metricstest.CheckCountData(t, eventDispatchCountM.Name(), tagsWithResult(resultFailed), 1)
+		metricstest.CheckDistributionCount(t, eventDispatchLatencyM.Name(), tagsWithResult(resultFailed), 1)
+	})
+}
+
+func TestReportFilteredEvent(t *testing.T) {
+	t.Cleanup(resetMetrics)
+
+	r := NewReporter(testEnvConfig())
+
+	r.ReportFilteredEvent("channel")
+
+	metricstest.CheckCountData(t, filteredEventCountM.Name(), map[string]string{
+		metricskey.LabelNamespaceName: "testns",
+		metricskey.LabelName:          "test",
+		metricskey.LabelResourceGroup: "testsources.example.com",
+		filterReasonKey.Name():        "channel",
+	}, 1)
+}
+
+func TestReportBuffer(t *testing.T) {
+	t.Cleanup(resetMetrics)
+
+	r := NewReporter(testEnvConfig())
+
+	r.ReportBufferDepth(3, 2048)
+	r.ReportBufferDepth(2, 1024)
+	r.ReportBufferDrop("expired")
+
+	tags := map[string]string{
+		metricskey.LabelNamespaceName: "testns",
+		metricskey.LabelName:          "test",
+		metricskey.LabelResourceGroup: "testsources.example.com",
+	}
+
+	metricstest.CheckLastValueData(t, bufferDepthM.Name(), tags, 2)
+	metricstest.CheckLastValueData(t, bufferSizeM.Name(), tags, 1024)
+
+	tags[dropReasonKey.Name()] = "expired"
+	metricstest.CheckCountData(t, bufferDropCountM.Name(), tags, 1)
+}
+
+func testEnvConfig() adapter.EnvConfig {
+	return adapter.EnvConfig{
+		Namespace:     "testns",
+		Name:          "test",
+		ResourceGroup: "testsources.example.com",
+	}
+}
+
+// resetMetrics clears the data recorded by the views of the package.
+func resetMetrics() {
+	metricstest.Unregister(
+		requestCountM.Name(),
+		authFailureCountM.Name(),
+		requestSizeM.Name(),
+		eventDispatchCountM.Name(),
+		eventDispatchLatencyM.Name(),
+		filteredEventCountM.Name(),
+		bufferDepthM.Name(),
+		bufferSizeM.Name(),
+		bufferDropCountM.Name(),
+	)
+	mustRegisterStatsViews()
+}
+
+// stubClient is a cloudevents.Client which returns a preset result for every
+// event sent.
+type stubClient struct {
+	cloudevents.Client
+
+	result  protocol.Result
+	lastCtx context.Context
+}
+
+// Send implements cloudevents.Client.
+func (c *stubClient) Send(ctx context.Context, _ event.Event) protocol.Result {
+	c.lastCtx = ctx
+	return c.result
+}
+
+// Request implements cloudevents.Client.
+func (c *stubClient) Request(ctx context.Context, _ event.Event) (*event.Event, protocol.Result) {
+	c.lastCtx = ctx
+	return nil, c.result
+}
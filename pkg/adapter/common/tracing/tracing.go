@@ -0,0 +1,139 @@
+/*
+Copyright (c) 2020 TriggerMesh Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package tracing propagates the trace context of webhook requests to the
+// events sent by adapters.
+package tracing
+
+import (
+	"context"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/cloudevents/sdk-go/v2/event"
+	"github.com/cloudevents/sdk-go/v2/extensions"
+	"github.com/cloudevents/sdk-go/v2/protocol"
+	"go.opencensus.io/trace"
+)
+
+// Names of the spans created by adapters.
+const (
+	// VerifySpanName is the name of the span around the verification of
+	// the authenticity of a webhook request.
+	VerifySpanName = "webhook.verify"
+	// SendSpanName is the name of the span around the delivery of an
+	// event to the sink.
+	SendSpanName = "event.send"
+	// DeliverSpanName is the name of the span around the delayed delivery
+	// of an event which was previously sent to a buffer.
+	DeliverSpanName = "event.deliver"
+)
+
+// Attributes of the spans created around the delivery of events.
+const (
+	attrEventType   = "cloudevents.type"
+	attrEventSource = "cloudevents.source"
+	attrEventID     = "cloudevents.id"
+)
+
+// StartVerifySpan starts a span around the verification of a webhook request.
+func StartVerifySpan(ctx context.Context) (context.Context, *trace.Span) {
+	return trace.StartSpan(ctx, VerifySpanName)
+}
+
+// EndSpan ends the given span, with a status reflecting the given error.
+func EndSpan(span *trace.Span, err error) {
+	if err != nil {
+		span.SetStatus(trace.Status{
+			Code:    trace.StatusCodeUnknown,
+			Message: err.Error(),
+		})
+	}
+	span.End()
+}
+
+// StartDeliverSpan starts a span around the delayed delivery of the given
+// event. The span continues the trace carried by the event's tracing
+// extension, if any, so that the trace of the webhook request which
+// originated the event continues at the sink.
+func StartDeliverSpan(ctx context.Context, e event.Event) (context.Context, *trace.Span) {
+	if ext, ok := extensions.GetDistributedTracingExtension(e); ok {
+		if sc, err := ext.ToSpanContext(); err == nil {
+			return trace.StartSpanWithRemoteParent(ctx, DeliverSpanName, sc,
+				trace.WithSpanKind(trace.SpanKindClient))
+		}
+	}
+	return trace.StartSpan(ctx, DeliverSpanName, trace.WithSpanKind(trace.SpanKindClient))
+}
+
+// InstrumentClient returns a CloudEvents client which sends events within a
+// span. The trace context of that span is carried by the "traceparent"
+// extension of sent events, in addition to the tracing headers set by the
+// client's HTTP transport, so that traces continue at the sink.
+func InstrumentClient(c cloudevents.Client) cloudevents.Client {
+	return &instrumentedClient{Client: c}
+}
+
+// instrumentedClient is a cloudevents.Client which traces the events it
+// sends.
+type instrumentedClient struct {
+	cloudevents.Client
+}
+
+var _ cloudevents.Client = (*instrumentedClient)(nil)
+
+// Send implements cloudevents.Client.
+func (c *instrumentedClient) Send(ctx context.Context, e event.Event) protocol.Result {
+	ctx, span := startSendSpan(ctx, &e)
+	res := c.Client.Send(ctx, e)
+	endSendSpan(span, res)
+	return res
+}
+
+// Request implements cloudevents.Client.
+func (c *instrumentedClient) Request(ctx context.Context, e event.Event) (*event.Event, protocol.Result) {
+	ctx, span := startSendSpan(ctx, &e)
+	resp, res := c.Client.Request(ctx, e)
+	endSendSpan(span, res)
+	return resp, res
+}
+
+// startSendSpan starts a span around the delivery of the given event, and
+// sets the trace context of that span as the event's tracing extension.
+func startSendSpan(ctx context.Context, e *event.Event) (context.Context, *trace.Span) {
+	ctx, span := trace.StartSpan(ctx, SendSpanName, trace.WithSpanKind(trace.SpanKindClient))
+	if span.IsRecordingEvents() {
+		span.AddAttributes(
+			trace.StringAttribute(attrEventType, e.Type()),
+			trace.StringAttribute(attrEventSource, e.Source()),
+			trace.StringAttribute(attrEventID, e.ID()),
+		)
+	}
+
+	// the event's context is shared with the caller's copy of the event
+	e.Context = e.Context.Clone()
+	extensions.FromSpanContext(span.SpanContext()).AddTracingAttributes(e)
+
+	return ctx, span
+}
+
+// endSendSpan ends the given span, with a status reflecting the given result.
+func endSendSpan(span *trace.Span, res protocol.Result) {
+	if cloudevents.IsACK(res) {
+		span.End()
+		return
+	}
+	EndSpan(span, res)
+}
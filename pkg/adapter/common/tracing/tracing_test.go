@@ -0,0 +1,92 @@
+/*
+Copyright (c) 2020 TriggerMesh Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tracing
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/cloudevents/sdk-go/v2/event"
+	"github.com/cloudevents/sdk-go/v2/extensions"
+	"github.com/cloudevents/sdk-go/v2/protocol"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opencensus.io/trace"
+)
+
+func TestInstrumentClient(t *testing.T) {
+	ctx, parent := trace.StartSpan(context.Background(), "webhook", trace.WithSampler(trace.AlwaysSample()))
+	defer parent.End()
+
+	tc := map[string]struct {
+		result protocol.Result
+	}{
+		"sent event": {
+			result: nil,
+		},
+		"failed event": {
+			result: errors.New("fake error"),
+		},
+	}
+
+	for name, c := range tc {
+		//nolint:scopelint
+		t.Run(name, func(t *testing.T) {
+			cli := &stubClient{result: c.result}
+
+			e := cloudevents.NewEvent()
+			e.SetType("com.example.test")
+
+			res := InstrumentClient(cli).Send(ctx, e)
+			assert.Equal(t, c.result, res)
+
+			_, hasExt := e.Extensions()[extensions.TraceParentExtension]
+			assert.False(t, hasExt, "The caller's event should not be mutated")
+
+			sentSpan := trace.FromContext(cli.lastCtx)
+			require.NotNil(t, sentSpan, "The event should be sent within a span")
+			assert.Equal(t, parent.SpanContext().TraceID, sentSpan.SpanContext().TraceID,
+				"The span should belong to the trace of the caller")
+
+			ext, ok := extensions.GetDistributedTracingExtension(cli.lastEvent)
+			require.True(t, ok, "The sent event should carry a tracing extension")
+
+			sc, err := ext.ToSpanContext()
+			require.NoError(t, err)
+			assert.Equal(t, sentSpan.SpanContext().TraceID, sc.TraceID)
+			assert.Equal(t, sentSpan.SpanContext().SpanID, sc.SpanID)
+		})
+	}
+}
+
+// stubClient is a cloudevents.Client which returns a preset result for every
+// event sent.
+type stubClient struct {
+	cloudevents.Client
+
+	result    protocol.Result
+	lastCtx   context.Context
+	lastEvent event.Event
+}
+
+// Send implements cloudevents.Client.
+func (c *stubClient) Send(ctx context.Context, e event.Event) protocol.Result {
+	c.lastCtx, c.lastEvent = ctx, e
+	return c.result
+}
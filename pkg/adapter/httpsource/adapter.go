@@ -23,21 +23,39 @@ import (
 
 	"knative.dev/eventing/pkg/adapter/v2"
 	"knative.dev/pkg/logging"
+
+	"github.com/triggermesh/knative-sources/pkg/adapter/common/buffer"
+	"github.com/triggermesh/knative-sources/pkg/adapter/common/stats"
+	"github.com/triggermesh/knative-sources/pkg/adapter/common/tracing"
 )
 
 // NewAdapter implementation
 func NewAdapter(ctx context.Context, aEnv adapter.EnvConfigAccessor, ceClient cloudevents.Client) adapter.Adapter {
 	env := aEnv.(*envAccessor)
+	logger := logging.FromContext(ctx)
+
+	reporter := stats.NewReporter(env.EnvConfig)
+	ceClient = stats.InstrumentClient(ceClient, reporter)
 
-	return &httpHandler{
+	// buffered events are acknowledged before being delivered
+	var buf *buffer.Client
+	if env.Buffer.Enabled() {
+		buf = buffer.NewClient(env.Buffer, ceClient, reporter, logger.Named("buffer"))
+		ceClient = buf
+	}
+	ceClient = tracing.InstrumentClient(ceClient)
+
+	return buffer.WithDelivery(&httpHandler{
 		eventType:   env.EventType,
 		eventSource: env.EventSource,
 
 		username: env.BasicAuthUsername,
 		password: env.BasicAuthPassword,
 		ceClient: ceClient,
-		logger:   logging.FromContext(ctx),
-	}
+		srvCfg:   env.Config,
+		reporter: reporter,
+		logger:   logger,
+	}, buf)
 }
 
 var _ adapter.Adapter = (*httpHandler)(nil)
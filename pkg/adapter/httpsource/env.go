@@ -18,6 +18,9 @@ package httpsource
 
 import (
 	"knative.dev/eventing/pkg/adapter/v2"
+
+	"github.com/triggermesh/knative-sources/pkg/adapter/common/buffer"
+	"github.com/triggermesh/knative-sources/pkg/adapter/common/server"
 )
 
 // EnvAccessor for configuration parameters
@@ -27,6 +30,11 @@ func EnvAccessor() adapter.EnvConfigAccessor {
 
 type envAccessor struct {
 	adapter.EnvConfig
+	server.Config
+
+	// Events are queued in an on-disk buffer before being delivered to
+	// the sink when a buffer directory is set.
+	Buffer buffer.Config
 
 	EventType         string `envconfig:"HTTP_EVENT_TYPE" required:"true"`
 	EventSource       string `envconfig:"HTTP_EVENT_SOURCE" required:"true"`
@@ -20,19 +20,16 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"io/ioutil"
 	"net/http"
-	"time"
 
 	"github.com/google/uuid"
 
 	cloudevents "github.com/cloudevents/sdk-go/v2"
 	"go.uber.org/zap"
-)
 
-const (
-	serverPort                = "8080"
-	serverShutdownGracePeriod = time.Second * 10
+	"github.com/triggermesh/knative-sources/pkg/adapter/common/server"
+	"github.com/triggermesh/knative-sources/pkg/adapter/common/stats"
+	"github.com/triggermesh/knative-sources/pkg/adapter/common/tracing"
 )
 
 type httpHandler struct {
@@ -43,7 +40,8 @@ type httpHandler struct {
 	password string
 
 	ceClient cloudevents.Client
-	srv      *http.Server
+	srvCfg   server.Config
+	reporter *stats.Reporter
 
 	logger *zap.SugaredLogger
 }
@@ -51,29 +49,7 @@ type httpHandler struct {
 // Start the server for receiving Http events. Will block until the stop channel closes.
 func (h *httpHandler) Start(ctx context.Context) error {
 	h.logger.Info("Starting Http event handler...")
-
-	m := http.NewServeMux()
-	m.HandleFunc("/", h.handleAll)
-	http.HandleFunc("/health", healthCheckHandler)
-
-	h.srv = &http.Server{
-		Addr:    ":" + serverPort,
-		Handler: m,
-	}
-
-	done := make(chan bool, 1)
-	go h.gracefulShutdown(ctx.Done(), done)
-
-	h.logger.Infof("Http Source is ready to handle requests at %s", h.srv.Addr)
-	if err := h.srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-		// if an error occurs listening we don't want a graceful shutdown, the
-		// server is not serving requests. Return and let the done channel die.
-		return fmt.Errorf("could not listen on %s: %w", h.srv.Addr, err)
-	}
-
-	<-done
-	h.logger.Infof("Server stopped")
-	return nil
+	return server.New(h.srvCfg, http.HandlerFunc(h.handleAll), h.reporter, h.logger.Named("server")).Run(ctx)
 }
 
 // handleAll receives all Http events at a single resource, it
@@ -84,22 +60,17 @@ func (h *httpHandler) handleAll(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if h.username != "" && h.password != "" {
-		us, ps, ok := r.BasicAuth()
-		if !ok {
-			h.handleError(errors.New("Wrong authentication header"), http.StatusBadRequest, w)
-			return
-		}
-		if us != h.username || ps != h.password {
-			h.handleError(errors.New("Credentials are not valid"), http.StatusUnauthorized, w)
-			return
-		}
+	_, span := tracing.StartVerifySpan(r.Context())
+	code, err := h.authenticate(r)
+	tracing.EndSpan(span, err)
+	if err != nil {
+		h.handleError(err, code, w)
+		return
 	}
 
-	defer r.Body.Close()
-	body, err := ioutil.ReadAll(r.Body)
+	body, code, err := server.ReadBody(r)
 	if err != nil {
-		h.handleError(err, http.StatusInternalServerError, w)
+		h.handleError(err, code, w)
 		return
 	}
 
@@ -113,34 +84,35 @@ func (h *httpHandler) handleAll(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if result := h.ceClient.Send(context.Background(), event); !cloudevents.IsACK(result) {
+	if result := h.ceClient.Send(r.Context(), event); !cloudevents.IsACK(result) {
 		h.handleError(fmt.Errorf("could not send Cloud Event: %w", result), http.StatusInternalServerError, w)
+		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 }
 
-func (h *httpHandler) handleError(err error, code int, w http.ResponseWriter) {
-	h.logger.Error("An error ocurred", zap.Error(err))
-	http.Error(w, err.Error(), code)
-}
-
-func healthCheckHandler(w http.ResponseWriter, _ *http.Request) {
-	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
-	w.WriteHeader(http.StatusOK)
-}
+// authenticate verifies the Basic Authentication credentials of the given
+// request, if the handler requires any. When an error is returned, the
+// returned status code is the one the request should be responded with.
+func (h *httpHandler) authenticate(r *http.Request) (int, error) {
+	if h.username == "" || h.password == "" {
+		return http.StatusOK, nil
+	}
 
-func (h *httpHandler) gracefulShutdown(stopCh <-chan struct{}, done chan<- bool) {
-	<-stopCh
-	h.logger.Info("Server is shutting down...")
+	us, ps, ok := r.BasicAuth()
+	if !ok {
+		return http.StatusBadRequest, errors.New("Wrong authentication header")
+	}
+	if us != h.username || ps != h.password {
+		return http.StatusUnauthorized, errors.New("Credentials are not valid")
+	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), serverShutdownGracePeriod)
-	defer cancel()
+	return http.StatusOK, nil
+}
 
-	h.srv.SetKeepAlivesEnabled(false)
-	if err := h.srv.Shutdown(ctx); err != nil {
-		h.logger.Fatalf("Could not gracefully shutdown the server: %v", err)
-	}
-	close(done)
+func (h *httpHandler) handleError(err error, code int, w http.ResponseWriter) {
+	h.logger.Error("An error ocurred", zap.Error(err))
+	server.Error(w, err, code)
 }
@@ -18,35 +18,136 @@ package slacksource
 
 import (
 	"context"
+	"os"
 
 	cloudevents "github.com/cloudevents/sdk-go/v2"
 	"go.uber.org/zap"
 
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+
 	"knative.dev/eventing/pkg/adapter/v2"
 	"knative.dev/pkg/logging"
-)
 
-const defaultListenPort = 8080
+	"github.com/triggermesh/knative-sources/pkg/adapter/common/buffer"
+	"github.com/triggermesh/knative-sources/pkg/adapter/common/stats"
+	"github.com/triggermesh/knative-sources/pkg/adapter/common/tracing"
+)
 
 // NewAdapter adapter implementation
 func NewAdapter(ctx context.Context, aEnv adapter.EnvConfigAccessor, ceClient cloudevents.Client) adapter.Adapter {
 	env := aEnv.(*envAccessor)
 	logger := logging.FromContext(ctx)
 
-	return &slackAdapter{
-		handler: NewSlackEventAPIHandler(ceClient, defaultListenPort, env.SigningSecret, env.AppID, standardTime{}, logger.Named("handler")),
-		logger:  logger,
+	reporter := stats.NewReporter(env.EnvConfig)
+	ceClient = stats.InstrumentClient(ceClient, reporter)
+
+	// buffered events are acknowledged before being delivered
+	var buf *buffer.Client
+	if env.Buffer.Enabled() {
+		buf = buffer.NewClient(env.Buffer, ceClient, reporter, logger.Named("buffer"))
+		ceClient = buf
+	}
+	ceClient = tracing.InstrumentClient(ceClient)
+
+	var enricher *eventEnricher
+	if env.Enrichment {
+		enricher = newEventEnricher(newSlackAPIClient(env.APIURL, env.BotToken), env.EnrichmentCacheTTL,
+			standardTime{}, logger.Named("enricher"))
+	}
+
+	// messages returned by the sink are posted to Slack on behalf of the
+	// app's bot user
+	var replier *replyPoster
+	if env.Replies && env.BotToken != "" {
+		replier = newReplyPoster(newSlackAPIClient(env.APIURL, env.BotToken), logger.Named("replier"))
 	}
+
+	// notifications about the Slack app are reported to the controller
+	// through a ConfigMap
+	var appStatus *appStatusReporter
+	if env.AppStatusConfigMap != "" {
+		appStatus = newAppStatusReporterInCluster(env.Namespace, env.AppStatusConfigMap, logger.Named("appstatus"))
+	}
+
+	handler := NewSlackEventAPIHandler(ceClient, env.Config, reporter, env.SigningSecret, env.AppID, slackAppsFromConfig(env.Apps),
+		newEventFilter(env.EventFilter), enricher, env.DeadLetterSink, replier, appStatus, standardTime{}, logger.Named("handler"))
+
+	// In Socket Mode, events are received over a WebSocket connection
+	// opened to Slack instead of the handler's HTTP endpoint.
+	if env.AppToken != "" {
+		handler = newSocketModeHandler(handler.(*slackEventAPIHandler), newSlackAPIClient(env.APIURL, env.AppToken),
+			logger.Named("socketmode"))
+	}
+
+	return buffer.WithDelivery(&slackAdapter{
+		handler:   handler,
+		appStatus: appStatus,
+		logger:    logger,
+	}, buf)
 }
 
 var _ adapter.Adapter = (*slackAdapter)(nil)
 
 type slackAdapter struct {
-	handler SlackEventAPIHandler
-	logger  *zap.SugaredLogger
+	handler   SlackEventAPIHandler
+	appStatus *appStatusReporter
+	logger    *zap.SugaredLogger
 }
 
 // Start runs the Slack handler.
 func (a *slackAdapter) Start(ctx context.Context) error {
+	if a.appStatus != nil {
+		go a.appStatus.run(ctx)
+	}
 	return a.handler.Start(ctx)
 }
+
+// newAppStatusReporterInCluster returns an appStatusReporter which reports to
+// the given ConfigMap using the in-cluster Kubernetes configuration, or nil
+// if the Kubernetes API can not be reached.
+func newAppStatusReporterInCluster(namespace, name string, logger *zap.SugaredLogger) *appStatusReporter {
+	cfg, err := rest.InClusterConfig()
+	if err != nil {
+		logger.Warnw("Unable to report the status of the Slack app, "+
+			"the Kubernetes API client can not be configured", zap.Error(err))
+		return nil
+	}
+
+	client, err := kubernetes.NewForConfig(cfg)
+	if err != nil {
+		logger.Warnw("Unable to report the status of the Slack app, "+
+			"the Kubernetes API client can not be created", zap.Error(err))
+		return nil
+	}
+
+	return newAppStatusReporter(client.CoreV1().ConfigMaps(namespace), name, logger)
+}
+
+// slackAppsFromConfig returns the given Slack apps indexed by app ID. The
+// signing secret of each app is read from the environment.
+func slackAppsFromConfig(cfg slackAppsConfig) map[string]*slackApp {
+	if len(cfg) == 0 {
+		return nil
+	}
+
+	apps := make(map[string]*slackApp, len(cfg))
+
+	for _, appCfg := range cfg {
+		app := &slackApp{
+			signingSecret: os.Getenv(envSlackAppSigningSecretPrefix + appCfg.AppID),
+			sink:          appCfg.Sink,
+		}
+
+		if len(appCfg.EventTypes) > 0 {
+			app.eventTypes = make(map[string]struct{}, len(appCfg.EventTypes))
+			for _, typ := range appCfg.EventTypes {
+				app.eventTypes[typ] = struct{}{}
+			}
+		}
+
+		apps[appCfg.AppID] = app
+	}
+
+	return apps
+}
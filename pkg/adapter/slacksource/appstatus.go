@@ -0,0 +1,144 @@
+/*
+Copyright (c) 2020 TriggerMesh Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package slacksource
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	coreclientv1 "k8s.io/client-go/kubernetes/typed/core/v1"
+
+	"github.com/triggermesh/knative-sources/pkg/apis/sources/v1alpha1"
+)
+
+const (
+	// size of the queue of pending patches
+	appStatusQueueSize = 16
+	// maximum duration of a single patch request
+	appStatusPatchTimeout = 10 * time.Second
+)
+
+// appStatusReporter reports the notifications received from Slack about the
+// app to the controller, by patching the app status ConfigMap of the source.
+// Patches are sent asynchronously and in order, so that reporting doesn't
+// delay the acknowledgement of Slack requests.
+type appStatusReporter struct {
+	cmClient coreclientv1.ConfigMapInterface
+	name     string
+
+	patches chan appStatusPatch
+
+	mu sync.Mutex
+	// whether previous notifications are known to be cleared from the
+	// ConfigMap. The state of the ConfigMap is unknown upon startup.
+	cleared bool
+
+	logger *zap.SugaredLogger
+}
+
+// appStatusPatch is a set of changes to the data of the app status
+// ConfigMap. Nil values remove their key.
+type appStatusPatch map[string]*string
+
+// newAppStatusReporter returns an appStatusReporter which reports to the
+// given ConfigMap.
+func newAppStatusReporter(cmClient coreclientv1.ConfigMapInterface, name string,
+	logger *zap.SugaredLogger) *appStatusReporter {
+
+	return &appStatusReporter{
+		cmClient: cmClient,
+		name:     name,
+		patches:  make(chan appStatusPatch, appStatusQueueSize),
+		logger:   logger,
+	}
+}
+
+// run sends queued patches until the given context is cancelled.
+func (r *appStatusReporter) run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case p := <-r.patches:
+			r.send(ctx, p)
+		}
+	}
+}
+
+// notify reports a notification of the given kind, which occurred at the
+// given time.
+func (r *appStatusReporter) notify(key string, t time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	ts := t.UTC().Format(time.RFC3339)
+	r.cleared = false
+	r.enqueue(appStatusPatch{key: &ts})
+}
+
+// eventReceived reports that Slack delivers events to the app, which clears
+// all previous notifications.
+func (r *appStatusReporter) eventReceived() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.cleared {
+		return
+	}
+
+	r.cleared = r.enqueue(appStatusPatch{
+		v1alpha1.SlackAppStatusRateLimitedKey:   nil,
+		v1alpha1.SlackAppStatusUninstalledKey:   nil,
+		v1alpha1.SlackAppStatusTokensRevokedKey: nil,
+	})
+}
+
+// enqueue queues the given patch, and returns whether it was queued.
+// Patches are dropped when the queue is full.
+func (r *appStatusReporter) enqueue(p appStatusPatch) bool {
+	select {
+	case r.patches <- p:
+		return true
+	default:
+		r.logger.Warn("Dropping app status update, too many pending updates")
+		return false
+	}
+}
+
+// send applies the given patch to the app status ConfigMap.
+func (r *appStatusReporter) send(ctx context.Context, p appStatusPatch) {
+	// marshaling a map of strings can not fail
+	patch, _ := json.Marshal(map[string]interface{}{"data": p})
+
+	ctx, cancel := context.WithTimeout(ctx, appStatusPatchTimeout)
+	defer cancel()
+
+	if _, err := r.cmClient.Patch(ctx, r.name, types.MergePatchType, patch, metav1.PatchOptions{}); err != nil {
+		r.logger.Errorw("Failed to update app status ConfigMap", zap.Error(err))
+
+		// clear notifications again upon the next event
+		r.mu.Lock()
+		r.cleared = false
+		r.mu.Unlock()
+	}
+}
@@ -0,0 +1,173 @@
+/*
+Copyright (c) 2020 TriggerMesh Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package slacksource
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	cloudeventst "github.com/cloudevents/sdk-go/v2/client/test"
+	"github.com/stretchr/testify/assert"
+	zapt "go.uber.org/zap/zaptest"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/triggermesh/knative-sources/pkg/apis/sources/v1alpha1"
+)
+
+func TestAppStatusReporter(t *testing.T) {
+	const ns, name = "test", "my-source-slack-app-status"
+
+	kubeClient := fake.NewSimpleClientset(&corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: ns,
+			Name:      name,
+		},
+	})
+	cmClient := kubeClient.CoreV1().ConfigMaps(ns)
+
+	r := newAppStatusReporter(cmClient, name, zapt.NewLogger(t).Sugar())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go r.run(ctx)
+
+	cmData := func() map[string]string {
+		cm, err := cmClient.Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			t.Fatalf("Error getting ConfigMap: %s", err)
+		}
+		return cm.Data
+	}
+
+	r.notify(v1alpha1.SlackAppStatusUninstalledKey, time.Unix(1605002400, 0))
+
+	assert.Eventually(t, func() bool {
+		return cmData()[v1alpha1.SlackAppStatusUninstalledKey] == "2020-11-10T10:00:00Z"
+	}, time.Second, 10*time.Millisecond, "notification was not reported")
+
+	r.eventReceived()
+
+	assert.Eventually(t, func() bool {
+		return len(cmData()) == 0
+	}, time.Second, 10*time.Millisecond, "notifications were not cleared")
+
+	patches := func() int {
+		var n int
+		for _, a := range kubeClient.Actions() {
+			if a.GetVerb() == "patch" {
+				n++
+			}
+		}
+		return n
+	}
+
+	r.eventReceived()
+
+	assert.Never(t, func() bool {
+		return patches() != 2
+	}, 100*time.Millisecond, 10*time.Millisecond, "cleared notifications should not be cleared again")
+}
+
+func TestSlackAppNotifications(t *testing.T) {
+	tc := map[string]struct {
+		body string
+
+		expectedType  string
+		expectedPatch appStatusPatch
+	}{
+		"rate limited": {
+			body: `{"token":"XXYYZZ","type":"app_rate_limited","team_id":"TXXXXXXXX","minute_rate_limited":1605002400,"api_app_id":"A0000000001"}`,
+
+			expectedType: v1alpha1.SlackAppRateLimitedEventType,
+			expectedPatch: appStatusPatch{
+				v1alpha1.SlackAppStatusRateLimitedKey: strPtr("2020-11-10T10:00:00Z"),
+			},
+		},
+		"app uninstalled": {
+			body: `{"team_id":"TXXXXXXXX","api_app_id":"A0000000001","event":{"type":"app_uninstalled"},"type":"event_callback","event_id":"Ev08MFMKH6","event_time":1605002700}`,
+
+			expectedType: v1alpha1.SlackAppUninstalledEventType,
+			expectedPatch: appStatusPatch{
+				v1alpha1.SlackAppStatusUninstalledKey: strPtr("2020-11-10T10:05:00Z"),
+			},
+		},
+		"tokens revoked": {
+			body: `{"team_id":"TXXXXXXXX","api_app_id":"A0000000001","event":{"type":"tokens_revoked","tokens":{"bot":["UXXXXXXXX"]}},"type":"event_callback","event_id":"Ev08MFMKH6","event_time":1605002700}`,
+
+			expectedType: v1alpha1.SlackTokensRevokedEventType,
+			expectedPatch: appStatusPatch{
+				v1alpha1.SlackAppStatusTokensRevokedKey: strPtr("2020-11-10T10:05:00Z"),
+			},
+		},
+		"other event": {
+			body: `{"team_id":"TXXXXXXXX","api_app_id":"A0000000001","event":{"type":"message"},"type":"event_callback","event_id":"Ev08MFMKH6","event_time":1605002700}`,
+
+			expectedType: v1alpha1.SlackGenericEventType,
+			expectedPatch: appStatusPatch{
+				v1alpha1.SlackAppStatusRateLimitedKey:   nil,
+				v1alpha1.SlackAppStatusUninstalledKey:   nil,
+				v1alpha1.SlackAppStatusTokensRevokedKey: nil,
+			},
+		},
+	}
+
+	for name, c := range tc {
+		t.Run(name, func(t *testing.T) {
+			logger := zapt.NewLogger(t).Sugar()
+
+			ceClient, chEvent := cloudeventst.NewMockSenderClient(t, 1)
+
+			// patches are queued but never sent
+			appStatus := newAppStatusReporter(nil, "", logger)
+
+			handler := &slackEventAPIHandler{
+				appStatus: appStatus,
+				ceClient:  ceClient,
+				logger:    logger,
+				time:      standardTime{},
+			}
+
+			req, _ := http.NewRequest("POST", "/", read(c.body))
+
+			rr := httptest.NewRecorder()
+			http.HandlerFunc(handler.handleAll).ServeHTTP(rr, req)
+
+			assert.Equal(t, http.StatusOK, rr.Code, "unexpected response code")
+
+			select {
+			case event := <-chEvent:
+				assert.Equal(t, c.expectedType, event.Type())
+				assert.Equal(t, "TXXXXXXXX", event.Source())
+			case <-time.After(1 * time.Second):
+				assert.Fail(t, "expected cloud event was not sent")
+			}
+
+			select {
+			case p := <-appStatus.patches:
+				assert.Equal(t, c.expectedPatch, p)
+			default:
+				assert.Fail(t, "expected app status update was not queued")
+			}
+		})
+	}
+}
@@ -0,0 +1,206 @@
+/*
+Copyright (c) 2020 TriggerMesh Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package slacksource
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"go.uber.org/zap"
+)
+
+// CloudEvent extensions set by the enrichment of Slack events.
+const (
+	userNameCeExtension    = "comslackusername"
+	userEmailCeExtension   = "comslackuseremail"
+	channelNameCeExtension = "comslackchannelname"
+)
+
+// enrichmentBudget is the maximum time spent resolving the attributes of a
+// single event. Slack expects events to be acknowledged within 3 seconds,
+// which also has to accommodate the delivery of the event to the sink.
+const enrichmentBudget = 1 * time.Second
+
+// maxCacheEntries is the maximum number of entries kept by each cache of the
+// eventEnricher.
+const maxCacheEntries = 10000
+
+// eventEnricher resolves the Slack IDs contained in events to
+// human-readable attributes using the Slack Web API.
+type eventEnricher struct {
+	api *slackAPIClient
+
+	users    *ttlCache
+	channels *ttlCache
+
+	logger *zap.SugaredLogger
+}
+
+// newEventEnricher returns an eventEnricher which caches the details of
+// Slack users and channels for the given duration.
+func newEventEnricher(api *slackAPIClient, cacheTTL time.Duration, tw timeWrap, logger *zap.SugaredLogger) *eventEnricher {
+	return &eventEnricher{
+		api:      api,
+		users:    newTTLCache(cacheTTL, maxCacheEntries, tw),
+		channels: newTTLCache(cacheTTL, maxCacheEntries, tw),
+		logger:   logger,
+	}
+}
+
+// enrich sets extensions on the given CloudEvent with the details of the
+// user and channel the Slack event refers to. Enrichment is best effort,
+// failures to resolve IDs are logged and do not prevent the event from being
+// sent. The Web API calls made for a given event share a time budget of
+// enrichmentBudget.
+func (e *eventEnricher) enrich(ctx context.Context, se SlackEvent, event *cloudevents.Event) {
+	ctx, cancel := context.WithTimeout(ctx, enrichmentBudget)
+	defer cancel()
+
+	if userID := se.User(); userID != "" {
+		if user := e.user(ctx, userID); user != nil {
+			event.SetExtension(userNameCeExtension, user.Name)
+			if user.Profile.Email != "" {
+				event.SetExtension(userEmailCeExtension, user.Profile.Email)
+			}
+		}
+	}
+
+	if channelID := se.Channel(); channelID != "" {
+		if channel := e.channel(ctx, channelID); channel != nil && channel.Name != "" {
+			event.SetExtension(channelNameCeExtension, channel.Name)
+		}
+	}
+}
+
+// user returns the details of the Slack user with the given ID.
+func (e *eventEnricher) user(ctx context.Context, id string) *slackUser {
+	if u, ok := e.users.get(id); ok {
+		return u.(*slackUser)
+	}
+
+	u, err := e.api.usersInfo(ctx, id)
+	if err != nil {
+		e.logger.Warnw("Unable to resolve Slack user", zap.String("user", id), zap.Error(err))
+		return nil
+	}
+
+	e.users.set(id, u)
+	return u
+}
+
+// channel returns the details of the Slack channel with the given ID.
+func (e *eventEnricher) channel(ctx context.Context, id string) *slackChannel {
+	if ch, ok := e.channels.get(id); ok {
+		return ch.(*slackChannel)
+	}
+
+	ch, err := e.api.conversationsInfo(ctx, id)
+	if err != nil {
+		e.logger.Warnw("Unable to resolve Slack channel", zap.String("channel", id), zap.Error(err))
+		return nil
+	}
+
+	e.channels.set(id, ch)
+	return ch
+}
+
+// ttlCache is a key-value cache in which entries expire after a fixed
+// duration. The number of entries is bounded, and the entries which expire
+// the soonest are evicted first once that bound is reached.
+type ttlCache struct {
+	ttl        time.Duration
+	maxEntries int
+	time       timeWrap
+
+	mu      sync.Mutex
+	entries map[string]ttlCacheEntry
+}
+
+type ttlCacheEntry struct {
+	value   interface{}
+	expires time.Time
+}
+
+// newTTLCache returns an empty ttlCache which holds at most maxEntries
+// entries.
+func newTTLCache(ttl time.Duration, maxEntries int, tw timeWrap) *ttlCache {
+	return &ttlCache{
+		ttl:        ttl,
+		maxEntries: maxEntries,
+		time:       tw,
+		entries:    make(map[string]ttlCacheEntry),
+	}
+}
+
+// get returns the value stored at the given key if it hasn't expired.
+func (c *ttlCache) get(key string) (interface{}, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+
+	if c.time.Now().After(entry.expires) {
+		delete(c.entries, key)
+		return nil, false
+	}
+
+	return entry.value, true
+}
+
+// set stores a value at the given key.
+func (c *ttlCache) set(key string, value interface{}) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, exists := c.entries[key]; !exists && len(c.entries) >= c.maxEntries {
+		c.evict()
+	}
+
+	c.entries[key] = ttlCacheEntry{
+		value:   value,
+		expires: c.time.Now().Add(c.ttl),
+	}
+}
+
+// evict removes all expired entries from the cache, or the entry which
+// expires the soonest if none has expired yet. The caller must hold the
+// lock of the cache.
+func (c *ttlCache) evict() {
+	now := c.time.Now()
+
+	var oldestKey string
+	var oldest time.Time
+
+	for k, e := range c.entries {
+		if now.After(e.expires) {
+			delete(c.entries, k)
+			continue
+		}
+		if oldestKey == "" || e.expires.Before(oldest) {
+			oldestKey, oldest = k, e.expires
+		}
+	}
+
+	if len(c.entries) >= c.maxEntries {
+		delete(c.entries, oldestKey)
+	}
+}
@@ -0,0 +1,148 @@
+/*
+Copyright (c) 2020 TriggerMesh Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package slacksource
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/stretchr/testify/assert"
+	zapt "go.uber.org/zap/zaptest"
+)
+
+const tBotToken = "xoxb-test"
+
+func TestEventEnricher(t *testing.T) {
+	logger := zapt.NewLogger(t).Sugar()
+
+	calls := make(map[string]int)
+	slackAPI := httptest.NewServer(fakeSlackAPI(t, calls))
+	defer slackAPI.Close()
+
+	tw := &mockedTime{time.Unix(1593192796, 0)}
+
+	e := newEventEnricher(newSlackAPIClient(slackAPI.URL, tBotToken), time.Minute, tw, logger)
+
+	enrich := func(se SlackEvent) cloudevents.Event {
+		event := cloudevents.NewEvent()
+		e.enrich(context.Background(), se, &event)
+		return event
+	}
+
+	event := enrich(SlackEvent{"type": "message", "user": "U1", "channel": "C1"})
+	assert.Equal(t, "jdoe", event.Extensions()[userNameCeExtension])
+	assert.Equal(t, "jdoe@example.com", event.Extensions()[userEmailCeExtension])
+	assert.Equal(t, "general", event.Extensions()[channelNameCeExtension])
+
+	event = enrich(SlackEvent{"type": "message", "user": "U1", "channel": "C1"})
+	assert.Equal(t, "jdoe", event.Extensions()[userNameCeExtension])
+	assert.Equal(t, 1, calls["/users.info"], "user details should be cached")
+	assert.Equal(t, 1, calls["/conversations.info"], "channel details should be cached")
+
+	tw.t = tw.t.Add(2 * time.Minute)
+
+	_ = enrich(SlackEvent{"type": "message", "user": "U1", "channel": "C1"})
+	assert.Equal(t, 2, calls["/users.info"], "cached user details should expire")
+	assert.Equal(t, 2, calls["/conversations.info"], "cached channel details should expire")
+
+	event = enrich(SlackEvent{"type": "message", "user": "U404"})
+	assert.NotContains(t, event.Extensions(), userNameCeExtension, "unknown users should not be enriched")
+}
+
+func TestTTLCacheBound(t *testing.T) {
+	tw := &mockedTime{time.Unix(1593192796, 0)}
+
+	c := newTTLCache(time.Minute, 2, tw)
+
+	c.set("a", 1)
+	tw.t = tw.t.Add(time.Second)
+	c.set("b", 2)
+	tw.t = tw.t.Add(time.Second)
+
+	c.set("c", 3)
+	assert.Len(t, c.entries, 2)
+	_, ok := c.get("a")
+	assert.False(t, ok, "the entry expiring the soonest should be evicted")
+
+	tw.t = tw.t.Add(2 * time.Minute)
+
+	c.set("d", 4)
+	assert.Len(t, c.entries, 1, "expired entries should be swept")
+	v, ok := c.get("d")
+	assert.True(t, ok)
+	assert.Equal(t, 4, v)
+}
+
+func TestSlackAPIClientErrors(t *testing.T) {
+	slackAPI := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "30")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer slackAPI.Close()
+
+	_, err := newSlackAPIClient(slackAPI.URL, tBotToken).usersInfo(context.Background(), "U1")
+
+	apiErr, ok := err.(*slackAPIError)
+	if !ok {
+		t.Fatalf("Expected a *slackAPIError, got %T: %v", err, err)
+	}
+	assert.Equal(t, http.StatusTooManyRequests, apiErr.StatusCode)
+	assert.Equal(t, 30*time.Second, apiErr.RetryAfter)
+}
+
+// fakeSlackAPI returns a http.Handler which simulates the Slack Web API and
+// counts the calls to each method.
+func fakeSlackAPI(t *testing.T, calls map[string]int) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls[r.URL.Path]++
+
+		if r.Header.Get("Authorization") != "Bearer "+tBotToken {
+			_, _ = w.Write([]byte(`{"ok":false,"error":"invalid_auth"}`))
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+
+		var resp string
+
+		switch r.URL.Path {
+		case "/users.info":
+			if r.URL.Query().Get("user") != "U1" {
+				resp = `{"ok":false,"error":"user_not_found"}`
+				break
+			}
+			resp = `{"ok":true,"user":{"id":"U1","name":"jdoe","real_name":"John Doe","profile":{"email":"jdoe@example.com"}}}`
+
+		case "/conversations.info":
+			if r.URL.Query().Get("channel") != "C1" {
+				resp = `{"ok":false,"error":"channel_not_found"}`
+				break
+			}
+			resp = `{"ok":true,"channel":{"id":"C1","name":"general"}}`
+
+		default:
+			t.Errorf("Unexpected call to Slack API method %s", r.URL.Path)
+			resp = `{"ok":false,"error":"unknown_method"}`
+		}
+
+		_, _ = w.Write([]byte(resp))
+	})
+}
@@ -17,7 +17,14 @@ limitations under the License.
 package slacksource
 
 import (
+	"encoding/json"
+	"time"
+
 	"knative.dev/eventing/pkg/adapter/v2"
+
+	"github.com/triggermesh/knative-sources/pkg/adapter/common/buffer"
+	"github.com/triggermesh/knative-sources/pkg/adapter/common/server"
+	"github.com/triggermesh/knative-sources/pkg/apis/sources/v1alpha1"
 )
 
 // EnvAccessor for configuration parameters
@@ -27,6 +34,62 @@ func EnvAccessor() adapter.EnvConfigAccessor {
 
 type envAccessor struct {
 	adapter.EnvConfig
-	AppID         string `envconfig:"SLACK_APP_ID"`
-	SigningSecret string `envconfig:"SLACK_SIGNING_SECRET"`
+	server.Config
+
+	// Events are queued in an on-disk buffer before being delivered to
+	// the sink when a buffer directory is set.
+	Buffer buffer.Config
+
+	AppID         string             `envconfig:"SLACK_APP_ID"`
+	SigningSecret string             `envconfig:"SLACK_SIGNING_SECRET"`
+	Apps          slackAppsConfig    `envconfig:"SLACK_APPS"`
+	EventFilter   *eventFilterConfig `envconfig:"SLACK_EVENT_FILTER"`
+
+	// Destination of events which could not be delivered to the sink.
+	// Failed deliveries are reported to Slack for retry when unset.
+	DeadLetterSink string `envconfig:"SLACK_DEAD_LETTER_SINK"`
+
+	// ConfigMap through which notifications about the Slack app are
+	// reported to the controller
+	AppStatusConfigMap string `envconfig:"SLACK_APP_STATUS_CONFIGMAP"`
+
+	// Slack Web API
+	APIURL   string `envconfig:"SLACK_API_URL" default:"https://slack.com/api"`
+	BotToken string `envconfig:"SLACK_BOT_TOKEN"`
+	// Receive events in Socket Mode when set
+	AppToken string `envconfig:"SLACK_APP_TOKEN"`
+
+	// Post replies returned by the sink to Slack, using the bot token
+	Replies bool `envconfig:"SLACK_REPLIES_ENABLED"`
+
+	Enrichment         bool          `envconfig:"SLACK_ENRICHMENT_ENABLED"`
+	EnrichmentCacheTTL time.Duration `envconfig:"SLACK_ENRICHMENT_CACHE_TTL" default:"10m"`
+}
+
+// envSlackAppSigningSecretPrefix is the prefix of the env vars containing
+// the signing secret of each Slack app, suffixed with the app's ID.
+const envSlackAppSigningSecretPrefix = "SLACK_SIGNING_SECRET_"
+
+// slackAppConfig contains the settings of a Slack app sharing the adapter's
+// endpoint.
+type slackAppConfig struct {
+	AppID      string   `json:"appID"`
+	Sink       string   `json:"sink,omitempty"`
+	EventTypes []string `json:"eventTypes,omitempty"`
+}
+
+// slackAppsConfig is a list of Slack apps serialized to JSON.
+type slackAppsConfig []slackAppConfig
+
+// Decode implements envconfig.Decoder.
+func (c *slackAppsConfig) Decode(value string) error {
+	return json.Unmarshal([]byte(value), c)
+}
+
+// eventFilterConfig is a Slack event filter serialized to JSON.
+type eventFilterConfig v1alpha1.SlackEventFilter
+
+// Decode implements envconfig.Decoder.
+func (c *eventFilterConfig) Decode(value string) error {
+	return json.Unmarshal([]byte(value), c)
 }
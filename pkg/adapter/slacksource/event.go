@@ -28,6 +28,51 @@ func (e SlackEvent) Type() string {
 	return s.(string)
 }
 
+// Subtype of the event, if any.
+func (e SlackEvent) Subtype() string {
+	return e.stringField("subtype")
+}
+
+// Channel returns the ID of the channel the event refers to, if any.
+func (e SlackEvent) Channel() string {
+	if ch := e.stringField("channel"); ch != "" {
+		return ch
+	}
+
+	// events about items (e.g. reactions) reference the item's channel
+	if item, ok := e["item"].(map[string]interface{}); ok {
+		return SlackEvent(item).stringField("channel")
+	}
+
+	return ""
+}
+
+// User returns the ID of the user who generated the event, if any.
+func (e SlackEvent) User() string {
+	return e.stringField("user")
+}
+
+// ThreadTS returns the timestamp of the thread the event belongs to, or the
+// timestamp of the event itself, which starts a new thread when replied to.
+func (e SlackEvent) ThreadTS() string {
+	if ts := e.stringField("thread_ts"); ts != "" {
+		return ts
+	}
+	return e.stringField("ts")
+}
+
+// BotID returns the ID of the bot which generated the event, if any.
+func (e SlackEvent) BotID() string {
+	return e.stringField("bot_id")
+}
+
+// stringField returns the value of a field of the event if that field
+// exists and is a string.
+func (e SlackEvent) stringField(name string) string {
+	s, _ := e[name].(string)
+	return s
+}
+
 // SlackEventWrapper contains a common wrapper for all events.
 // See https://api.slack.com/types/event for reference.
 type SlackEventWrapper struct {
@@ -41,6 +86,36 @@ type SlackEventWrapper struct {
 	TeamID      string     `json:"team_id"`
 	Token       string     `json:"token"`
 	Type        string     `json:"type"`
+
+	// Enterprise Grid
+	// See https://api.slack.com/enterprise/apps/events for reference.
+	EnterpriseID       string               `json:"enterprise_id"`
+	ContextTeamID      string               `json:"context_team_id"`
+	IsExtSharedChannel bool                 `json:"is_ext_shared_channel"`
+	Authorizations     []SlackAuthorization `json:"authorizations"`
+
+	// Rate limiting
+	// See https://api.slack.com/events-api#rate_limiting for reference.
+	MinuteRateLimited int `json:"minute_rate_limited"`
+}
+
+// SlackAuthorization describes an installation of the app the event is
+// visible to.
+// See https://api.slack.com/changelog/2020-09-15-events-api-truncate-authed-users for reference.
+type SlackAuthorization struct {
+	EnterpriseID        string `json:"enterprise_id"`
+	TeamID              string `json:"team_id"`
+	UserID              string `json:"user_id"`
+	IsBot               bool   `json:"is_bot"`
+	IsEnterpriseInstall bool   `json:"is_enterprise_install"`
+}
+
+// SlackRateLimit is the payload of the notification sent by Slack when it
+// stops delivering events to the app because of rate limiting.
+type SlackRateLimit struct {
+	TeamID            string `json:"team_id"`
+	APIAppID          string `json:"api_app_id"`
+	MinuteRateLimited int    `json:"minute_rate_limited"`
 }
 
 // SlackChallenge contains the handshake challenge for
@@ -56,3 +131,18 @@ type SlackChallenge struct {
 type SlackChallengeResponse struct {
 	Challenge string `json:"challenge"`
 }
+
+// SlackInteraction contains the attributes common to all interactivity
+// payloads.
+// See https://api.slack.com/reference/interaction-payloads for reference.
+type SlackInteraction struct {
+	APIAppID  string `json:"api_app_id"`
+	TriggerID string `json:"trigger_id"`
+	Type      string `json:"type"`
+	Team      struct {
+		ID string `json:"id"`
+	} `json:"team"`
+	Enterprise struct {
+		ID string `json:"id"`
+	} `json:"enterprise"`
+}
@@ -0,0 +1,137 @@
+/*
+Copyright (c) 2020 TriggerMesh Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package slacksource
+
+// Reasons for dropping Slack events.
+const (
+	dropReasonEventType    = "event_type"
+	dropReasonEventSubtype = "event_subtype"
+	dropReasonChannel      = "channel"
+	dropReasonUser         = "user"
+	dropReasonBotMessage   = "bot_message"
+	dropReasonBotUser      = "bot_user"
+)
+
+const botMessageSubtype = "bot_message"
+
+// eventFilter decides which Slack events are forwarded to the sink.
+type eventFilter struct {
+	includeTypes    stringSet
+	excludeTypes    stringSet
+	includeSubtypes stringSet
+	excludeSubtypes stringSet
+	includeChannels stringSet
+	excludeChannels stringSet
+	includeUsers    stringSet
+	excludeUsers    stringSet
+
+	dropBotMessages bool
+	botUserID       string
+}
+
+// newEventFilter returns an eventFilter for the given configuration, or nil
+// if no configuration is provided.
+func newEventFilter(cfg *eventFilterConfig) *eventFilter {
+	if cfg == nil {
+		return nil
+	}
+
+	f := &eventFilter{
+		includeTypes:    newStringSet(cfg.IncludeEventTypes),
+		excludeTypes:    newStringSet(cfg.ExcludeEventTypes),
+		includeSubtypes: newStringSet(cfg.IncludeEventSubtypes),
+		excludeSubtypes: newStringSet(cfg.ExcludeEventSubtypes),
+		includeChannels: newStringSet(cfg.IncludeChannels),
+		excludeChannels: newStringSet(cfg.ExcludeChannels),
+		includeUsers:    newStringSet(cfg.IncludeUsers),
+		excludeUsers:    newStringSet(cfg.ExcludeUsers),
+
+		dropBotMessages: cfg.DropBotMessages,
+	}
+
+	if cfg.BotUserID != nil {
+		f.botUserID = *cfg.BotUserID
+	}
+
+	return f
+}
+
+// dropReason returns the reason why the given event should be dropped, or
+// an empty string if the event should be forwarded to the sink.
+func (f *eventFilter) dropReason(e SlackEvent) string {
+	if !f.includeTypes.allows(e.Type()) || f.excludeTypes.has(e.Type()) {
+		return dropReasonEventType
+	}
+
+	if subtype := e.Subtype(); subtype != "" &&
+		(!f.includeSubtypes.allows(subtype) || f.excludeSubtypes.has(subtype)) {
+
+		return dropReasonEventSubtype
+	}
+
+	if ch := e.Channel(); ch != "" &&
+		(!f.includeChannels.allows(ch) || f.excludeChannels.has(ch)) {
+
+		return dropReasonChannel
+	}
+
+	user := e.User()
+
+	if f.botUserID != "" && user == f.botUserID {
+		return dropReasonBotUser
+	}
+
+	if f.dropBotMessages && (e.Subtype() == botMessageSubtype || e.BotID() != "") {
+		return dropReasonBotMessage
+	}
+
+	if user != "" && (!f.includeUsers.allows(user) || f.excludeUsers.has(user)) {
+		return dropReasonUser
+	}
+
+	return ""
+}
+
+// stringSet is a set of strings.
+type stringSet map[string]struct{}
+
+// newStringSet returns a stringSet containing the given elements, or nil
+// if no element is given.
+func newStringSet(elems []string) stringSet {
+	if len(elems) == 0 {
+		return nil
+	}
+
+	s := make(stringSet, len(elems))
+	for _, e := range elems {
+		s[e] = struct{}{}
+	}
+
+	return s
+}
+
+// has returns whether the set contains the given element.
+func (s stringSet) has(elem string) bool {
+	_, ok := s[elem]
+	return ok
+}
+
+// allows returns whether the given element is allowed by an include list
+// represented by the set. An empty set allows all elements.
+func (s stringSet) allows(elem string) bool {
+	return len(s) == 0 || s.has(elem)
+}
@@ -0,0 +1,166 @@
+/*
+Copyright (c) 2020 TriggerMesh Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package slacksource
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	cloudeventst "github.com/cloudevents/sdk-go/v2/client/test"
+	"github.com/stretchr/testify/assert"
+	zapt "go.uber.org/zap/zaptest"
+
+	"knative.dev/eventing/pkg/adapter/v2"
+	"knative.dev/pkg/metrics/metricskey"
+	"knative.dev/pkg/metrics/metricstest"
+	_ "knative.dev/pkg/metrics/testing"
+
+	"github.com/triggermesh/knative-sources/pkg/adapter/common/stats"
+)
+
+func TestEventFilter(t *testing.T) {
+	botUserID := "UBOT"
+
+	tc := map[string]struct {
+		filter eventFilterConfig
+		event  SlackEvent
+
+		expectReason string
+	}{
+		"empty filter": {
+			event: SlackEvent{"type": "message", "channel": "C1", "user": "U1"},
+		},
+		"included event type": {
+			filter: eventFilterConfig{IncludeEventTypes: []string{"message"}},
+			event:  SlackEvent{"type": "message"},
+		},
+		"not included event type": {
+			filter:       eventFilterConfig{IncludeEventTypes: []string{"app_mention"}},
+			event:        SlackEvent{"type": "message"},
+			expectReason: dropReasonEventType,
+		},
+		"excluded event type": {
+			filter:       eventFilterConfig{ExcludeEventTypes: []string{"message"}},
+			event:        SlackEvent{"type": "message"},
+			expectReason: dropReasonEventType,
+		},
+		"excluded event subtype": {
+			filter:       eventFilterConfig{ExcludeEventSubtypes: []string{"channel_join"}},
+			event:        SlackEvent{"type": "message", "subtype": "channel_join"},
+			expectReason: dropReasonEventSubtype,
+		},
+		"event without subtype and include list of subtypes": {
+			filter: eventFilterConfig{IncludeEventSubtypes: []string{"thread_broadcast"}},
+			event:  SlackEvent{"type": "message"},
+		},
+		"not included channel": {
+			filter:       eventFilterConfig{IncludeChannels: []string{"C1"}},
+			event:        SlackEvent{"type": "message", "channel": "C2"},
+			expectReason: dropReasonChannel,
+		},
+		"excluded channel of item": {
+			filter:       eventFilterConfig{ExcludeChannels: []string{"C1"}},
+			event:        SlackEvent{"type": "reaction_added", "item": map[string]interface{}{"channel": "C1"}},
+			expectReason: dropReasonChannel,
+		},
+		"event without channel and include list of channels": {
+			filter: eventFilterConfig{IncludeChannels: []string{"C1"}},
+			event:  SlackEvent{"type": "team_join"},
+		},
+		"excluded user": {
+			filter:       eventFilterConfig{ExcludeUsers: []string{"U1"}},
+			event:        SlackEvent{"type": "message", "user": "U1"},
+			expectReason: dropReasonUser,
+		},
+		"bot message by subtype": {
+			filter:       eventFilterConfig{DropBotMessages: true},
+			event:        SlackEvent{"type": "message", "subtype": "bot_message"},
+			expectReason: dropReasonBotMessage,
+		},
+		"bot message by bot ID": {
+			filter:       eventFilterConfig{DropBotMessages: true},
+			event:        SlackEvent{"type": "message", "bot_id": "B1", "user": "U1"},
+			expectReason: dropReasonBotMessage,
+		},
+		"message from own bot user": {
+			filter:       eventFilterConfig{BotUserID: &botUserID},
+			event:        SlackEvent{"type": "message", "user": botUserID},
+			expectReason: dropReasonBotUser,
+		},
+	}
+
+	for name, c := range tc {
+		t.Run(name, func(t *testing.T) {
+			f := newEventFilter(&c.filter)
+			assert.Equal(t, c.expectReason, f.dropReason(c.event))
+		})
+	}
+}
+
+func TestSlackEventFiltered(t *testing.T) {
+	logger := zapt.NewLogger(t).Sugar()
+
+	ceClient, chEvent := cloudeventst.NewMockSenderClient(t, 1)
+
+	handler := &slackEventAPIHandler{
+		filter: newEventFilter(&eventFilterConfig{
+			ExcludeChannels: []string{"C01112A09FT"},
+		}),
+		ceClient: ceClient,
+		reporter: stats.NewReporter(adapter.EnvConfig{
+			Namespace:     "testns",
+			Name:          "test",
+			ResourceGroup: "slacksources.sources.triggermesh.io",
+		}),
+		logger: logger,
+		time:   standardTime{},
+	}
+
+	req, _ := http.NewRequest("GET", "/", read(`
+	{
+		"team_id": "TXXXXXXXX",
+		"api_app_id": "AXXXXXXXXX",
+		"event": {
+			"type": "message",
+			"channel": "C01112A09FT",
+			"user": "UXXXXXXX1"
+		},
+		"type": "event_callback",
+		"event_id": "Ev08MFMKH6",
+		"event_time": 1234567890
+	}`))
+
+	rr := httptest.NewRecorder()
+	http.HandlerFunc(handler.handleAll).ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code, "filtered events should be acknowledged")
+
+	select {
+	case event := <-chEvent:
+		assert.Fail(t, "unexpected cloud event was sent", event.ID())
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	metricstest.CheckCountData(t, "filtered_event_count", map[string]string{
+		metricskey.LabelNamespaceName: "testns",
+		metricskey.LabelName:          "test",
+		metricskey.LabelResourceGroup: "slacksources.sources.triggermesh.io",
+		"filter_reason":               dropReasonChannel,
+	}, 1)
+}
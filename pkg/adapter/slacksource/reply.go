@@ -0,0 +1,117 @@
+/*
+Copyright (c) 2020 TriggerMesh Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package slacksource
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"go.uber.org/zap"
+
+	"github.com/triggermesh/knative-sources/pkg/apis/sources/v1alpha1"
+)
+
+const (
+	// maximum number of retries of a rate limited message
+	replyMaxRetries = 3
+	// delay before retrying a rate limited message when Slack doesn't
+	// indicate one
+	replyDefaultRetryAfter = time.Second
+	// maximum duration of the posting of a message, including retries
+	replyTimeout = 2 * time.Minute
+)
+
+// replyPoster posts the messages contained in CloudEvents returned by the
+// sink as replies to Slack events.
+type replyPoster struct {
+	api *slackAPIClient
+
+	logger *zap.SugaredLogger
+}
+
+// newReplyPoster returns a replyPoster which posts messages using the given
+// Slack Web API client, authenticated with a bot token.
+func newReplyPoster(api *slackAPIClient, logger *zap.SugaredLogger) *replyPoster {
+	return &replyPoster{
+		api:    api,
+		logger: logger,
+	}
+}
+
+// postAsync posts the message contained in the given reply event without
+// blocking the caller, which must respond to Slack within 3 seconds.
+func (p *replyPoster) postAsync(origin SlackEvent, reply *cloudevents.Event) {
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), replyTimeout)
+		defer cancel()
+
+		if err := p.post(ctx, origin, reply); err != nil {
+			p.logger.Errorw("Could not post reply to Slack", zap.String("reply", reply.ID()), zap.Error(err))
+		}
+	}()
+}
+
+// post posts the message contained in the given reply event to Slack. The
+// channel and thread of the message default to the ones of the event the
+// sink replied to, if any. Replies of unsupported types are ignored.
+func (p *replyPoster) post(ctx context.Context, origin SlackEvent, reply *cloudevents.Event) error {
+	if typ := reply.Type(); typ != v1alpha1.SlackPostMessageEventType {
+		p.logger.Debugw("Ignoring reply of unsupported type", zap.String("type", typ))
+		return nil
+	}
+
+	msg := make(map[string]interface{})
+	if err := reply.DataAs(&msg); err != nil {
+		return fmt.Errorf("decoding reply data: %w", err)
+	}
+
+	if origin != nil {
+		if _, ok := msg["channel"]; !ok {
+			msg["channel"] = origin.Channel()
+		}
+		if _, ok := msg["thread_ts"]; !ok {
+			if ts := origin.ThreadTS(); ts != "" {
+				msg["thread_ts"] = ts
+			}
+		}
+	}
+
+	for retry := 0; ; retry++ {
+		err := p.api.chatPostMessage(ctx, msg)
+
+		apiErr := (*slackAPIError)(nil)
+		if err == nil || !errors.As(err, &apiErr) || apiErr.Code != "ratelimited" || retry == replyMaxRetries {
+			return err
+		}
+
+		retryAfter := apiErr.RetryAfter
+		if retryAfter == 0 {
+			retryAfter = replyDefaultRetryAfter
+		}
+
+		p.logger.Debugw("Posting of reply was rate limited, retrying", zap.Duration("delay", retryAfter))
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(retryAfter):
+		}
+	}
+}
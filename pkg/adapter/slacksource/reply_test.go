@@ -0,0 +1,126 @@
+/*
+Copyright (c) 2020 TriggerMesh Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package slacksource
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	cloudeventst "github.com/cloudevents/sdk-go/v2/client/test"
+	"github.com/cloudevents/sdk-go/v2/protocol"
+	"github.com/stretchr/testify/assert"
+	zapt "go.uber.org/zap/zaptest"
+
+	"github.com/triggermesh/knative-sources/pkg/apis/sources/v1alpha1"
+)
+
+func TestSlackEventReply(t *testing.T) {
+	const body = `{"team_id":"TXXXXXXXX","api_app_id":"A0000000001","event":{"type":"app_mention","channel":"C1","user":"U1","text":"hi","ts":"1593192794.008000"},"type":"event_callback","event_id":"Ev08MFMKH6"}`
+
+	logger := zapt.NewLogger(t).Sugar()
+
+	// the first call is rate limited, the second one succeeds
+	var calls int
+	posted := make(chan map[string]interface{}, 1)
+
+	slackAPI := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/chat.postMessage", r.URL.Path)
+		assert.Equal(t, "Bearer "+tBotToken, r.Header.Get("Authorization"))
+
+		if calls++; calls == 1 {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+
+		msg := make(map[string]interface{})
+		if err := json.NewDecoder(r.Body).Decode(&msg); err != nil {
+			t.Errorf("Failed to decode message: %s", err)
+		}
+		posted <- msg
+
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"ok":true}`))
+	}))
+	defer slackAPI.Close()
+
+	ceClient, chEvent := cloudeventst.NewMockRequesterClient(t, 1,
+		func(e cloudevents.Event) (*cloudevents.Event, protocol.Result) {
+			reply := cloudevents.NewEvent()
+			reply.SetID("reply-1")
+			reply.SetSource("my-bot")
+			reply.SetType(v1alpha1.SlackPostMessageEventType)
+			_ = reply.SetData(cloudevents.ApplicationJSON, map[string]interface{}{"text": "hello"})
+			return &reply, nil
+		},
+	)
+
+	handler := &slackEventAPIHandler{
+		replier:  newReplyPoster(newSlackAPIClient(slackAPI.URL, tBotToken), logger),
+		ceClient: ceClient,
+		logger:   logger,
+		time:     standardTime{},
+	}
+
+	req, _ := http.NewRequest("GET", "/", read(body))
+
+	rr := httptest.NewRecorder()
+	http.HandlerFunc(handler.handleAll).ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code, "unexpected response code")
+
+	select {
+	case event := <-chEvent:
+		assert.Equal(t, "Ev08MFMKH6", event.ID(), "event ID does not match")
+	case <-time.After(1 * time.Second):
+		t.Fatal("Expected cloud event was not sent")
+	}
+
+	select {
+	case msg := <-posted:
+		assert.Equal(t, map[string]interface{}{
+			"channel":   "C1",
+			"thread_ts": "1593192794.008000",
+			"text":      "hello",
+		}, msg)
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected reply was not posted to Slack")
+	}
+
+	assert.Equal(t, 2, calls, "expected the rate limited message to be retried once")
+}
+
+func TestReplyPosterIgnoresUnsupportedTypes(t *testing.T) {
+	logger := zapt.NewLogger(t).Sugar()
+
+	slackAPI := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("Unexpected call to Slack API method %s", r.URL.Path)
+	}))
+	defer slackAPI.Close()
+
+	p := newReplyPoster(newSlackAPIClient(slackAPI.URL, tBotToken), logger)
+
+	reply := cloudevents.NewEvent()
+	reply.SetType("com.example.unsupported")
+
+	assert.NoError(t, p.post(context.Background(), SlackEvent{"channel": "C1"}, &reply))
+}
@@ -21,41 +21,100 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"io/ioutil"
 	"net/http"
-	"strconv"
+	"net/url"
+	"strings"
 	"time"
 
 	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/cloudevents/sdk-go/v2/protocol"
+	"github.com/google/uuid"
+	"github.com/triggermesh/knative-sources/pkg/adapter/common/server"
+	"github.com/triggermesh/knative-sources/pkg/adapter/common/stats"
+	"github.com/triggermesh/knative-sources/pkg/adapter/common/tracing"
 	"github.com/triggermesh/knative-sources/pkg/apis/sources/v1alpha1"
 	"go.uber.org/zap"
 )
 
 const apiAppIdCeExtension = "comslackapiappid"
 
+// CloudEvent extensions set on events from Enterprise Grid organizations.
+const (
+	enterpriseIDCeExtension          = "comslackenterpriseid"
+	contextTeamIDCeExtension         = "comslackctxteamid"
+	extSharedChannelCeExtension      = "comslackextshared"
+	authTeamIDCeExtension            = "comslackauthteamid"
+	authUserIDCeExtension            = "comslackauthuserid"
+	authEnterpriseInstallCeExtension = "comslackentinstall"
+)
+
 // SlackEventAPIHandler listen for Slack API Events
 type SlackEventAPIHandler interface {
 	Start(ctx context.Context) error
 }
 
 type slackEventAPIHandler struct {
-	port          int
+	srvCfg        server.Config
+	reporter      *stats.Reporter
 	signingSecret string
 	appID         string
+	apps          map[string]*slackApp
+	filter        *eventFilter
+	enricher      *eventEnricher
+
+	// events which can not be delivered to the sink are sent to the
+	// dead-letter sink, if set, instead of being retried by Slack
+	deadLetterSink string
+
+	// replies returned by the sink are posted to Slack when set
+	replier *replyPoster
+
+	// notifications about the Slack app are reported to the controller
+	// when set
+	appStatus *appStatusReporter
 
 	ceClient cloudevents.Client
-	srv      *http.Server
 
 	time   timeWrap
 	logger *zap.SugaredLogger
 }
 
+// slackApp contains the settings of a Slack app sharing the handler's
+// endpoint.
+type slackApp struct {
+	signingSecret string
+	sink          string
+	eventTypes    map[string]struct{}
+}
+
+// acceptsEventType returns whether events of the given type should be
+// forwarded for this app.
+func (a *slackApp) acceptsEventType(typ string) bool {
+	if len(a.eventTypes) == 0 {
+		return true
+	}
+	_, ok := a.eventTypes[typ]
+	return ok
+}
+
 // NewSlackEventAPIHandler creates the default implementation of the Slack API Events handler
-func NewSlackEventAPIHandler(ceClient cloudevents.Client, port int, signingSecret, appID string, tw timeWrap, logger *zap.SugaredLogger) SlackEventAPIHandler {
+func NewSlackEventAPIHandler(ceClient cloudevents.Client, srvCfg server.Config, reporter *stats.Reporter,
+	signingSecret, appID string, apps map[string]*slackApp,
+	filter *eventFilter, enricher *eventEnricher, deadLetterSink string, replier *replyPoster, appStatus *appStatusReporter,
+	tw timeWrap, logger *zap.SugaredLogger) SlackEventAPIHandler {
+
 	return &slackEventAPIHandler{
-		port:          port,
+		srvCfg:        srvCfg,
+		reporter:      reporter,
 		signingSecret: signingSecret,
 		appID:         appID,
+		apps:          apps,
+		filter:        filter,
+		enricher:      enricher,
+
+		deadLetterSink: deadLetterSink,
+		replier:        replier,
+		appStatus:      appStatus,
 
 		ceClient: ceClient,
 		time:     tw,
@@ -67,49 +126,23 @@ func NewSlackEventAPIHandler(ceClient cloudevents.Client, port int, signingSecre
 // until the stop channel closes.
 func (h *slackEventAPIHandler) Start(ctx context.Context) error {
 	h.logger.Info("Starting Slack event handler")
-
-	m := http.NewServeMux()
-	m.HandleFunc("/", h.handleAll)
-
-	h.srv = &http.Server{
-		Addr:    ":" + strconv.Itoa(h.port),
-		Handler: m,
-	}
-
-	done := make(chan bool, 1)
-	go h.gracefulShutdown(ctx.Done(), done)
-
-	h.logger.Infof("Server is ready to handle requests at %s", h.srv.Addr)
-	if err := h.srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-		return fmt.Errorf("could not listen on %s: %w", h.srv.Addr, err)
-	}
-
-	<-done
-	h.logger.Infof("Server stopped")
-	return nil
+	return server.New(h.srvCfg, http.HandlerFunc(h.handleAll), h.reporter, h.logger.Named("server")).Run(ctx)
 }
 
 // handleAll receives all Slack events at a single resource, it
 // is up to this function to parse event wrapper and dispatch.
 func (h *slackEventAPIHandler) handleAll(w http.ResponseWriter, r *http.Request) {
-	if r.Body == nil {
-		h.handleError(errors.New("request without body not supported"), http.StatusBadRequest, w)
-		return
-	}
-
-	defer r.Body.Close()
-	body, err := ioutil.ReadAll(r.Body)
+	body, code, err := server.ReadBody(r)
 	if err != nil {
-		h.handleError(err, http.StatusInternalServerError, w)
+		h.handleError(err, code, w)
 		return
 	}
 
-	if h.signingSecret != "" {
-		err = h.verifySigning(r.Header, body)
-		if err != nil {
-			h.handleError(err, http.StatusUnauthorized, w)
-			return
-		}
+	// Interactivity payloads are sent as a form-encoded parameter.
+	// See: https://api.slack.com/interactivity/handling#payloads
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "application/x-www-form-urlencoded") {
+		h.handleInteraction(r.Context(), r.Header, body, w)
+		return
 	}
 
 	event := &SlackEventWrapper{}
@@ -124,18 +157,38 @@ func (h *slackEventAPIHandler) handleAll(w http.ResponseWriter, r *http.Request)
 	// Otherwise the message will be retried.
 	// See: https://api.slack.com/events-api#receiving_events (Responding to Events)
 
-	if h.appID != "" && event.APIAppID != h.appID {
+	app, ok := h.appFor(event.APIAppID)
+	if !ok {
 		// silently ignore, some other integration should take
 		// care of this event.
 		return
 	}
 
-	// There are only 2 documented types to be received from the Events API
+	// URL verifications are the only requests which are not bound to
+	// any app
+	if app == nil && event.Type != "url_verification" && h.requiresAppID() {
+		h.handleError(errMissingAppID, http.StatusUnauthorized, w)
+		return
+	}
+
+	// The event wrapper is parsed before verifying the request's
+	// signature, because the signing secret depends on the app the
+	// request claims to originate from.
+	if err = h.verifyRequest(r.Context(), app, r.Header, body); err != nil {
+		h.handleError(err, http.StatusUnauthorized, w)
+		return
+	}
+
+	// There are only 3 documented types to be received from the Events API
 	// - `event_callback`, See: https://api.slack.com/events-api#receiving_events
-	// - `event_callback`, See: https://api.slack.com/events-api#subscriptions
+	// - `url_verification`, See: https://api.slack.com/events-api#subscriptions
+	// - `app_rate_limited`, See: https://api.slack.com/events-api#rate_limiting
 	switch event.Type {
 	case "event_callback":
-		h.handleCallback(event, w)
+		h.handleCallback(r.Context(), app, event, w)
+
+	case "app_rate_limited":
+		h.handleRateLimit(r.Context(), app, event, w)
 
 	case "url_verification":
 		h.handleChallenge(body, w)
@@ -145,23 +198,82 @@ func (h *slackEventAPIHandler) handleAll(w http.ResponseWriter, r *http.Request)
 	}
 }
 
-func (h *slackEventAPIHandler) gracefulShutdown(stopCh <-chan struct{}, done chan<- bool) {
-	<-stopCh
-	h.logger.Info("Server is shutting down...")
+// appFor returns the settings of the Slack app identified by appID, and
+// whether requests from that app should be handled at all.
+// A nil app is returned for requests which are not bound to any app, such
+// as URL verifications.
+func (h *slackEventAPIHandler) appFor(appID string) (*slackApp, bool) {
+	if app, ok := h.apps[appID]; ok {
+		return app, true
+	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
+	if appID == "" {
+		return nil, true
+	}
 
-	h.srv.SetKeepAlivesEnabled(false)
-	if err := h.srv.Shutdown(ctx); err != nil {
-		h.logger.Fatalf("Could not gracefully shutdown the server: %v", err)
+	// the source's top-level settings apply to any app, unless either
+	// an app ID or a list of apps was provided
+	if appID == h.appID || (h.appID == "" && len(h.apps) == 0) {
+		return &slackApp{signingSecret: h.signingSecret}, true
 	}
-	close(done)
+
+	return nil, false
+}
+
+// errMissingAppID is returned for requests which don't identify the Slack
+// app they originate from, while the handler is shared between several apps.
+var errMissingAppID = errors.New("the request doesn't contain the ID of a Slack app")
+
+// requiresAppID returns whether requests must identify the Slack app they
+// originate from. Without an app ID, a request could otherwise be
+// authenticated with the signing secret of any of the apps.
+func (h *slackEventAPIHandler) requiresAppID() bool {
+	return len(h.signingSecrets()) > 1
+}
+
+// verifyRequest verifies the signature of a request using the signing
+// secret of the given app. Requests which are not bound to any app are
+// accepted if their signature matches any of the known signing secrets.
+func (h *slackEventAPIHandler) verifyRequest(ctx context.Context, app *slackApp, header http.Header, body []byte) (err error) {
+	_, span := tracing.StartVerifySpan(ctx)
+	defer func() { tracing.EndSpan(span, err) }()
+
+	if app != nil {
+		if app.signingSecret == "" {
+			return nil
+		}
+		return h.verifySigning(app.signingSecret, header, body)
+	}
+
+	for _, secret := range h.signingSecrets() {
+		if err = h.verifySigning(secret, header, body); err == nil {
+			return nil
+		}
+	}
+
+	return err
+}
+
+// signingSecrets returns all the signing secrets known to the handler.
+func (h *slackEventAPIHandler) signingSecrets() []string {
+	var secrets []string
+
+	if h.signingSecret != "" {
+		secrets = append(secrets, h.signingSecret)
+	}
+
+	for _, app := range h.apps {
+		if app.signingSecret != "" {
+			secrets = append(secrets, app.signingSecret)
+		}
+	}
+
+	return secrets
 }
 
 func (h *slackEventAPIHandler) handleError(err error, code int, w http.ResponseWriter) {
 	h.logger.Error("An error ocurred", zap.Error(err))
-	http.Error(w, err.Error(), code)
+	server.Error(w, err, code)
 }
 
 func (h *slackEventAPIHandler) handleChallenge(body []byte, w http.ResponseWriter) {
@@ -177,7 +289,8 @@ func (h *slackEventAPIHandler) handleChallenge(body []byte, w http.ResponseWrite
 	cr := &SlackChallengeResponse{Challenge: c.Challenge}
 	res, err := json.Marshal(cr)
 	if err != nil {
-		h.handleError(err, http.StatusBadRequest, w)
+		h.handleError(err, http.StatusInternalServerError, w)
+		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -189,32 +302,313 @@ func (h *slackEventAPIHandler) handleChallenge(body []byte, w http.ResponseWrite
 	}
 }
 
-func (h *slackEventAPIHandler) handleCallback(wrapper *SlackEventWrapper, w http.ResponseWriter) {
+func (h *slackEventAPIHandler) handleCallback(ctx context.Context, app *slackApp, wrapper *SlackEventWrapper,
+	w http.ResponseWriter) {
+
 	h.logger.Info("callback received")
 
+	event, err := h.processCallback(ctx, app, wrapper)
+	if err != nil {
+		h.handleError(err, http.StatusBadRequest, w)
+		return
+	}
+	if event == nil {
+		return
+	}
+
+	// Responding with an error status code causes Slack to retry the
+	// delivery of the event.
+	if err := h.deliver(ctx, app, event, wrapper.Event); err != nil {
+		h.handleError(err, http.StatusInternalServerError, w)
+	}
+}
+
+// deliver sends the given event to the sink of the given Slack app. Events
+// which are rejected by the sink are sent to the dead-letter sink, if set.
+// A non-nil error is returned when the event could not be delivered at all,
+// in which case it should be retried by Slack.
+// When replies are enabled, the event returned by the sink, if any, is
+// posted to Slack in reply to the origin Slack event.
+func (h *slackEventAPIHandler) deliver(ctx context.Context, app *slackApp, event *cloudevents.Event, origin SlackEvent) error {
+	var reply *cloudevents.Event
+	var result protocol.Result
+
+	if h.replier != nil {
+		reply, result = h.ceClient.Request(sendContext(ctx, app), *event)
+	} else {
+		result = h.ceClient.Send(sendContext(ctx, app), *event)
+	}
+
+	if cloudevents.IsACK(result) {
+		if reply != nil {
+			h.replier.postAsync(origin, reply)
+		}
+		return nil
+	}
+
+	if h.deadLetterSink == "" {
+		return fmt.Errorf("sending CloudEvent to the sink: %w", result)
+	}
+
+	h.logger.Warnw("Could not send CloudEvent to the sink, sending it to the dead-letter sink",
+		zap.String("event", event.ID()), zap.Error(result))
+
+	dlResult := h.ceClient.Send(cloudevents.ContextWithTarget(ctx, h.deadLetterSink), *event)
+	if !cloudevents.IsACK(dlResult) {
+		return fmt.Errorf("sending CloudEvent to the dead-letter sink: %w", dlResult)
+	}
+
+	return nil
+}
+
+// handleRateLimit forwards an app_rate_limited notification.
+func (h *slackEventAPIHandler) handleRateLimit(ctx context.Context, app *slackApp, wrapper *SlackEventWrapper,
+	w http.ResponseWriter) {
+
+	event, err := h.processRateLimit(wrapper)
+	if err != nil {
+		h.handleError(err, http.StatusBadRequest, w)
+		return
+	}
+
+	if err := h.deliver(ctx, app, event, nil); err != nil {
+		h.handleError(err, http.StatusInternalServerError, w)
+	}
+}
+
+// processRateLimit reports the given app_rate_limited notification and
+// returns the CloudEvent to be sent for it. Such notifications are never
+// filtered out.
+func (h *slackEventAPIHandler) processRateLimit(wrapper *SlackEventWrapper) (*cloudevents.Event, error) {
+	h.logger.Warnw("Slack stopped delivering events to the app because of rate limiting",
+		zap.String("app", wrapper.APIAppID), zap.String("team", wrapper.TeamID))
+
+	if h.appStatus != nil {
+		h.appStatus.notify(v1alpha1.SlackAppStatusRateLimitedKey, time.Unix(int64(wrapper.MinuteRateLimited), 0))
+	}
+
+	return cloudEventFromRateLimit(wrapper)
+}
+
+// processCallback returns the CloudEvent to be sent for the given Slack
+// event callback, or nil if the event is filtered out.
+func (h *slackEventAPIHandler) processCallback(ctx context.Context, app *slackApp, wrapper *SlackEventWrapper) (*cloudevents.Event, error) {
+	// notifications are reported regardless of filters
+	h.reportAppStatus(wrapper)
+
+	if app != nil && !app.acceptsEventType(wrapper.Event.Type()) {
+		h.dropEvent(wrapper, dropReasonEventType)
+		return nil, nil
+	}
+
+	if h.filter != nil {
+		if reason := h.filter.dropReason(wrapper.Event); reason != "" {
+			h.dropEvent(wrapper, reason)
+			return nil, nil
+		}
+	}
+
 	event, err := cloudEventFromEventWrapper(wrapper)
+	if err != nil {
+		return nil, err
+	}
+
+	if h.enricher != nil {
+		h.enricher.enrich(ctx, wrapper.Event, event)
+	}
+
+	return event, nil
+}
+
+// reportAppStatus reports the notification about the Slack app carried by
+// the given event callback, if any. Other events show that Slack delivers
+// events to the app.
+func (h *slackEventAPIHandler) reportAppStatus(wrapper *SlackEventWrapper) {
+	if h.appStatus == nil {
+		return
+	}
+
+	t := time.Unix(int64(wrapper.EventTime), 0)
+
+	switch wrapper.Event.Type() {
+	case "app_uninstalled":
+		h.appStatus.notify(v1alpha1.SlackAppStatusUninstalledKey, t)
+	case "tokens_revoked":
+		h.appStatus.notify(v1alpha1.SlackAppStatusTokensRevokedKey, t)
+	default:
+		h.appStatus.eventReceived()
+	}
+}
+
+// sendContext returns a context for sending events generated by the given
+// Slack app to the app's sink, if it overrides the source's sink.
+func sendContext(ctx context.Context, app *slackApp) context.Context {
+	if app != nil && app.sink != "" {
+		return cloudevents.ContextWithTarget(ctx, app.sink)
+	}
+	return ctx
+}
+
+// handleInteraction forwards the interactivity payload contained in a
+// form-encoded request body.
+func (h *slackEventAPIHandler) handleInteraction(ctx context.Context, header http.Header, body []byte,
+	w http.ResponseWriter) {
+
+	form, err := url.ParseQuery(string(body))
+	if err != nil {
+		h.handleError(fmt.Errorf("could not parse form request: %w", err), http.StatusBadRequest, w)
+		return
+	}
+
+	payload := []byte(form.Get("payload"))
+
+	interaction := &SlackInteraction{}
+	if err := json.Unmarshal(payload, interaction); err != nil {
+		h.handleError(fmt.Errorf("could not unmarshall JSON payload: %w", err), http.StatusBadRequest, w)
+		return
+	}
+
+	app, ok := h.appFor(interaction.APIAppID)
+	if !ok {
+		return
+	}
+	if app == nil && h.requiresAppID() {
+		h.handleError(errMissingAppID, http.StatusUnauthorized, w)
+		return
+	}
+
+	// signatures are computed over the raw request body
+	if err := h.verifyRequest(ctx, app, header, body); err != nil {
+		h.handleError(err, http.StatusUnauthorized, w)
+		return
+	}
+
+	event, err := cloudEventFromInteraction(interaction, payload)
 	if err != nil {
 		h.handleError(err, http.StatusBadRequest, w)
 		return
 	}
 
-	if result := h.ceClient.Send(context.Background(), *event); !cloudevents.IsACK(result) {
+	if err := h.deliver(ctx, app, event, nil); err != nil {
 		h.handleError(err, http.StatusInternalServerError, w)
 	}
 }
 
+// dropEvent acknowledges a Slack event without forwarding it to the sink.
+func (h *slackEventAPIHandler) dropEvent(wrapper *SlackEventWrapper, reason string) {
+	h.logger.Debugw("Dropping filtered event", zap.String("event", wrapper.EventID), zap.String("reason", reason))
+	h.reporter.ReportFilteredEvent(reason)
+}
+
+// lifecycleEventTypes maps the types of Slack events about the lifecycle of
+// the app to their dedicated CloudEvent type.
+var lifecycleEventTypes = map[string]string{
+	"app_uninstalled": v1alpha1.SlackAppUninstalledEventType,
+	"tokens_revoked":  v1alpha1.SlackTokensRevokedEventType,
+}
+
 func cloudEventFromEventWrapper(wrapper *SlackEventWrapper) (*cloudevents.Event, error) {
 	event := cloudevents.NewEvent(cloudevents.VersionV1)
 
+	typ, ok := lifecycleEventTypes[wrapper.Event.Type()]
+	if !ok {
+		typ = v1alpha1.SlackGenericEventType
+	}
+
 	event.SetID(wrapper.EventID)
-	event.SetType(v1alpha1.SlackGenericEventType)
-	event.SetSource(wrapper.TeamID)
+	event.SetType(typ)
+	event.SetSource(eventSource(wrapper.EnterpriseID, wrapper.TeamID))
 	event.SetExtension(apiAppIdCeExtension, wrapper.APIAppID)
 	event.SetTime(time.Unix(int64(wrapper.EventTime), 0))
 	event.SetSubject(wrapper.Event.Type())
+	setEnterpriseExtensions(&event, wrapper)
 	if err := event.SetData(cloudevents.ApplicationJSON, wrapper.Event); err != nil {
 		return nil, err
 	}
 
 	return &event, nil
 }
+
+// eventSource returns the CloudEvent source of events generated in the
+// given Slack workspace. Workspaces which belong to an Enterprise Grid
+// organization are qualified with the ID of the organization.
+func eventSource(enterpriseID, teamID string) string {
+	switch {
+	case enterpriseID == "":
+		return teamID
+	case teamID == "":
+		return enterpriseID
+	default:
+		return enterpriseID + "/" + teamID
+	}
+}
+
+// setEnterpriseExtensions sets extensions on the given CloudEvent with the
+// Enterprise Grid attributes of a Slack event, if any.
+func setEnterpriseExtensions(event *cloudevents.Event, wrapper *SlackEventWrapper) {
+	if wrapper.EnterpriseID != "" {
+		event.SetExtension(enterpriseIDCeExtension, wrapper.EnterpriseID)
+	}
+	if wrapper.ContextTeamID != "" {
+		event.SetExtension(contextTeamIDCeExtension, wrapper.ContextTeamID)
+	}
+	if wrapper.IsExtSharedChannel {
+		event.SetExtension(extSharedChannelCeExtension, true)
+	}
+
+	// Slack only includes the authorization of a single installation,
+	// the others can be listed using apps.event.authorizations.list.
+	if len(wrapper.Authorizations) == 0 {
+		return
+	}
+
+	auth := wrapper.Authorizations[0]
+
+	if auth.TeamID != "" {
+		event.SetExtension(authTeamIDCeExtension, auth.TeamID)
+	}
+	if auth.UserID != "" {
+		event.SetExtension(authUserIDCeExtension, auth.UserID)
+	}
+	if auth.IsEnterpriseInstall {
+		event.SetExtension(authEnterpriseInstallCeExtension, true)
+	}
+}
+
+func cloudEventFromInteraction(interaction *SlackInteraction, payload []byte) (*cloudevents.Event, error) {
+	event := cloudevents.NewEvent(cloudevents.VersionV1)
+
+	// interactivity payloads don't have a unique identifier
+	event.SetID(uuid.New().String())
+	event.SetType(v1alpha1.SlackInteractionEventType)
+	event.SetSource(eventSource(interaction.Enterprise.ID, interaction.Team.ID))
+	event.SetExtension(apiAppIdCeExtension, interaction.APIAppID)
+	event.SetSubject(interaction.Type)
+	if err := event.SetData(cloudevents.ApplicationJSON, json.RawMessage(payload)); err != nil {
+		return nil, err
+	}
+
+	return &event, nil
+}
+
+func cloudEventFromRateLimit(wrapper *SlackEventWrapper) (*cloudevents.Event, error) {
+	event := cloudevents.NewEvent(cloudevents.VersionV1)
+
+	// rate limit notifications don't have a unique identifier, but Slack
+	// sends at most one per app, workspace and minute
+	event.SetID(fmt.Sprintf("%s-%s-%d", wrapper.APIAppID, wrapper.TeamID, wrapper.MinuteRateLimited))
+	event.SetType(v1alpha1.SlackAppRateLimitedEventType)
+	event.SetSource(eventSource(wrapper.EnterpriseID, wrapper.TeamID))
+	event.SetExtension(apiAppIdCeExtension, wrapper.APIAppID)
+	event.SetTime(time.Unix(int64(wrapper.MinuteRateLimited), 0))
+	data := &SlackRateLimit{
+		TeamID:            wrapper.TeamID,
+		APIAppID:          wrapper.APIAppID,
+		MinuteRateLimited: wrapper.MinuteRateLimited,
+	}
+	if err := event.SetData(cloudevents.ApplicationJSON, data); err != nil {
+		return nil, err
+	}
+
+	return &event, nil
+}
@@ -17,18 +17,32 @@ limitations under the License.
 package slacksource
 
 import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"io"
+	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"strconv"
 	"strings"
 	"testing"
 	"time"
 
+	cloudevents "github.com/cloudevents/sdk-go/v2"
 	cloudeventst "github.com/cloudevents/sdk-go/v2/client/test"
+	"github.com/cloudevents/sdk-go/v2/protocol"
 	"github.com/stretchr/testify/assert"
 	zapt "go.uber.org/zap/zaptest"
+
+	"github.com/triggermesh/knative-sources/pkg/apis/sources/v1alpha1"
 )
 
+const fixturesDir = "../../../test/fixtures/slack/"
+
 func TestSlackEvent(t *testing.T) {
 
 	logger := zapt.NewLogger(t).Sugar()
@@ -282,6 +296,392 @@ func TestSlackEvent(t *testing.T) {
 	}
 }
 
+func TestSlackEventMultipleApps(t *testing.T) {
+	const (
+		app1ID     = "A0000000001"
+		app1Secret = "6623e5d64e469c64908c481b6de975f0"
+		app2ID     = "A0000000002"
+		app2Secret = "a0d5ce5b87e6b7f5d0c1c6b9aa9d8d10"
+	)
+
+	logger := zapt.NewLogger(t).Sugar()
+
+	apps := map[string]*slackApp{
+		app1ID: {
+			signingSecret: app1Secret,
+		},
+		app2ID: {
+			signingSecret: app2Secret,
+			eventTypes:    map[string]struct{}{"app_mention": {}},
+		},
+	}
+
+	callback := func(appID, eventType string) string {
+		return `{"token":"XXYYZZ","team_id":"TXXXXXXXX","api_app_id":"` + appID + `",` +
+			`"event":{"type":"` + eventType + `","event_ts":"1234567890.123456","user":"UXXXXXXX1"},` +
+			`"type":"event_callback","event_id":"Ev08MFMKH6","event_time":1234567890}`
+	}
+
+	tc := map[string]struct {
+		body          string
+		signingSecret string
+
+		expectedCode     int
+		expectedContains string
+		expectEvent      bool
+	}{
+		"signed with the secret of the claimed app": {
+			body:          callback(app1ID, "message"),
+			signingSecret: app1Secret,
+
+			expectedCode: http.StatusOK,
+			expectEvent:  true,
+		},
+
+		"signed with the secret of another app": {
+			body:          callback(app1ID, "message"),
+			signingSecret: app2Secret,
+
+			expectedCode:     http.StatusUnauthorized,
+			expectedContains: "received wrong signature signing hash",
+		},
+
+		"unknown app": {
+			body:          callback("A0000000003", "message"),
+			signingSecret: app1Secret,
+
+			expectedCode: http.StatusOK,
+		},
+
+		"event type accepted by app": {
+			body:          callback(app2ID, "app_mention"),
+			signingSecret: app2Secret,
+
+			expectedCode: http.StatusOK,
+			expectEvent:  true,
+		},
+
+		"event type filtered out by app": {
+			body:          callback(app2ID, "message"),
+			signingSecret: app2Secret,
+
+			expectedCode: http.StatusOK,
+		},
+
+		"missing app ID": {
+			body:          callback("", "message"),
+			signingSecret: app1Secret,
+
+			expectedCode:     http.StatusUnauthorized,
+			expectedContains: "the request doesn't contain the ID of a Slack app",
+		},
+
+		"url verification signed by any app": {
+			body:          `{"token":"XXYYZZ","challenge":"3eZbrw1aBm2rZgRNFdxV2595E9CY3gmdALWMmHkvFXO7tYXAYM8P","type":"url_verification"}`,
+			signingSecret: app2Secret,
+
+			expectedCode:     http.StatusOK,
+			expectedContains: `{"challenge":"3eZbrw1aBm2rZgRNFdxV2595E9CY3gmdALWMmHkvFXO7tYXAYM8P"}`,
+		},
+
+		"url verification not signed by any app": {
+			body:          `{"token":"XXYYZZ","challenge":"3eZbrw1aBm2rZgRNFdxV2595E9CY3gmdALWMmHkvFXO7tYXAYM8P","type":"url_verification"}`,
+			signingSecret: "00000000000000000000000000000000",
+
+			expectedCode:     http.StatusUnauthorized,
+			expectedContains: "received wrong signature signing hash",
+		},
+	}
+
+	for name, c := range tc {
+		t.Run(name, func(t *testing.T) {
+			ceClient, chEvent := cloudeventst.NewMockSenderClient(t, 1)
+
+			handler := &slackEventAPIHandler{
+				apps:     apps,
+				ceClient: ceClient,
+				logger:   logger,
+				time:     standardTime{},
+			}
+
+			req := signedRequest(t, c.signingSecret, c.body)
+
+			rr := httptest.NewRecorder()
+			th := http.HandlerFunc(handler.handleAll)
+
+			th.ServeHTTP(rr, req)
+
+			assert.Equal(t, c.expectedCode, rr.Code, "unexpected response code")
+			assert.Contains(t, rr.Body.String(), c.expectedContains, "could not find expected response")
+
+			select {
+			case event := <-chEvent:
+				assert.True(t, c.expectEvent, "unexpected cloud event %q was sent", event.ID())
+			case <-time.After(100 * time.Millisecond):
+				assert.False(t, c.expectEvent, "expected cloud event was not sent")
+			}
+		})
+	}
+}
+
+func TestSlackEventAppSinkOverride(t *testing.T) {
+	logger := zapt.NewLogger(t).Sugar()
+
+	var defaultSinkHits, appSinkHits int
+
+	defaultSink := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defaultSinkHits++
+	}))
+	defer defaultSink.Close()
+
+	appSink := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		appSinkHits++
+	}))
+	defer appSink.Close()
+
+	p, err := cloudevents.NewHTTP(cloudevents.WithTarget(defaultSink.URL))
+	if err != nil {
+		t.Fatalf("Failed to create HTTP protocol: %s", err)
+	}
+
+	ceClient, err := cloudevents.NewClient(p)
+	if err != nil {
+		t.Fatalf("Failed to create CloudEvents client: %s", err)
+	}
+
+	handler := &slackEventAPIHandler{
+		apps: map[string]*slackApp{
+			"A0000000001": {},
+			"A0000000002": {sink: appSink.URL},
+		},
+		ceClient: ceClient,
+		logger:   logger,
+		time:     standardTime{},
+	}
+
+	for _, appID := range []string{"A0000000001", "A0000000002"} {
+		body := `{"team_id":"TXXXXXXXX","api_app_id":"` + appID + `","event":{"type":"message"},"type":"event_callback","event_id":"Ev08MFMKH6"}`
+
+		req, _ := http.NewRequest("GET", "/", read(body))
+
+		rr := httptest.NewRecorder()
+		http.HandlerFunc(handler.handleAll).ServeHTTP(rr, req)
+	}
+
+	assert.Equal(t, 1, defaultSinkHits, "expected exactly one event sent to the default sink")
+	assert.Equal(t, 1, appSinkHits, "expected exactly one event sent to the app's sink")
+}
+
+func TestSlackEventDelivery(t *testing.T) {
+	const deadLetterSink = "http://dead-letter.example.com"
+
+	const body = `{"team_id":"TXXXXXXXX","api_app_id":"A0000000001","event":{"type":"message"},"type":"event_callback","event_id":"Ev08MFMKH6"}`
+
+	logger := zapt.NewLogger(t).Sugar()
+
+	tc := map[string]struct {
+		deadLetterSink string
+		nackTargets    []string
+
+		expectedCode   int
+		expectedTarget *string
+	}{
+		"sink accepts the event": {
+			expectedCode:   http.StatusOK,
+			expectedTarget: strPtr(""),
+		},
+		"sink rejects the event without dead-letter sink": {
+			nackTargets: []string{""},
+
+			expectedCode: http.StatusInternalServerError,
+		},
+		"sink rejects the event with dead-letter sink": {
+			deadLetterSink: deadLetterSink,
+			nackTargets:    []string{""},
+
+			expectedCode:   http.StatusOK,
+			expectedTarget: strPtr(deadLetterSink),
+		},
+		"sink and dead-letter sink reject the event": {
+			deadLetterSink: deadLetterSink,
+			nackTargets:    []string{"", deadLetterSink},
+
+			expectedCode: http.StatusInternalServerError,
+		},
+	}
+
+	for name, c := range tc {
+		t.Run(name, func(t *testing.T) {
+			mockClient, chEvent := cloudeventst.NewMockSenderClient(t, 1)
+
+			ceClient := &nackingClient{
+				Client:      mockClient,
+				nackTargets: make(map[string]struct{}, len(c.nackTargets)),
+				sentTargets: make(chan string, 1),
+			}
+			for _, tgt := range c.nackTargets {
+				ceClient.nackTargets[tgt] = struct{}{}
+			}
+
+			handler := &slackEventAPIHandler{
+				deadLetterSink: c.deadLetterSink,
+				ceClient:       ceClient,
+				logger:         logger,
+				time:           standardTime{},
+			}
+
+			req, _ := http.NewRequest("GET", "/", read(body))
+
+			rr := httptest.NewRecorder()
+			http.HandlerFunc(handler.handleAll).ServeHTTP(rr, req)
+
+			assert.Equal(t, c.expectedCode, rr.Code, "unexpected response code")
+
+			if c.expectedTarget == nil {
+				select {
+				case event := <-chEvent:
+					assert.Fail(t, "unexpected cloud event %q was delivered", event.ID())
+				case <-time.After(100 * time.Millisecond):
+				}
+				return
+			}
+
+			select {
+			case event := <-chEvent:
+				assert.Equal(t, "Ev08MFMKH6", event.ID(), "event ID does not match")
+				assert.Equal(t, *c.expectedTarget, <-ceClient.sentTargets, "event delivered to the wrong sink")
+			case <-time.After(1 * time.Second):
+				assert.Fail(t, "expected cloud event was not delivered")
+			}
+		})
+	}
+}
+
+func TestSlackInteraction(t *testing.T) {
+	const signingSecret = "6623e5d64e469c64908c481b6de975f0"
+
+	const payload = `{"type":"block_actions","api_app_id":"A0000000001","team":{"id":"TXXXXXXXX"},"trigger_id":"123.456.abc","actions":[{"action_id":"approve"}]}`
+
+	logger := zapt.NewLogger(t).Sugar()
+
+	ceClient, chEvent := cloudeventst.NewMockSenderClient(t, 1)
+
+	handler := &slackEventAPIHandler{
+		signingSecret: signingSecret,
+		ceClient:      ceClient,
+		logger:        logger,
+		time:          standardTime{},
+	}
+
+	req := signedRequest(t, signingSecret, url.Values{"payload": {payload}}.Encode())
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	rr := httptest.NewRecorder()
+	http.HandlerFunc(handler.handleAll).ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code, "unexpected response code")
+
+	select {
+	case event := <-chEvent:
+		assert.Equal(t, v1alpha1.SlackInteractionEventType, event.Type())
+		assert.Equal(t, "TXXXXXXXX", event.Source())
+		assert.Equal(t, "block_actions", event.Subject())
+		assert.JSONEq(t, payload, string(event.Data()))
+	case <-time.After(1 * time.Second):
+		assert.Fail(t, "expected cloud event was not sent")
+	}
+}
+
+func TestCloudEventFromEventWrapper(t *testing.T) {
+	tc := map[string]struct {
+		fixture string
+
+		expectedSource     string
+		expectedExtensions map[string]interface{}
+	}{
+		"single workspace": {
+			fixture: "event_workspace.json",
+
+			expectedSource: "TA1J7JEBS",
+			expectedExtensions: map[string]interface{}{
+				apiAppIdCeExtension: "A01624EULRY",
+			},
+		},
+		"Enterprise Grid org-wide install": {
+			fixture: "event_grid_org_install.json",
+
+			expectedSource: "E0000000001/T0000000001",
+			expectedExtensions: map[string]interface{}{
+				apiAppIdCeExtension:              "A0000000001",
+				enterpriseIDCeExtension:          "E0000000001",
+				contextTeamIDCeExtension:         "T0000000001",
+				authUserIDCeExtension:            "W0000000002",
+				authEnterpriseInstallCeExtension: true,
+			},
+		},
+		"Enterprise Grid externally shared channel": {
+			fixture: "event_grid_ext_shared.json",
+
+			expectedSource: "E0000000001/T0000000001",
+			expectedExtensions: map[string]interface{}{
+				apiAppIdCeExtension:         "A0000000001",
+				enterpriseIDCeExtension:     "E0000000001",
+				contextTeamIDCeExtension:    "T0000000009",
+				extSharedChannelCeExtension: true,
+				authTeamIDCeExtension:       "T0000000001",
+				authUserIDCeExtension:       "U0000000002",
+			},
+		},
+	}
+
+	for name, c := range tc {
+		t.Run(name, func(t *testing.T) {
+			data, err := ioutil.ReadFile(fixturesDir + c.fixture)
+			if err != nil {
+				t.Fatalf("Error reading fixture file: %s", err)
+			}
+
+			wrapper := &SlackEventWrapper{}
+			if err := json.Unmarshal(data, wrapper); err != nil {
+				t.Fatalf("Error deserializing fixture: %s", err)
+			}
+
+			event, err := cloudEventFromEventWrapper(wrapper)
+			assert.NoError(t, err)
+			assert.NoError(t, event.Validate())
+
+			assert.Equal(t, c.expectedSource, event.Source(), "event source does not match")
+			assert.Equal(t, c.expectedExtensions, event.Extensions(), "event extensions do not match")
+		})
+	}
+}
+
+// nackingClient is a CloudEvents client which rejects the events sent to
+// the given targets, and forwards other events to the wrapped client. An
+// empty target denotes the client's default target.
+type nackingClient struct {
+	cloudevents.Client
+
+	nackTargets map[string]struct{}
+	// targets of the events forwarded to the wrapped client
+	sentTargets chan string
+}
+
+// Send implements cloudevents.Client.
+func (c *nackingClient) Send(ctx context.Context, event cloudevents.Event) protocol.Result {
+	var target string
+	if u := cloudevents.TargetFromContext(ctx); u != nil {
+		target = u.String()
+	}
+
+	if _, nack := c.nackTargets[target]; nack {
+		return cloudevents.NewHTTPResult(http.StatusServiceUnavailable, "sink unavailable")
+	}
+
+	c.sentTargets <- target
+	return c.Client.Send(ctx, event)
+}
+
 type mockedTime struct {
 	t time.Time
 }
@@ -295,3 +695,26 @@ var _ timeWrap = (*mockedTime)(nil)
 func read(s string) io.Reader {
 	return strings.NewReader(s)
 }
+
+// signedRequest returns a request with the given body, signed with the
+// given Slack signing secret.
+func signedRequest(t *testing.T, signingSecret, body string) *http.Request {
+	t.Helper()
+
+	ts := strconv.FormatInt(time.Now().Unix(), 10)
+
+	hm := hmac.New(sha256.New, []byte(signingSecret))
+	if _, err := hm.Write([]byte("v0:" + ts + ":" + body)); err != nil {
+		t.Fatalf("Failed to compute request signature: %s", err)
+	}
+
+	req, _ := http.NewRequest("GET", "/", ioutil.NopCloser(read(body)))
+	req.Header.Set(signatureHeader, "v0="+hex.EncodeToString(hm.Sum(nil)))
+	req.Header.Set(signatureTimestampHeader, ts)
+
+	return req
+}
+
+func strPtr(s string) *string {
+	return &s
+}
@@ -0,0 +1,282 @@
+/*
+Copyright (c) 2020 TriggerMesh Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package slacksource
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/gorilla/websocket"
+	"go.uber.org/zap"
+)
+
+// Types of envelopes received over a Socket Mode connection.
+// See: https://api.slack.com/apis/connections/socket-implement
+const (
+	socketModeEnvelopeHello       = "hello"
+	socketModeEnvelopeDisconnect  = "disconnect"
+	socketModeEnvelopeEventsAPI   = "events_api"
+	socketModeEnvelopeInteractive = "interactive"
+)
+
+// Bounds of the delay between two consecutive attempts to reconnect after a
+// Socket Mode connection failed.
+const (
+	socketModeMinReconnectDelay = 1 * time.Second
+	socketModeMaxReconnectDelay = 1 * time.Minute
+)
+
+// socketModeMaxConcurrency is the maximum number of envelopes processed
+// concurrently. Envelopes are acknowledged as soon as they are received, and
+// reading from the connection is paused while this limit is reached.
+const socketModeMaxConcurrency = 16
+
+// socketModeEnvelope wraps every message received over a Socket Mode
+// connection.
+type socketModeEnvelope struct {
+	Type       string          `json:"type"`
+	EnvelopeID string          `json:"envelope_id,omitempty"`
+	Payload    json.RawMessage `json:"payload,omitempty"`
+	// only set in "disconnect" envelopes
+	Reason string `json:"reason,omitempty"`
+}
+
+// socketModeAck acknowledges the reception of an envelope.
+type socketModeAck struct {
+	EnvelopeID string `json:"envelope_id"`
+}
+
+// socketModeHandler receives Slack events over a Socket Mode connection
+// instead of exposing a HTTP endpoint, and forwards them the same way the
+// Events API handler does.
+type socketModeHandler struct {
+	events *slackEventAPIHandler
+	api    *slackAPIClient
+	dialer *websocket.Dialer
+
+	// bounds and tracks the processing of envelopes
+	sem chan struct{}
+	wg  sync.WaitGroup
+
+	logger *zap.SugaredLogger
+}
+
+var _ SlackEventAPIHandler = (*socketModeHandler)(nil)
+
+// newSocketModeHandler returns a handler which receives events in Socket
+// Mode and processes them using the given Events API handler. The Slack Web
+// API client must authenticate using an app-level token.
+func newSocketModeHandler(events *slackEventAPIHandler, api *slackAPIClient, logger *zap.SugaredLogger) *socketModeHandler {
+	return &socketModeHandler{
+		events: events,
+		api:    api,
+		dialer: &websocket.Dialer{
+			Proxy:            http.ProxyFromEnvironment,
+			HandshakeTimeout: slackAPITimeout,
+		},
+		sem:    make(chan struct{}, socketModeMaxConcurrency),
+		logger: logger,
+	}
+}
+
+// Start opens a Socket Mode connection and receives events until the
+// context is cancelled, reconnecting whenever the connection is closed.
+func (h *socketModeHandler) Start(ctx context.Context) error {
+	h.logger.Info("Starting Slack Socket Mode handler")
+
+	// envelopes being processed were already acknowledged
+	defer h.wg.Wait()
+
+	delay := socketModeMinReconnectDelay
+
+	for {
+		err := h.connectAndServe(ctx)
+		if ctx.Err() != nil {
+			h.logger.Info("Socket Mode handler stopped")
+			return nil
+		}
+
+		// Slack requested a new connection
+		if err == nil {
+			delay = socketModeMinReconnectDelay
+			continue
+		}
+
+		h.logger.Errorw("Socket Mode connection failed, reconnecting", zap.Error(err), zap.Duration("delay", delay))
+
+		select {
+		case <-ctx.Done():
+			h.logger.Info("Socket Mode handler stopped")
+			return nil
+		case <-time.After(delay):
+		}
+
+		if delay *= 2; delay > socketModeMaxReconnectDelay {
+			delay = socketModeMaxReconnectDelay
+		}
+	}
+}
+
+// connectAndServe opens a Socket Mode connection and handles the envelopes
+// received from it. It returns a nil error when Slack closes the connection
+// gracefully.
+func (h *socketModeHandler) connectAndServe(ctx context.Context) error {
+	wsURL, err := h.api.appsConnectionsOpen(ctx)
+	if err != nil {
+		return fmt.Errorf("requesting Socket Mode URL: %w", err)
+	}
+
+	conn, _, err := h.dialer.DialContext(ctx, wsURL, nil)
+	if err != nil {
+		return fmt.Errorf("connecting to Socket Mode URL: %w", err)
+	}
+	defer conn.Close()
+
+	// unblock pending reads when the context is cancelled
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.Close()
+		case <-done:
+		}
+	}()
+
+	for {
+		env := &socketModeEnvelope{}
+		if err := conn.ReadJSON(env); err != nil {
+			return fmt.Errorf("reading from Socket Mode connection: %w", err)
+		}
+
+		switch env.Type {
+		case socketModeEnvelopeHello:
+			h.logger.Info("Socket Mode connection established")
+			continue
+
+		case socketModeEnvelopeDisconnect:
+			h.logger.Infow("Socket Mode connection closed by Slack", zap.String("reason", env.Reason))
+			return nil
+		}
+
+		// Envelopes are acknowledged before being processed, so that slow
+		// sinks don't cause Slack to retry them. Failures to deliver are
+		// handled by the buffer or the dead-letter sink, if configured.
+		if env.EnvelopeID != "" {
+			if err := conn.WriteJSON(&socketModeAck{EnvelopeID: env.EnvelopeID}); err != nil {
+				return fmt.Errorf("acknowledging Socket Mode envelope: %w", err)
+			}
+		}
+
+		switch env.Type {
+		case socketModeEnvelopeEventsAPI:
+			h.dispatch(ctx, func() { h.handleEventsAPI(ctx, env) })
+		case socketModeEnvelopeInteractive:
+			h.dispatch(ctx, func() { h.handleInteractive(ctx, env) })
+		default:
+			h.logger.Warnf("not supported envelope type %q", env.Type)
+		}
+	}
+}
+
+// dispatch runs the given function asynchronously once fewer than
+// socketModeMaxConcurrency envelopes are being processed. It blocks until
+// then, or until the context is cancelled.
+func (h *socketModeHandler) dispatch(ctx context.Context, fn func()) {
+	select {
+	case h.sem <- struct{}{}:
+	case <-ctx.Done():
+		return
+	}
+
+	h.wg.Add(1)
+	go func() {
+		defer func() {
+			<-h.sem
+			h.wg.Done()
+		}()
+		fn()
+	}()
+}
+
+// handleEventsAPI forwards the Slack event contained in the given envelope.
+func (h *socketModeHandler) handleEventsAPI(ctx context.Context, env *socketModeEnvelope) {
+	wrapper := &SlackEventWrapper{}
+	if err := json.Unmarshal(env.Payload, wrapper); err != nil {
+		h.logger.Errorw("Could not unmarshal events_api payload", zap.Error(err))
+		return
+	}
+
+	app, ok := h.events.appFor(wrapper.APIAppID)
+	if !ok {
+		return
+	}
+
+	var event *cloudevents.Event
+	var err error
+
+	switch wrapper.Type {
+	case "event_callback":
+		event, err = h.events.processCallback(ctx, app, wrapper)
+	case "app_rate_limited":
+		event, err = h.events.processRateLimit(wrapper)
+	default:
+		h.logger.Warnf("not supported content %q", wrapper.Type)
+		return
+	}
+	if err != nil {
+		h.logger.Errorw("Could not process Slack event", zap.Error(err))
+		return
+	}
+	if event == nil {
+		return
+	}
+
+	if err := h.events.deliver(ctx, app, event, wrapper.Event); err != nil {
+		h.logger.Errorw("Could not deliver CloudEvent", zap.Error(err))
+	}
+}
+
+// handleInteractive forwards the interactivity payload contained in the
+// given envelope.
+func (h *socketModeHandler) handleInteractive(ctx context.Context, env *socketModeEnvelope) {
+	interaction := &SlackInteraction{}
+	if err := json.Unmarshal(env.Payload, interaction); err != nil {
+		h.logger.Errorw("Could not unmarshal interactive payload", zap.Error(err))
+		return
+	}
+
+	app, ok := h.events.appFor(interaction.APIAppID)
+	if !ok {
+		return
+	}
+
+	event, err := cloudEventFromInteraction(interaction, env.Payload)
+	if err != nil {
+		h.logger.Errorw("Could not process Slack interaction", zap.Error(err))
+		return
+	}
+
+	if err := h.events.deliver(ctx, app, event, nil); err != nil {
+		h.logger.Errorw("Could not deliver CloudEvent", zap.Error(err))
+	}
+}
@@ -0,0 +1,241 @@
+/*
+Copyright (c) 2020 TriggerMesh Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package slacksource
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const slackAPITimeout = 5 * time.Second
+
+// slackAPIClient is a minimal client for the Slack Web API.
+// See: https://api.slack.com/web
+type slackAPIClient struct {
+	baseURL string
+	token   string
+
+	client *http.Client
+}
+
+// newSlackAPIClient returns a Slack Web API client which authenticates using
+// the given token.
+func newSlackAPIClient(baseURL, token string) *slackAPIClient {
+	return &slackAPIClient{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		token:   token,
+		client:  &http.Client{Timeout: slackAPITimeout},
+	}
+}
+
+// slackAPIResponse contains the attributes common to all Slack Web API
+// responses.
+type slackAPIResponse struct {
+	OK    bool   `json:"ok"`
+	Error string `json:"error,omitempty"`
+}
+
+// slackAPIError is returned when a call to the Slack Web API fails.
+type slackAPIError struct {
+	Method     string
+	StatusCode int
+	Code       string
+	// RetryAfter is set when the call was rate limited.
+	// See: https://api.slack.com/docs/rate-limits
+	RetryAfter time.Duration
+}
+
+// Error implements the error interface.
+func (e *slackAPIError) Error() string {
+	if e.Code != "" {
+		return fmt.Sprintf("Slack API method %s returned error %q", e.Method, e.Code)
+	}
+	return fmt.Sprintf("Slack API method %s returned status code %d", e.Method, e.StatusCode)
+}
+
+// get calls the given Slack Web API method with the given query parameters
+// and decodes the response into out.
+func (c *slackAPIClient) get(ctx context.Context, method string, params url.Values, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+"/"+method+"?"+params.Encode(), nil)
+	if err != nil {
+		return fmt.Errorf("creating request: %w", err)
+	}
+
+	return c.do(req, method, out)
+}
+
+// post calls the given Slack Web API method with the given form-encoded
+// arguments and decodes the response into out.
+func (c *slackAPIClient) post(ctx context.Context, method string, args url.Values, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/"+method, strings.NewReader(args.Encode()))
+	if err != nil {
+		return fmt.Errorf("creating request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	return c.do(req, method, out)
+}
+
+// postJSON calls the given Slack Web API method with the given arguments
+// serialized to JSON, and decodes the response into out.
+func (c *slackAPIClient) postJSON(ctx context.Context, method string, args interface{}, out interface{}) error {
+	body, err := json.Marshal(args)
+	if err != nil {
+		return fmt.Errorf("serializing arguments: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/"+method, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("creating request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+
+	return c.do(req, method, out)
+}
+
+// do sends the given request to the Slack Web API and decodes the response
+// into out.
+func (c *slackAPIClient) do(req *http.Request, method string, out interface{}) error {
+	req.Header.Set("Authorization", "Bearer "+c.token)
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("calling Slack API method %s: %w", method, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		apiErr := &slackAPIError{
+			Method:     method,
+			StatusCode: resp.StatusCode,
+		}
+
+		if resp.StatusCode == http.StatusTooManyRequests {
+			apiErr.Code = "ratelimited"
+			if secs, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+				apiErr.RetryAfter = time.Duration(secs) * time.Second
+			}
+		}
+
+		return apiErr
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("reading response of Slack API method %s: %w", method, err)
+	}
+
+	apiResp := &slackAPIResponse{}
+	if err := json.Unmarshal(body, apiResp); err != nil {
+		return fmt.Errorf("decoding response of Slack API method %s: %w", method, err)
+	}
+
+	if !apiResp.OK {
+		return &slackAPIError{
+			Method:     method,
+			StatusCode: resp.StatusCode,
+			Code:       apiResp.Error,
+		}
+	}
+
+	if out == nil {
+		return nil
+	}
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("decoding response of Slack API method %s: %w", method, err)
+	}
+
+	return nil
+}
+
+// slackUser is a Slack user, as returned by the users.info method.
+// See: https://api.slack.com/types/user
+type slackUser struct {
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	RealName string `json:"real_name"`
+	Profile  struct {
+		Email       string `json:"email"`
+		DisplayName string `json:"display_name"`
+	} `json:"profile"`
+}
+
+// usersInfo returns the details of the Slack user with the given ID.
+// See: https://api.slack.com/methods/users.info
+func (c *slackAPIClient) usersInfo(ctx context.Context, userID string) (*slackUser, error) {
+	var resp struct {
+		User slackUser `json:"user"`
+	}
+
+	if err := c.get(ctx, "users.info", url.Values{"user": {userID}}, &resp); err != nil {
+		return nil, err
+	}
+
+	return &resp.User, nil
+}
+
+// slackChannel is a Slack conversation, as returned by the
+// conversations.info method.
+// See: https://api.slack.com/types/conversation
+type slackChannel struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// conversationsInfo returns the details of the Slack conversation with the
+// given ID.
+// See: https://api.slack.com/methods/conversations.info
+func (c *slackAPIClient) conversationsInfo(ctx context.Context, channelID string) (*slackChannel, error) {
+	var resp struct {
+		Channel slackChannel `json:"channel"`
+	}
+
+	if err := c.get(ctx, "conversations.info", url.Values{"channel": {channelID}}, &resp); err != nil {
+		return nil, err
+	}
+
+	return &resp.Channel, nil
+}
+
+// appsConnectionsOpen returns the URL of a new Socket Mode WebSocket. The
+// client must authenticate using an app-level token.
+// See: https://api.slack.com/methods/apps.connections.open
+func (c *slackAPIClient) appsConnectionsOpen(ctx context.Context) (string, error) {
+	var resp struct {
+		URL string `json:"url"`
+	}
+
+	if err := c.post(ctx, "apps.connections.open", nil, &resp); err != nil {
+		return "", err
+	}
+
+	return resp.URL, nil
+}
+
+// chatPostMessage posts the given message to a Slack conversation.
+// See: https://api.slack.com/methods/chat.postMessage
+func (c *slackAPIClient) chatPostMessage(ctx context.Context, msg map[string]interface{}) error {
+	return c.postJSON(ctx, "chat.postMessage", msg, nil)
+}
@@ -22,10 +22,18 @@ import (
 	cloudevents "github.com/cloudevents/sdk-go/v2"
 	"go.uber.org/zap"
 
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+
 	"knative.dev/eventing/pkg/adapter/v2"
 	"knative.dev/pkg/logging"
 
+	"github.com/triggermesh/knative-sources/pkg/adapter/common/buffer"
+	"github.com/triggermesh/knative-sources/pkg/adapter/common/stats"
+	"github.com/triggermesh/knative-sources/pkg/adapter/common/tracing"
 	"github.com/triggermesh/knative-sources/pkg/apis/sources/v1alpha1"
+	"github.com/triggermesh/knative-sources/pkg/zendesk/incremental"
+	"github.com/triggermesh/knative-sources/pkg/zendesk/webhooks"
 )
 
 var _ adapter.Adapter = (*zendeskAdapter)(nil)
@@ -41,9 +49,94 @@ func NewAdapter(ctx context.Context, aEnv adapter.EnvConfigAccessor, ceClient cl
 	logger := logging.FromContext(ctx)
 	eventsource := v1alpha1.ZendeskSourceName(env.Subdomain, env.Name)
 
-	return &zendeskAdapter{
-		handler: NewZendeskAPIHandler(ceClient, env.WebhookUsername, env.WebhookPassword, eventsource, logger.Named("handler")),
+	reporter := stats.NewReporter(env.EnvConfig)
+	ceClient = stats.InstrumentClient(ceClient, reporter)
+
+	// buffered events are acknowledged before being delivered
+	var buf *buffer.Client
+	if env.Buffer.Enabled() {
+		buf = buffer.NewClient(env.Buffer, ceClient, reporter, logger.Named("buffer"))
+		ceClient = buf
+	}
+	ceClient = tracing.InstrumentClient(ceClient)
+
+	// In polling mode, ticket events are exported periodically from the
+	// Zendesk API instead of being received from Zendesk.
+	if env.PollingInterval > 0 {
+		var cursor cursorStore
+		if env.CursorConfigMap != "" {
+			cursor = newCursorStoreInCluster(env.Namespace, env.CursorConfigMap, logger)
+		}
+
+		return buffer.WithDelivery(&zendeskAdapter{
+			handler: newTicketEventsPoller(incremental.NewClient(env.Subdomain, env.Email, env.APIToken), cursor,
+				env.PollingInterval, env.Events, ceClient, eventsource, logger.Named("poller")),
+			logger: logger,
+		}, buf)
+	}
+
+	handler := NewZendeskAPIHandler(ceClient, env.Config, reporter, newRequestAuthenticator(env), eventsource,
+		logger.Named("handler"))
+
+	return buffer.WithDelivery(&zendeskAdapter{
+		handler: handler,
 		logger:  logger,
+	}, buf)
+}
+
+// newRequestAuthenticator returns a requestAuthenticator suitable for the
+// Zendesk API which notifies the adapter.
+func newRequestAuthenticator(env *envAccessor) requestAuthenticator {
+	if env.WebhookName == "" {
+		return &basicAuthenticator{
+			username: env.WebhookUsername,
+			password: env.WebhookPassword,
+		}
+	}
+
+	client := webhooks.NewClient(env.Subdomain, env.Email, env.APIToken)
+
+	names := []string{env.WebhookName}
+	if env.EventsWebhookName != "" {
+		names = append(names, env.EventsWebhookName)
+	}
+
+	secrets := make([]signingSecretGetter, len(names))
+	for i, n := range names {
+		secrets[i] = &webhookSigningSecret{
+			client: client,
+			name:   n,
+			time:   standardTime{},
+		}
+	}
+
+	return &signatureVerifier{
+		secrets: secrets,
+		time:    standardTime{},
+	}
+}
+
+// newCursorStoreInCluster returns a cursorStore which persists the polling
+// cursor in the given ConfigMap using the in-cluster Kubernetes configuration,
+// or nil if the Kubernetes API can not be reached.
+func newCursorStoreInCluster(namespace, name string, logger *zap.SugaredLogger) cursorStore {
+	cfg, err := rest.InClusterConfig()
+	if err != nil {
+		logger.Warnw("Unable to persist the polling cursor, "+
+			"the Kubernetes API client can not be configured", zap.Error(err))
+		return nil
+	}
+
+	client, err := kubernetes.NewForConfig(cfg)
+	if err != nil {
+		logger.Warnw("Unable to persist the polling cursor, "+
+			"the Kubernetes API client can not be created", zap.Error(err))
+		return nil
+	}
+
+	return &configMapCursorStore{
+		cmClient: client.CoreV1().ConfigMaps(namespace),
+		name:     name,
 	}
 }
 
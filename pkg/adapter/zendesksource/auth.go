@@ -0,0 +1,257 @@
+/*
+Copyright (c) 2020 TriggerMesh Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package zendesksource
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/triggermesh/knative-sources/pkg/zendesk/webhooks"
+)
+
+// requestAuthenticator authenticates the requests sent by Zendesk.
+type requestAuthenticator interface {
+	authenticate(r *http.Request, body []byte) error
+}
+
+// basicAuthenticator authenticates requests sent by a Zendesk Target, which
+// carry Basic Authentication credentials.
+type basicAuthenticator struct {
+	username string
+	password string
+}
+
+var _ requestAuthenticator = (*basicAuthenticator)(nil)
+
+const (
+	// auth header prefix, it is important that the blank
+	// space is present at the end for string manipulation
+	// at auth parsing function.
+	authPrefix = "Basic "
+)
+
+// authenticate implements requestAuthenticator.
+func (a *basicAuthenticator) authenticate(r *http.Request, _ []byte) error {
+	auth := r.Header.Get("Authorization")
+	if !strings.HasPrefix(auth, authPrefix) {
+		return errors.New("incorrect auth header")
+	}
+
+	content, err := base64.StdEncoding.DecodeString(auth[len(authPrefix):])
+	if err != nil {
+		return errors.New("could not decode the auth header")
+	}
+
+	pair := strings.SplitN(string(content), ":", 2)
+	if len(pair) != 2 {
+		return errors.New("misformated credentials at auth header")
+	}
+
+	// both credentials are always compared, in constant time, so the
+	// response time doesn't reveal which of them is wrong
+	userOK := subtle.ConstantTimeCompare([]byte(pair[0]), []byte(a.username)) == 1
+	passOK := subtle.ConstantTimeCompare([]byte(pair[1]), []byte(a.password)) == 1
+	if !userOK || !passOK {
+		return fmt.Errorf("credentials received for user %q are not valid", pair[0])
+	}
+
+	return nil
+}
+
+const (
+	signatureHeader          = "X-Zendesk-Webhook-Signature"
+	signatureTimestampHeader = "X-Zendesk-Webhook-Signature-Timestamp"
+	expiresSeconds           = int64(300)
+)
+
+// timeWrap allows for mocking Now functions at tests.
+type timeWrap interface {
+	Now() time.Time
+}
+
+type standardTime struct{}
+
+func (standardTime) Now() time.Time {
+	return time.Now()
+}
+
+var _ timeWrap = (*standardTime)(nil)
+
+// signatureVerifier authenticates requests sent by Zendesk webhooks, which
+// are signed with the signing secret of the webhook that sent them.
+// See: https://developer.zendesk.com/documentation/event-connectors/webhooks/verifying/
+type signatureVerifier struct {
+	// one per webhook which notifies the adapter
+	secrets []signingSecretGetter
+	time    timeWrap
+}
+
+var _ requestAuthenticator = (*signatureVerifier)(nil)
+
+// authenticate implements requestAuthenticator.
+func (v *signatureVerifier) authenticate(r *http.Request, body []byte) error {
+	signature := r.Header.Get(signatureHeader)
+	if signature == "" {
+		return errors.New("empty signature header")
+	}
+
+	timestamp := r.Header.Get(signatureTimestampHeader)
+	if timestamp == "" {
+		return errors.New("empty signature timestamp header")
+	}
+
+	ts, err := time.Parse(time.RFC3339, timestamp)
+	if err != nil {
+		return fmt.Errorf("error parsing header timestamp: %w", err)
+	}
+
+	// timestamps in the future are rejected as well, otherwise a captured
+	// request would remain valid until its timestamp is reached
+	age := v.time.Now().Unix() - ts.Unix()
+	if age > expiresSeconds {
+		return errors.New("signing timestamp expired")
+	}
+	if age < -expiresSeconds {
+		return errors.New("signing timestamp is in the future")
+	}
+
+	// cached secrets are tried first, since a webhook may have been
+	// re-created, or its signing secret rotated
+	var retrieved bool
+	var retrieveErr error
+
+	for _, refresh := range []bool{false, true} {
+		for _, s := range v.secrets {
+			secret, err := s.signingSecret(r.Context(), refresh)
+			if err != nil {
+				// a webhook may not exist yet, which shouldn't
+				// prevent the verification of other webhooks'
+				// requests
+				retrieveErr = err
+				continue
+			}
+			retrieved = true
+
+			if verifySignature(secret, signature, timestamp, body) {
+				return nil
+			}
+		}
+	}
+
+	if !retrieved && retrieveErr != nil {
+		return &signingSecretError{err: retrieveErr}
+	}
+
+	return errors.New("received wrong signature signing hash")
+}
+
+// signingSecretError indicates that a request could not be authenticated
+// because no signing secret could be retrieved from the Zendesk API. Such
+// requests are not necessarily illegitimate.
+type signingSecretError struct {
+	err error
+}
+
+// Error implements error.
+func (e *signingSecretError) Error() string {
+	return "error retrieving webhook signing secret: " + e.err.Error()
+}
+
+// Unwrap returns the error returned by the Zendesk API.
+func (e *signingSecretError) Unwrap() error {
+	return e.err
+}
+
+// verifySignature returns whether the given signature is the signature of the
+// given timestamp and body using the given signing secret.
+func verifySignature(secret, signature, timestamp string, body []byte) bool {
+	if secret == "" {
+		return false
+	}
+
+	hm := hmac.New(sha256.New, []byte(secret))
+	_, _ = hm.Write([]byte(timestamp))
+	_, _ = hm.Write(body)
+
+	challenge := base64.StdEncoding.EncodeToString(hm.Sum(nil))
+
+	return hmac.Equal([]byte(challenge), []byte(signature))
+}
+
+// signingSecretGetter returns the signing secret of a Zendesk webhook.
+type signingSecretGetter interface {
+	// signingSecret returns the signing secret of the webhook. When refresh
+	// is true, a cached signing secret is retrieved again.
+	signingSecret(ctx context.Context, refresh bool) (string, error)
+}
+
+// minimum interval between two retrievals of the signing secret, which
+// prevents unauthenticated requests from exhausting the Zendesk API rate limit
+const signingSecretRefreshInterval = time.Minute
+
+// webhookSigningSecret retrieves the signing secret of the Zendesk webhook
+// with the given name from the Zendesk API, and caches it.
+// The webhook is created by the controller once the adapter is reachable,
+// so it may not exist yet when the adapter starts.
+type webhookSigningSecret struct {
+	client *webhooks.Client
+	name   string
+	time   timeWrap
+
+	mu          sync.Mutex
+	secret      string
+	lastFetched time.Time
+}
+
+var _ signingSecretGetter = (*webhookSigningSecret)(nil)
+
+// signingSecret implements signingSecretGetter.
+func (s *webhookSigningSecret) signingSecret(ctx context.Context, refresh bool) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	mustFetch := s.secret == "" || refresh
+	if !mustFetch || s.time.Now().Sub(s.lastFetched) < signingSecretRefreshInterval {
+		return s.secret, nil
+	}
+	s.lastFetched = s.time.Now()
+
+	wh, err := s.client.FindWebhook(ctx, s.name)
+	if err != nil {
+		return "", err
+	}
+	if wh == nil {
+		return "", fmt.Errorf("webhook %q not found", s.name)
+	}
+
+	secret, err := s.client.GetSigningSecret(ctx, wh.ID)
+	if err != nil {
+		return "", err
+	}
+	s.secret = secret
+
+	return s.secret, nil
+}
@@ -0,0 +1,219 @@
+/*
+Copyright (c) 2020 TriggerMesh Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package zendesksource
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/triggermesh/knative-sources/pkg/zendesk/webhooks"
+)
+
+func TestSignatureVerifier(t *testing.T) {
+	const (
+		webhookName = "io.triggermesh.zendesksource.test.my-source"
+		webhookID   = "01WEBHOOK"
+
+		body      = `{"ticket":{"id":1}}`
+		timestamp = "2020-11-10T10:00:00Z"
+	)
+
+	now := fixedTime(time.Date(2020, 11, 10, 10, 1, 0, 0, time.UTC))
+
+	tc := map[string]struct {
+		signingSecret string
+		timestamp     string
+		signature     string
+
+		expectErr         string
+		expectSecretFetch int
+	}{
+		"valid signature": {
+			signingSecret:     "secret",
+			timestamp:         timestamp,
+			signature:         sign("secret", timestamp, body),
+			expectSecretFetch: 1,
+		},
+		"wrong signature": {
+			signingSecret: "secret",
+			timestamp:     timestamp,
+			signature:     sign("other-secret", timestamp, body),
+			expectErr:     "received wrong signature signing hash",
+			// refreshed secret is not retrieved again within the
+			// refresh interval
+			expectSecretFetch: 1,
+		},
+		"expired timestamp": {
+			signingSecret: "secret",
+			timestamp:     "2020-11-10T09:00:00Z",
+			signature:     sign("secret", "2020-11-10T09:00:00Z", body),
+			expectErr:     "signing timestamp expired",
+		},
+		"future timestamp": {
+			signingSecret: "secret",
+			timestamp:     "2020-11-10T11:00:00Z",
+			signature:     sign("secret", "2020-11-10T11:00:00Z", body),
+			expectErr:     "signing timestamp is in the future",
+		},
+		"missing signature": {
+			signingSecret: "secret",
+			timestamp:     timestamp,
+			expectErr:     "empty signature header",
+		},
+	}
+
+	for name, c := range tc {
+		t.Run(name, func(t *testing.T) {
+			var secretFetches int
+
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				var resp interface{}
+
+				switch r.URL.Path {
+				case "/webhooks":
+					resp = map[string]interface{}{
+						"webhooks": []webhooks.Webhook{{ID: webhookID, Name: webhookName}},
+					}
+				case "/webhooks/" + webhookID + "/signing_secret":
+					secretFetches++
+					resp = map[string]interface{}{
+						"signing_secret": map[string]string{"algorithm": "SHA256", "secret": c.signingSecret},
+					}
+				default:
+					http.NotFound(w, r)
+					return
+				}
+
+				w.Header().Set("Content-Type", "application/json")
+				_ = json.NewEncoder(w).Encode(resp)
+			}))
+			defer srv.Close()
+
+			client := webhooks.NewClient("example", "me@example.com", "api-token")
+			client.SetEndpointURL(srv.URL)
+
+			v := &signatureVerifier{
+				secrets: []signingSecretGetter{
+					&webhookSigningSecret{
+						client: client,
+						name:   webhookName,
+						time:   now,
+					},
+				},
+				time: now,
+			}
+
+			req, _ := http.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+			req.Header.Set(signatureTimestampHeader, c.timestamp)
+			if c.signature != "" {
+				req.Header.Set(signatureHeader, c.signature)
+			}
+
+			err := v.authenticate(req, []byte(body))
+			if c.expectErr == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, c.expectErr)
+			}
+			assert.Equal(t, c.expectSecretFetch, secretFetches, "unexpected number of signing secret retrievals")
+		})
+	}
+}
+
+func TestSignatureVerifierMultipleWebhooks(t *testing.T) {
+	const (
+		body      = `{"type":"zen:event-type:user.created"}`
+		timestamp = "2020-11-10T10:00:00Z"
+	)
+
+	now := fixedTime(time.Date(2020, 11, 10, 10, 1, 0, 0, time.UTC))
+
+	v := &signatureVerifier{
+		secrets: []signingSecretGetter{
+			staticSigningSecret{err: errors.New("webhook not found")},
+			staticSigningSecret{secret: "tickets-secret"},
+			staticSigningSecret{secret: "events-secret"},
+		},
+		time: now,
+	}
+
+	newRequest := func(secret string) *http.Request {
+		req, _ := http.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+		req.Header.Set(signatureTimestampHeader, timestamp)
+		req.Header.Set(signatureHeader, sign(secret, timestamp, body))
+		return req
+	}
+
+	assert.NoError(t, v.authenticate(newRequest("tickets-secret"), []byte(body)))
+	assert.NoError(t, v.authenticate(newRequest("events-secret"), []byte(body)))
+	assert.EqualError(t, v.authenticate(newRequest("other-secret"), []byte(body)),
+		"received wrong signature signing hash")
+
+	v.secrets = v.secrets[:1]
+	assert.EqualError(t, v.authenticate(newRequest("events-secret"), []byte(body)),
+		"error retrieving webhook signing secret: webhook not found")
+}
+
+func TestBasicAuthenticator(t *testing.T) {
+	a := &basicAuthenticator{username: "user", password: "pass"}
+
+	req, _ := http.NewRequest(http.MethodPost, "/", nil)
+	req.SetBasicAuth("user", "pass")
+	assert.NoError(t, a.authenticate(req, nil))
+
+	req.SetBasicAuth("user", "wrong")
+	assert.Error(t, a.authenticate(req, nil))
+
+	req.Header.Del("Authorization")
+	assert.Error(t, a.authenticate(req, nil))
+}
+
+// fixedTime is a timeWrap which always returns the same time.
+type fixedTime time.Time
+
+func (t fixedTime) Now() time.Time {
+	return time.Time(t)
+}
+
+// staticSigningSecret is a signingSecretGetter which always returns the same
+// signing secret or error.
+type staticSigningSecret struct {
+	secret string
+	err    error
+}
+
+func (s staticSigningSecret) signingSecret(context.Context, bool) (string, error) {
+	return s.secret, s.err
+}
+
+// sign returns the signature of a Zendesk webhook request.
+func sign(secret, timestamp, body string) string {
+	hm := hmac.New(sha256.New, []byte(secret))
+	hm.Write([]byte(timestamp + body))
+	return base64.StdEncoding.EncodeToString(hm.Sum(nil))
+}
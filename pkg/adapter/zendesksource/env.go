@@ -17,7 +17,12 @@ limitations under the License.
 package zendesksource
 
 import (
+	"time"
+
 	"knative.dev/eventing/pkg/adapter/v2"
+
+	"github.com/triggermesh/knative-sources/pkg/adapter/common/buffer"
+	"github.com/triggermesh/knative-sources/pkg/adapter/common/server"
 )
 
 // EnvAccessor for configuration parameters
@@ -27,8 +32,32 @@ func EnvAccessor() adapter.EnvConfigAccessor {
 
 type envAccessor struct {
 	adapter.EnvConfig
+	server.Config
+
+	// Events are queued in an on-disk buffer before being delivered to
+	// the sink when a buffer directory is set.
+	Buffer buffer.Config
 
 	WebhookUsername string `envconfig:"ZENDESK_WEBHOOK_USERNAME"`
 	WebhookPassword string `envconfig:"ZENDESK_WEBHOOK_PASSWORD"`
 	Subdomain       string `envconfig:"ZENDESK_SUBDOMAIN"`
+
+	// Name of the Zendesk webhook which notifies the adapter. When set,
+	// requests are authenticated by verifying their signature instead of
+	// Basic Authentication credentials.
+	WebhookName string `envconfig:"ZENDESK_WEBHOOK_NAME"`
+	// Name of the Zendesk webhook which notifies the adapter about user
+	// and organization events, if any.
+	EventsWebhookName string `envconfig:"ZENDESK_EVENTS_WEBHOOK_NAME"`
+	// Credentials used to retrieve the signing secret of the webhook.
+	Email    string `envconfig:"ZENDESK_EMAIL"`
+	APIToken string `envconfig:"ZENDESK_API_TOKEN"`
+
+	// Interval at which the Zendesk API is polled for ticket events. When
+	// set, the adapter polls Zendesk instead of receiving notifications.
+	PollingInterval time.Duration `envconfig:"ZENDESK_POLLING_INTERVAL"`
+	// Zendesk events to generate in polling mode.
+	Events []string `envconfig:"ZENDESK_EVENTS"`
+	// Name of the ConfigMap in which the polling cursor is persisted.
+	CursorConfigMap string `envconfig:"ZENDESK_CURSOR_CONFIGMAP"`
 }
@@ -16,50 +16,191 @@ limitations under the License.
 
 package zendesksource
 
-// ZendeskEvent contains the event payload
-type ZendeskEvent map[string]interface{}
+import (
+	"bytes"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
 
-// ID returns the ID of the Zendesk ticket
-func (ze *ZendeskEvent) ID() string {
-	v, ok := (*ze)["id"]
-	if !ok {
-		return ""
+	"github.com/triggermesh/knative-sources/pkg/apis/sources/v1alpha1"
+)
+
+// ZendeskEvent is the payload sent to the adapter by a Zendesk Trigger. Its
+// attributes are rendered by Zendesk from the placeholders of the Trigger's
+// payload template.
+// See: https://support.zendesk.com/hc/en-us/articles/203662156
+type ZendeskEvent struct {
+	// Type of CloudEvent to generate. It is set in the payload of the
+	// Zendesk Trigger by the controller.
+	EventType string `json:"event_type"`
+
+	Ticket       *Ticket       `json:"ticket"`
+	CurrentUser  *User         `json:"current_user"`
+	Satisfaction *Satisfaction `json:"satisfaction"`
+}
+
+// Ticket is a Zendesk ticket.
+type Ticket struct {
+	ID           NumericID     `json:"id"`
+	ExternalID   string        `json:"external_id"`
+	Title        string        `json:"title"`
+	URL          string        `json:"url"`
+	Description  string        `json:"description"`
+	Via          string        `json:"via"`
+	Status       string        `json:"status"`
+	Priority     string        `json:"priority"`
+	TicketType   string        `json:"ticket_type"`
+	GroupName    string        `json:"group_name"`
+	BrandName    string        `json:"brand_name"`
+	DueDate      string        `json:"due_date"`
+	CreatedAt    string        `json:"created_at"`
+	UpdatedAt    string        `json:"updated_at"`
+	Tags         string        `json:"tags"`
+	Assignee     *User         `json:"assignee"`
+	Requester    *User         `json:"requester"`
+	Organization *Organization `json:"organization"`
+}
+
+// User is a Zendesk user, such as the requester of a ticket.
+type User struct {
+	ID           NumericID     `json:"id"`
+	Name         string        `json:"name"`
+	FirstName    string        `json:"first_name"`
+	LastName     string        `json:"last_name"`
+	Email        string        `json:"email"`
+	Language     string        `json:"language"`
+	Phone        string        `json:"phone"`
+	ExternalID   string        `json:"external_id"`
+	Details      string        `json:"details"`
+	Notes        string        `json:"notes"`
+	Organization *Organization `json:"organization"`
+}
+
+// Organization is a Zendesk organization.
+type Organization struct {
+	ID         NumericID `json:"id"`
+	Name       string    `json:"name"`
+	ExternalID string    `json:"external_id"`
+	Details    string    `json:"details"`
+	Notes      string    `json:"notes"`
+}
+
+// Satisfaction is the satisfaction rating of a ticket.
+type Satisfaction struct {
+	CurrentRating  string `json:"current_rating"`
+	CurrentComment string `json:"current_comment"`
+}
+
+// NumericID is the ID of a Zendesk object. Placeholders of IDs render as
+// numbers, which may or may not be quoted in a Trigger's payload template.
+type NumericID string
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (id *NumericID) UnmarshalJSON(b []byte) error {
+	b = bytes.TrimSpace(b)
+
+	if len(b) > 0 && b[0] == '"' {
+		var s string
+		if err := json.Unmarshal(b, &s); err != nil {
+			return err
+		}
+		*id = NumericID(s)
+		return nil
 	}
 
-	id, ok := v.(string)
-	if !ok {
-		return ""
+	if bytes.Equal(b, []byte("null")) {
+		*id = ""
+		return nil
 	}
 
-	return id
+	var n json.Number
+	if err := json.Unmarshal(b, &n); err != nil {
+		return err
+	}
+	*id = NumericID(n)
+	return nil
 }
 
-// Title returns the title of the Zendesk ticket
-func (ze *ZendeskEvent) Title() string {
-	v, ok := (*ze)["title"]
-	if !ok {
-		return ""
-	}
+// WebhookEvent is the payload sent to the adapter by a Zendesk webhook which
+// is subscribed to Zendesk events, such as user and organization events.
+// See: https://developer.zendesk.com/api-reference/webhooks/event-types/webhook-event-types/
+type WebhookEvent struct {
+	ID      string `json:"id"`
+	Type    string `json:"type"`
+	Subject string `json:"subject"`
+	Time    string `json:"time"`
 
-	t, ok := v.(string)
-	if !ok {
-		return ""
+	Detail *WebhookEventDetail `json:"detail"`
+}
+
+// WebhookEventDetail contains the attributes of the Zendesk object a
+// WebhookEvent is about.
+type WebhookEventDetail struct {
+	ID NumericID `json:"id"`
+}
+
+// isWebhookEvent returns whether the given payload of a Zendesk notification
+// is a WebhookEvent rather than the payload of a Trigger. Payload templates of
+// Triggers are arbitrary, so only the type of Zendesk event is inspected.
+func isWebhookEvent(payload []byte) bool {
+	var probe struct {
+		Type interface{} `json:"type"`
+	}
+	if err := json.Unmarshal(payload, &probe); err != nil {
+		return false
 	}
 
-	return t
+	typ, ok := probe.Type.(string)
+	return ok && strings.HasPrefix(typ, v1alpha1.ZendeskWebhookEventTypePrefix)
 }
 
-// Type returns the type of the Zendesk ticket
-func (ze *ZendeskEvent) Type() string {
-	v, ok := (*ze)["type"]
-	if !ok {
-		return ""
+// objectID returns the ID of the Zendesk object the event is about. Subjects
+// of Zendesk events have the format "zen:<object type>:<ID>".
+func (we *WebhookEvent) objectID() string {
+	if we.Detail != nil && we.Detail.ID != "" {
+		return string(we.Detail.ID)
 	}
+	return we.Subject[strings.LastIndexByte(we.Subject, ':')+1:]
+}
 
-	t, ok := v.(string)
-	if !ok {
+// eventTypeAttr is the attribute of Zendesk events which contains the type of
+// CloudEvent to generate.
+const eventTypeAttr = "event_type"
+
+// ticketID returns the ID of the event's ticket, if any.
+func (ze *ZendeskEvent) ticketID() string {
+	if ze.Ticket == nil {
 		return ""
 	}
+	return string(ze.Ticket.ID)
+}
+
+// updateTime returns the time of the last update of the event's ticket, or
+// the zero time if it is unknown.
+func (ze *ZendeskEvent) updateTime() time.Time {
+	if ze.Ticket == nil {
+		return time.Time{}
+	}
+
+	for _, ts := range []string{ze.Ticket.UpdatedAt, ze.Ticket.CreatedAt} {
+		if t, err := time.Parse(time.RFC3339, ts); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// cloudEventID returns a CloudEvent ID for the event, which is stable across
+// redeliveries of the same notification. Each update of a ticket can notify
+// several Triggers, so the ID also depends on the CloudEvent type.
+// The given fallback is used as a discriminator when the time of the update
+// is unknown, e.g. with payload templates which don't include it.
+func (ze *ZendeskEvent) cloudEventID(eventType, fallback string) string {
+	discriminator := fallback
+	if t := ze.updateTime(); !t.IsZero() {
+		discriminator = strconv.FormatInt(t.Unix(), 10)
+	}
 
-	return t
+	return ze.ticketID() + "-" + discriminator + "-" + eventType
 }
@@ -0,0 +1,466 @@
+/*
+Copyright (c) 2020 TriggerMesh Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package zendesksource
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"go.uber.org/zap"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	coreclientv1 "k8s.io/client-go/kubernetes/typed/core/v1"
+
+	"github.com/triggermesh/knative-sources/pkg/apis/sources/v1alpha1"
+	"github.com/triggermesh/knative-sources/pkg/zendesk/incremental"
+)
+
+const (
+	// Zendesk rejects exports which start less than a minute ago, so the
+	// first poll of a new source starts one minute in the past.
+	initialStartTimeOffset = time.Minute
+	// minimum delay between two pages of a single poll. Zendesk limits
+	// Incremental Exports to 10 requests per minute.
+	pageInterval = 6 * time.Second
+)
+
+// ticketEventsClient exports ticket events from the Zendesk API.
+type ticketEventsClient interface {
+	TicketEvents(ctx context.Context, startTime int64) (*incremental.TicketEventsPage, error)
+	Tickets(ctx context.Context, ids []int64) (map[int64]json.RawMessage, error)
+}
+
+// ticketEventsPoller generates CloudEvents from ticket events exported
+// periodically from the Zendesk Incremental Exports API. It implements
+// ZendeskAPIHandler as an alternative to receiving notifications from Zendesk.
+// Events are delivered at least once: the cursor is only advanced once all
+// the events of a page have been sent.
+type ticketEventsPoller struct {
+	client   ticketEventsClient
+	cursor   cursorStore
+	interval time.Duration
+	events   map[v1alpha1.ZendeskEvent]struct{}
+
+	ceClient    cloudevents.Client
+	eventsource string
+
+	// IDs of the ticket events which occurred at the end time of the
+	// last page, and are therefore exported again in the next page
+	boundaryIDs map[int64]struct{}
+
+	time   timeWrap
+	logger *zap.SugaredLogger
+}
+
+var _ ZendeskAPIHandler = (*ticketEventsPoller)(nil)
+
+// newTicketEventsPoller returns a ticketEventsPoller which generates events of
+// the given Zendesk event types. A nil cursorStore keeps the cursor in memory.
+func newTicketEventsPoller(client ticketEventsClient, cursor cursorStore, interval time.Duration,
+	events []string, ceClient cloudevents.Client, eventsource string, logger *zap.SugaredLogger) *ticketEventsPoller {
+
+	if cursor == nil {
+		cursor = &memoryCursorStore{}
+	}
+
+	evs := make(map[v1alpha1.ZendeskEvent]struct{}, len(events))
+	for _, ev := range events {
+		evs[v1alpha1.ZendeskEvent(ev)] = struct{}{}
+	}
+	if len(evs) == 0 {
+		evs[v1alpha1.ZendeskTicketCreated] = struct{}{}
+	}
+
+	return &ticketEventsPoller{
+		client:      client,
+		cursor:      cursor,
+		interval:    interval,
+		events:      evs,
+		ceClient:    ceClient,
+		eventsource: eventsource,
+		time:        standardTime{},
+		logger:      logger,
+	}
+}
+
+// Start polls the Zendesk API until the given context is cancelled.
+func (p *ticketEventsPoller) Start(ctx context.Context) error {
+	p.logger.Info("Starting Zendesk poller...")
+
+	startTime, err := p.initialStartTime(ctx)
+	if err != nil {
+		return fmt.Errorf("initializing polling cursor: %w", err)
+	}
+
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		startTime = p.poll(ctx, startTime)
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// initialStartTime returns the persisted cursor, or initializes it if the
+// source was never polled before. Ticket events which occurred before the
+// creation of the source are not exported.
+func (p *ticketEventsPoller) initialStartTime(ctx context.Context) (int64, error) {
+	startTime, found, err := p.cursor.load(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if found {
+		p.logger.Infof("Resuming the export of ticket events from %d", startTime)
+		return startTime, nil
+	}
+
+	startTime = p.time.Now().Add(-initialStartTimeOffset).Unix()
+	if err := p.cursor.save(ctx, startTime); err != nil {
+		return 0, err
+	}
+
+	return startTime, nil
+}
+
+// poll exports the ticket events which occurred since the given start time
+// until the end of the stream, and returns the start time of the next poll.
+func (p *ticketEventsPoller) poll(ctx context.Context, startTime int64) int64 {
+	for {
+		page, err := p.client.TicketEvents(ctx, startTime)
+		if err != nil {
+			p.logger.Errorw("Failed to export ticket events", zap.Error(err))
+			return startTime
+		}
+
+		if err := p.dispatch(ctx, page); err != nil {
+			p.logger.Errorw("Failed to send events, the export will be retried", zap.Error(err))
+			return startTime
+		}
+
+		if page.EndTime > startTime {
+			startTime = page.EndTime
+			if err := p.cursor.save(ctx, startTime); err != nil {
+				// events may be sent again after a restart
+				p.logger.Errorw("Failed to persist the polling cursor", zap.Error(err))
+			}
+		}
+
+		if page.EndOfStream {
+			return startTime
+		}
+
+		select {
+		case <-ctx.Done():
+			return startTime
+		case <-time.After(pageInterval):
+		}
+	}
+}
+
+// dispatch sends a CloudEvent for each subscribed Zendesk event found in the
+// given page of ticket events.
+func (p *ticketEventsPoller) dispatch(ctx context.Context, page *incremental.TicketEventsPage) error {
+	type match struct {
+		te     *incremental.TicketEvent
+		events []v1alpha1.ZendeskEvent
+	}
+
+	var matches []match
+	ticketIDs := make(map[int64]struct{})
+
+	boundaryIDs := make(map[int64]struct{})
+
+	for i := range page.TicketEvents {
+		te := &page.TicketEvents[i]
+
+		if te.Timestamp == page.EndTime {
+			boundaryIDs[te.ID] = struct{}{}
+		}
+		if _, seen := p.boundaryIDs[te.ID]; seen {
+			continue
+		}
+
+		var evs []v1alpha1.ZendeskEvent
+		for _, ev := range zendeskEventsOf(te) {
+			if _, subscribed := p.events[ev]; subscribed {
+				evs = append(evs, ev)
+			}
+		}
+		if len(evs) == 0 {
+			continue
+		}
+
+		matches = append(matches, match{te: te, events: evs})
+		ticketIDs[te.TicketID] = struct{}{}
+	}
+
+	tickets, err := p.tickets(ctx, ticketIDs)
+	if err != nil {
+		return fmt.Errorf("retrieving tickets: %w", err)
+	}
+
+	for _, m := range matches {
+		for _, ev := range m.events {
+			event, err := p.cloudEventFromTicketEvent(m.te, ev, tickets[m.te.TicketID])
+			if err != nil {
+				return fmt.Errorf("creating CloudEvent: %w", err)
+			}
+
+			if result := p.ceClient.Send(ctx, *event); !cloudevents.IsACK(result) {
+				return fmt.Errorf("sending CloudEvent: %w", result)
+			}
+		}
+	}
+
+	p.boundaryIDs = boundaryIDs
+
+	return nil
+}
+
+// tickets retrieves the tickets with the given IDs, in batches.
+func (p *ticketEventsPoller) tickets(ctx context.Context, ids map[int64]struct{}) (map[int64]json.RawMessage, error) {
+	tickets := make(map[int64]json.RawMessage, len(ids))
+
+	batch := make([]int64, 0, incremental.MaxTicketsPerRequest)
+
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		ts, err := p.client.Tickets(ctx, batch)
+		if err != nil {
+			return err
+		}
+		for id, t := range ts {
+			tickets[id] = t
+		}
+		batch = batch[:0]
+		return nil
+	}
+
+	for id := range ids {
+		batch = append(batch, id)
+		if len(batch) == cap(batch) {
+			if err := flush(); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if err := flush(); err != nil {
+		return nil, err
+	}
+
+	return tickets, nil
+}
+
+// cloudEventFromTicketEvent returns a CloudEvent of the type of the given
+// Zendesk event, which occurred on the given ticket.
+// The CloudEvent's ID is derived from the ticket event, which allows sinks to
+// discard events sent more than once.
+func (p *ticketEventsPoller) cloudEventFromTicketEvent(te *incremental.TicketEvent, ev v1alpha1.ZendeskEvent,
+	ticket json.RawMessage) (*cloudevents.Event, error) {
+
+	data := map[string]json.RawMessage{
+		"ticket_event": te.Raw,
+	}
+
+	event := cloudevents.NewEvent(cloudevents.VersionV1)
+
+	event.SetSubject(strconv.FormatInt(te.TicketID, 10))
+
+	if ticket != nil {
+		data["ticket"] = ticket
+
+		var attrs struct {
+			Type     string `json:"type"`
+			Status   string `json:"status"`
+			Priority string `json:"priority"`
+		}
+		if err := json.Unmarshal(ticket, &attrs); err == nil {
+			setTicketExtensions(&event, attrs.Type, attrs.Status, attrs.Priority, "")
+		}
+	}
+
+	event.SetID(strconv.FormatInt(te.ID, 10) + "-" + string(ev))
+	event.SetType(ev.EventType())
+	event.SetSource(p.eventsource)
+	event.SetTime(time.Unix(te.Timestamp, 0))
+
+	if err := event.SetData(cloudevents.ApplicationJSON, data); err != nil {
+		return nil, fmt.Errorf("failed to set event data: %w", err)
+	}
+
+	return &event, nil
+}
+
+// zendeskEventsOf returns the Zendesk events represented by the given ticket
+// event, which are the same events the Triggers created by the controller
+// notify about.
+func zendeskEventsOf(te *incremental.TicketEvent) []v1alpha1.ZendeskEvent {
+	var created, changed bool
+	var evs []v1alpha1.ZendeskEvent
+
+	for _, ce := range te.ChildEvents {
+		switch ce["event_type"] {
+		case "Create":
+			created = true
+
+		case "Change":
+			changed = true
+
+			switch {
+			case ce["status"] == "solved":
+				evs = append(evs, v1alpha1.ZendeskTicketSolved)
+			case hasKey(ce, "status") && ce["status"] != "closed" && ce["previous_value"] == "solved":
+				evs = append(evs, v1alpha1.ZendeskTicketReopened)
+			case hasKey(ce, "assignee_id"):
+				evs = append(evs, v1alpha1.ZendeskTicketAssigneeChanged)
+			case hasKey(ce, "satisfaction_score"):
+				evs = append(evs, v1alpha1.ZendeskTicketSatisfactionRated)
+			}
+
+		case "Comment":
+			changed = true
+			evs = append(evs, v1alpha1.ZendeskTicketCommentAdded)
+
+		case "SatisfactionRating":
+			changed = true
+			evs = append(evs, v1alpha1.ZendeskTicketSatisfactionRated)
+		}
+	}
+
+	switch {
+	case created:
+		// the initial comment (description) and field values of a
+		// ticket are reported as child events of its creation
+		return []v1alpha1.ZendeskEvent{v1alpha1.ZendeskTicketCreated}
+	case changed:
+		return dedupEvents(append([]v1alpha1.ZendeskEvent{v1alpha1.ZendeskTicketUpdated}, evs...))
+	default:
+		return nil
+	}
+}
+
+// hasKey returns whether the given map contains the given key.
+func hasKey(m map[string]interface{}, k string) bool {
+	_, ok := m[k]
+	return ok
+}
+
+// dedupEvents removes duplicates from the given list of events, preserving
+// their order.
+func dedupEvents(evs []v1alpha1.ZendeskEvent) []v1alpha1.ZendeskEvent {
+	seen := make(map[v1alpha1.ZendeskEvent]struct{}, len(evs))
+
+	deduped := evs[:0]
+	for _, ev := range evs {
+		if _, ok := seen[ev]; ok {
+			continue
+		}
+		seen[ev] = struct{}{}
+		deduped = append(deduped, ev)
+	}
+	return deduped
+}
+
+// cursorStore persists the start time of the next export of ticket events.
+type cursorStore interface {
+	// load returns the persisted start time, and whether it was found.
+	load(ctx context.Context) (int64, bool, error)
+	// save persists the given start time.
+	save(ctx context.Context, startTime int64) error
+}
+
+// cursorStartTimeKey is the key of the start time in the cursor ConfigMap.
+const cursorStartTimeKey = "startTime"
+
+// configMapCursorStore persists the cursor in a ConfigMap created for the
+// adapter by the controller.
+type configMapCursorStore struct {
+	cmClient coreclientv1.ConfigMapInterface
+	name     string
+}
+
+var _ cursorStore = (*configMapCursorStore)(nil)
+
+// load implements cursorStore.
+func (s *configMapCursorStore) load(ctx context.Context) (int64, bool, error) {
+	cm, err := s.cmClient.Get(ctx, s.name, metav1.GetOptions{})
+	switch {
+	case apierrors.IsNotFound(err):
+		return 0, false, nil
+	case err != nil:
+		return 0, false, fmt.Errorf("getting cursor ConfigMap: %w", err)
+	}
+
+	v, ok := cm.Data[cursorStartTimeKey]
+	if !ok {
+		return 0, false, nil
+	}
+
+	startTime, err := strconv.ParseInt(v, 10, 64)
+	if err != nil {
+		return 0, false, fmt.Errorf("parsing start time %q: %w", v, err)
+	}
+	return startTime, true, nil
+}
+
+// save implements cursorStore.
+func (s *configMapCursorStore) save(ctx context.Context, startTime int64) error {
+	// marshaling a map of strings can not fail
+	patch, _ := json.Marshal(map[string]interface{}{
+		"data": map[string]string{
+			cursorStartTimeKey: strconv.FormatInt(startTime, 10),
+		},
+	})
+
+	_, err := s.cmClient.Patch(ctx, s.name, types.MergePatchType, patch, metav1.PatchOptions{})
+	return err
+}
+
+// memoryCursorStore keeps the cursor in memory, and therefore doesn't
+// persist it across restarts.
+type memoryCursorStore struct {
+	startTime *int64
+}
+
+var _ cursorStore = (*memoryCursorStore)(nil)
+
+// load implements cursorStore.
+func (s *memoryCursorStore) load(context.Context) (int64, bool, error) {
+	if s.startTime == nil {
+		return 0, false, nil
+	}
+	return *s.startTime, true, nil
+}
+
+// save implements cursorStore.
+func (s *memoryCursorStore) save(_ context.Context, startTime int64) error {
+	s.startTime = &startTime
+	return nil
+}
@@ -0,0 +1,211 @@
+/*
+Copyright (c) 2020 TriggerMesh Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package zendesksource
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	cloudeventst "github.com/cloudevents/sdk-go/v2/client/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	zapt "go.uber.org/zap/zaptest"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/triggermesh/knative-sources/pkg/apis/sources/v1alpha1"
+	"github.com/triggermesh/knative-sources/pkg/zendesk/incremental"
+)
+
+func TestZendeskEventsOf(t *testing.T) {
+	tc := map[string]struct {
+		childEvents string
+		expect      []v1alpha1.ZendeskEvent
+	}{
+		"ticket created": {
+			childEvents: `[{"event_type":"Create","status":"new"},{"event_type":"Comment","body":"Help!"}]`,
+			expect:      []v1alpha1.ZendeskEvent{v1alpha1.ZendeskTicketCreated},
+		},
+		"comment added": {
+			childEvents: `[{"event_type":"Comment","body":"Any update?"}]`,
+			expect:      []v1alpha1.ZendeskEvent{v1alpha1.ZendeskTicketUpdated, v1alpha1.ZendeskTicketCommentAdded},
+		},
+		"ticket solved and assigned": {
+			childEvents: `[{"event_type":"Change","status":"solved","previous_value":"open"},` +
+				`{"event_type":"Change","assignee_id":42,"previous_value":null}]`,
+			expect: []v1alpha1.ZendeskEvent{v1alpha1.ZendeskTicketUpdated, v1alpha1.ZendeskTicketSolved,
+				v1alpha1.ZendeskTicketAssigneeChanged},
+		},
+		"ticket reopened": {
+			childEvents: `[{"event_type":"Change","status":"open","previous_value":"solved"}]`,
+			expect:      []v1alpha1.ZendeskEvent{v1alpha1.ZendeskTicketUpdated, v1alpha1.ZendeskTicketReopened},
+		},
+		"ticket closed": {
+			childEvents: `[{"event_type":"Change","status":"closed","previous_value":"solved"}]`,
+			expect:      []v1alpha1.ZendeskEvent{v1alpha1.ZendeskTicketUpdated},
+		},
+		"satisfaction rated": {
+			childEvents: `[{"event_type":"SatisfactionRating","score":"good"}]`,
+			expect:      []v1alpha1.ZendeskEvent{v1alpha1.ZendeskTicketUpdated, v1alpha1.ZendeskTicketSatisfactionRated},
+		},
+		"no ticket change": {
+			childEvents: `[{"event_type":"Notification","subject":"Ticket received"}]`,
+			expect:      nil,
+		},
+	}
+
+	for name, c := range tc {
+		//nolint:scopelint
+		t.Run(name, func(t *testing.T) {
+			te := &incremental.TicketEvent{}
+			require.NoError(t, json.Unmarshal([]byte(`{"child_events":`+c.childEvents+`}`), te))
+
+			assert.Equal(t, c.expect, zendeskEventsOf(te))
+		})
+	}
+}
+
+func TestTicketEventsPoller(t *testing.T) {
+	const ns, cmName = "test", "my-source-zendesk-cursor"
+
+	const (
+		startTime = 1605002400
+		endTime   = startTime + 60
+	)
+
+	// Page of ticket events returned by the fake API for each start time.
+	// The last ticket event of the first page occurred at the end time of
+	// that page, and is therefore exported again in the next page.
+	pages := map[int64]string{
+		startTime: `{"ticket_events":[` +
+			`{"id":1,"ticket_id":10,"timestamp":` + strconv.Itoa(startTime+10) + `,"child_events":[{"event_type":"Create"}]},` +
+			`{"id":2,"ticket_id":20,"timestamp":` + strconv.Itoa(startTime+20) + `,"child_events":[{"event_type":"Change","priority":"high"}]},` +
+			`{"id":3,"ticket_id":20,"timestamp":` + strconv.Itoa(endTime) + `,"child_events":[{"event_type":"Change","status":"solved"}]}` +
+			`],"end_time":` + strconv.Itoa(endTime) + `,"end_of_stream":true}`,
+		endTime: `{"ticket_events":[` +
+			`{"id":3,"ticket_id":20,"timestamp":` + strconv.Itoa(endTime) + `,"child_events":[{"event_type":"Change","status":"solved"}]}` +
+			`],"end_time":` + strconv.Itoa(endTime) + `,"end_of_stream":true}`,
+	}
+
+	var requestedStartTimes []int64
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/incremental/ticket_events.json", func(w http.ResponseWriter, r *http.Request) {
+		st, _ := strconv.ParseInt(r.URL.Query().Get("start_time"), 10, 64)
+		requestedStartTimes = append(requestedStartTimes, st)
+		_, _ = w.Write([]byte(pages[st]))
+	})
+	mux.HandleFunc("/tickets/show_many.json", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprint(w, `{"tickets":[{"id":10,"subject":"Printer on fire"},{"id":20,"subject":"Cannot log in","type":"incident","status":"solved"}]}`)
+	})
+
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	client := incremental.NewClient("", "", "")
+	client.SetEndpointURL(srv.URL)
+
+	kubeClient := fake.NewSimpleClientset(&corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: ns,
+			Name:      cmName,
+		},
+		Data: map[string]string{
+			cursorStartTimeKey: strconv.Itoa(startTime),
+		},
+	})
+	cursor := &configMapCursorStore{
+		cmClient: kubeClient.CoreV1().ConfigMaps(ns),
+		name:     cmName,
+	}
+
+	events := []string{
+		string(v1alpha1.ZendeskTicketCreated),
+		string(v1alpha1.ZendeskTicketSolved),
+	}
+
+	ctx := context.Background()
+
+	ceClient, chEvent := cloudeventst.NewMockSenderClient(t, 3)
+	p := newTicketEventsPoller(client, cursor, 0, events, ceClient, "test.zendesk.com", zapt.NewLogger(t).Sugar())
+
+	st, err := p.initialStartTime(ctx)
+	require.NoError(t, err)
+	require.Equal(t, int64(startTime), st, "Polling should start from the persisted cursor")
+
+	st = p.poll(ctx, st)
+	assert.Equal(t, int64(endTime), st)
+
+	require.Len(t, chEvent, 2, "Expected one event per subscribed Zendesk event")
+
+	ev := <-chEvent
+	assert.Equal(t, "1-"+string(v1alpha1.ZendeskTicketCreated), ev.ID())
+	assert.Equal(t, v1alpha1.ZendeskTicketCreated.EventType(), ev.Type())
+	assert.Equal(t, "test.zendesk.com", ev.Source())
+	assert.Equal(t, "10", ev.Subject())
+	assert.Equal(t, int64(startTime+10), ev.Time().Unix())
+
+	ev = <-chEvent
+	assert.Equal(t, "3-"+string(v1alpha1.ZendeskTicketSolved), ev.ID())
+	assert.Equal(t, v1alpha1.ZendeskTicketSolved.EventType(), ev.Type())
+	assert.Equal(t, "20", ev.Subject())
+	assert.Equal(t, "incident", ev.Extensions()[ticketTypeExtension])
+	assert.Equal(t, "solved", ev.Extensions()[ticketStatusExtension])
+
+	cm, err := cursor.cmClient.Get(ctx, cmName, metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, strconv.Itoa(endTime), cm.Data[cursorStartTimeKey], "Cursor should be persisted")
+
+	// the ticket event exported again at the boundary of the page
+	// is not sent twice
+	st = p.poll(ctx, st)
+	assert.Equal(t, int64(endTime), st)
+	assert.Len(t, chEvent, 0, "Ticket events should not be sent twice")
+
+	// a restarted poller resumes from the persisted cursor
+	p = newTicketEventsPoller(client, cursor, 0, events, ceClient, "test.zendesk.com", zapt.NewLogger(t).Sugar())
+
+	st, err = p.initialStartTime(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(endTime), st, "Polling should resume from the persisted cursor")
+
+	assert.Equal(t, []int64{startTime, endTime}, requestedStartTimes)
+}
+
+func TestTicketEventsPollerInitialCursor(t *testing.T) {
+	cursor := &memoryCursorStore{}
+
+	ceClient, _ := cloudeventst.NewMockSenderClient(t, 1)
+	p := newTicketEventsPoller(nil, cursor, 0, nil, ceClient, "test.zendesk.com", zapt.NewLogger(t).Sugar())
+	p.time = fixedTime(time.Unix(1605002400, 0))
+
+	st, err := p.initialStartTime(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, int64(1605002400-60), st, "Ticket events which occurred before the source was created should be skipped")
+
+	saved, found, _ := cursor.load(context.Background())
+	assert.True(t, found, "Initial cursor should be persisted")
+	assert.Equal(t, st, saved)
+}
@@ -18,17 +18,18 @@ package zendesksource
 
 import (
 	"context"
-	"encoding/base64"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
-	"io/ioutil"
 	"net/http"
-	"strings"
-	"sync"
 	"time"
 
 	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/triggermesh/knative-sources/pkg/adapter/common/server"
+	"github.com/triggermesh/knative-sources/pkg/adapter/common/stats"
+	"github.com/triggermesh/knative-sources/pkg/adapter/common/tracing"
 	"github.com/triggermesh/knative-sources/pkg/apis/sources/v1alpha1"
 	"go.uber.org/zap"
 )
@@ -38,36 +39,27 @@ type ZendeskAPIHandler interface {
 	Start(ctx context.Context) error
 }
 
-const (
-	serverPort                = "8080"
-	serverShutdownGracePeriod = time.Second * 10
-)
-
-const (
-	// auth header prefix, it is important that the blank
-	// space is present at the end for string manipulation
-	// at auth parsing function.
-	authPrefix = "Basic "
-)
-
 type zendeskAPIHandler struct {
-	username string
-	password string
+	auth requestAuthenticator
 
 	ceClient    cloudevents.Client
-	srv         *http.Server
+	srvCfg      server.Config
+	reporter    *stats.Reporter
 	eventsource string
 
 	logger *zap.SugaredLogger
 }
 
 // NewZendeskAPIHandler creates the default implementation of the Zendesk API Events handler
-func NewZendeskAPIHandler(ceClient cloudevents.Client, username, password, eventsource string, logger *zap.SugaredLogger) ZendeskAPIHandler {
+func NewZendeskAPIHandler(ceClient cloudevents.Client, srvCfg server.Config, reporter *stats.Reporter,
+	auth requestAuthenticator, eventsource string, logger *zap.SugaredLogger) ZendeskAPIHandler {
+
 	return &zendeskAPIHandler{
-		username:    username,
-		password:    password,
+		auth:        auth,
 		eventsource: eventsource,
 		ceClient:    ceClient,
+		srvCfg:      srvCfg,
+		reporter:    reporter,
 		logger:      logger,
 	}
 }
@@ -75,155 +67,185 @@ func NewZendeskAPIHandler(ceClient cloudevents.Client, username, password, event
 // Start the server for receiving Zendesk events. Will block until the stop channel closes.
 func (h *zendeskAPIHandler) Start(ctx context.Context) error {
 	h.logger.Info("Starting Zendesk event handler...")
-	ctx, cancel := context.WithCancel(ctx)
-	defer cancel()
-
-	// handle stop signals
-	go func() {
-		<-ctx.Done()
-		h.logger.Info("Shutdown signal received. Terminating")
-		h.srv.SetKeepAlivesEnabled(false)
-		cancel()
-	}()
-
-	m := http.NewServeMux()
-	m.HandleFunc("/", h.handleAll)
-	http.HandleFunc("/health", healthCheckHandler)
+	return server.New(h.srvCfg, http.HandlerFunc(h.handleAll), h.reporter, h.logger.Named("server")).Run(ctx)
+}
 
-	h.srv = &http.Server{
-		Addr:    ":" + serverPort,
-		Handler: m,
+// handleAll receives all Zendesk events at a single resource, it
+// is up to this function to parse event wrapper and dispatch.
+//
+// Zendesk retries the delivery of notifications which fail with a server
+// error, so the status code of the response distinguishes requests which
+// can never succeed (4xx) from transient failures (5xx).
+func (h *zendeskAPIHandler) handleAll(w http.ResponseWriter, r *http.Request) {
+	body, code, err := server.ReadBody(r)
+	if err != nil {
+		h.handleError(err, code, w)
+		return
 	}
 
-	serverErrCh := make(chan error)
-	defer close(serverErrCh)
-
-	var wg sync.WaitGroup
-
-	wg.Add(1)
-	go func() {
-		h.logger.Infof("Zendesk Source is ready to handle requests at %s", h.srv.Addr)
-		serverErrCh <- h.srv.ListenAndServe()
-		wg.Done()
-	}()
-
-	var err error
-
-	select {
-	case serverErr := <-serverErrCh:
-		if serverErr != nil {
-			err = fmt.Errorf("failure during runtime of Zendesk event handler: %w", serverErr)
+	// secrets retrieved from the Zendesk API during the verification
+	// are retrieved within the verification's span
+	ctx, span := tracing.StartVerifySpan(r.Context())
+	err = h.auth.authenticate(r.WithContext(ctx), body)
+	tracing.EndSpan(span, err)
+	if err != nil {
+		// the request may be legitimate if the signing secret of the
+		// webhook could not be retrieved
+		var secretErr *signingSecretError
+		if errors.As(err, &secretErr) {
+			h.handleError(err, http.StatusServiceUnavailable, w)
+			return
 		}
-		cancel()
 
-	case <-ctx.Done():
-		h.logger.Info("Shutting server down")
+		h.handleError(fmt.Errorf("could not authenticate request: %w", err), http.StatusUnauthorized, w)
+		return
+	}
 
-		shutdownCtx, cancelTimeout := context.WithTimeout(ctx, serverShutdownGracePeriod)
-		defer cancelTimeout()
-		if shutdownErr := h.srv.Shutdown(shutdownCtx); shutdownErr != nil {
-			err = fmt.Errorf("error during server shutdown: %w", shutdownErr)
-		}
+	cEvent, err := h.cloudEventFromPayload(body)
+	if err != nil {
+		h.handleError(fmt.Errorf("could not create Cloud Event: %w", err), http.StatusBadRequest, w)
+		return
+	}
 
-		// unblock server goroutine
-		<-serverErrCh
+	if result := h.ceClient.Send(r.Context(), *cEvent); !cloudevents.IsACK(result) {
+		h.handleError(fmt.Errorf("could not send Cloud Event: %w", result), http.StatusServiceUnavailable, w)
+		return
 	}
 
-	wg.Wait()
-	return err
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusNoContent)
 }
 
-func (h *zendeskAPIHandler) validateAuthHeader(r *http.Request) error {
-	auth := r.Header.Get("Authorization")
-	if !strings.HasPrefix(auth, authPrefix) {
-		return errors.New("incorrect auth header")
-	}
+// handleError logs the given error and responds to the request with the
+// given status code. Details about failed authentications are not returned to
+// the client.
+func (h *zendeskAPIHandler) handleError(err error, code int, w http.ResponseWriter) {
+	h.logger.Errorw("An error ocurred", zap.Int("code", code), zap.Error(err))
 
-	content, err := base64.StdEncoding.DecodeString(auth[len(authPrefix):])
-	if err != nil {
-		return errors.New("could not decode the auth header")
+	if code == http.StatusUnauthorized {
+		err = errors.New(http.StatusText(code))
 	}
+	server.Error(w, err, code)
+}
 
-	pair := strings.SplitN(string(content), ":", 2)
-	if len(pair) != 2 {
-		return errors.New("misformated credentials at auth header")
+// cloudEventFromPayload returns a CloudEvent generated from the given payload
+// of a Zendesk Trigger or webhook. The payload of a Trigger, except the
+// attributes set by the controller, is used as the CloudEvent's data.
+func (h *zendeskAPIHandler) cloudEventFromPayload(payload []byte) (*cloudevents.Event, error) {
+	if isWebhookEvent(payload) {
+		return h.cloudEventFromWebhookEvent(payload)
 	}
 
-	if pair[0] != h.username || pair[1] != h.password {
-		return fmt.Errorf("credentials received for user %q are not valid", pair[0])
+	ze := &ZendeskEvent{}
+	if err := json.Unmarshal(payload, ze); err != nil {
+		return nil, fmt.Errorf("could not unmarshal JSON payload: %w", err)
 	}
 
-	return nil
-}
-
-// handleAll receives all Zendesk events at a single resource, it
-// is up to this function to parse event wrapper and dispatch.
-func (h *zendeskAPIHandler) handleAll(w http.ResponseWriter, r *http.Request) {
-	if r.Body == nil {
-		h.handleError(errors.New("request without body not supported"), w)
-		return
+	// preserves attributes of custom payload templates
+	data := make(map[string]json.RawMessage)
+	if err := json.Unmarshal(payload, &data); err != nil {
+		return nil, fmt.Errorf("could not unmarshal JSON payload: %w", err)
 	}
+	delete(data, eventTypeAttr)
 
-	if err := h.validateAuthHeader(r); err != nil {
-		h.handleError(err, w)
-		return
+	// Triggers created by prior releases of the source do not set the
+	// event type, and only notify about the creation of tickets.
+	eventType := ze.EventType
+	if eventType == "" {
+		eventType = v1alpha1.ZendeskTicketCreatedEventType
 	}
 
-	defer r.Body.Close()
-	body, err := ioutil.ReadAll(r.Body)
-	if err != nil {
-		h.handleError(err, w)
-		return
-	}
+	event := cloudevents.NewEvent(cloudevents.VersionV1)
 
-	event := &ZendeskEvent{}
-	err = json.Unmarshal(body, event)
-	if err != nil {
-		h.handleError(fmt.Errorf("could not unmarshall JSON request: %w", err), w)
-		return
+	event.SetID(ze.cloudEventID(eventType, payloadDigest(payload)))
+	event.SetType(eventType)
+	event.SetSource(h.eventsource)
+	event.SetSubject(ze.ticketID())
+	if t := ze.updateTime(); !t.IsZero() {
+		event.SetTime(t)
 	}
 
-	cEvent, err := h.cloudEventFromWrapper(event)
-	if err != nil {
-		h.handleError(fmt.Errorf("could not create Cloud Event: %w", err), w)
+	if t := ze.Ticket; t != nil {
+		setTicketExtensions(&event, t.TicketType, t.Status, t.Priority, t.BrandName)
 	}
 
-	if result := h.ceClient.Send(context.Background(), *cEvent); !cloudevents.IsACK(result) {
-		h.handleError(fmt.Errorf("could not send Cloud Event: %w", result), w)
+	if err := event.SetData(cloudevents.ApplicationJSON, data); err != nil {
+		return nil, fmt.Errorf("failed to set event data: %w", err)
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusNoContent)
+	return &event, nil
 }
 
-func (h *zendeskAPIHandler) handleError(err error, w http.ResponseWriter) {
-	h.logger.Error("An error ocurred", zap.Error(err))
-	http.Error(w, err.Error(), http.StatusInternalServerError)
-}
+// cloudEventFromWebhookEvent returns a CloudEvent generated from the given
+// payload of a Zendesk event sent by a webhook. The payload is used as the
+// CloudEvent's data. Zendesk events are mapped to the CloudEvent types of the
+// corresponding ZendeskSource events, and their original type is preserved
+// in an extension.
+func (h *zendeskAPIHandler) cloudEventFromWebhookEvent(payload []byte) (*cloudevents.Event, error) {
+	we := &WebhookEvent{}
+	if err := json.Unmarshal(payload, we); err != nil {
+		return nil, fmt.Errorf("could not unmarshal JSON payload: %w", err)
+	}
 
-func (h *zendeskAPIHandler) cloudEventFromWrapper(ze *ZendeskEvent) (*cloudevents.Event, error) {
-	data, err := json.Marshal(ze)
-	if err != nil {
-		return nil, err
+	ev, ok := v1alpha1.ZendeskEventOfWebhookEventType(we.Type)
+	if !ok {
+		return nil, fmt.Errorf("unsupported type of Zendesk event %q", we.Type)
 	}
+
 	event := cloudevents.NewEvent(cloudevents.VersionV1)
 
-	if ticketType := ze.Type(); ticketType != "" {
-		event.SetExtension("ticket_type", ticketType)
+	// the ID of a Zendesk event is preserved across redeliveries
+	id := we.ID
+	if id == "" {
+		id = we.objectID() + "-" + payloadDigest(payload)
 	}
-	event.SetID(ze.ID())
-	event.SetType(v1alpha1.ZendeskTicketCreatedEventType)
+
+	event.SetID(id)
+	event.SetType(ev.EventType())
 	event.SetSource(h.eventsource)
-	event.SetSubject(ze.Title())
+	event.SetSubject(we.objectID())
+	if t, err := time.Parse(time.RFC3339, we.Time); err == nil {
+		event.SetTime(t)
+	}
+	event.SetExtension(zendeskEventTypeExtension, we.Type)
 
-	if err := event.SetData(cloudevents.ApplicationJSON, data); err != nil {
+	if err := event.SetData(cloudevents.ApplicationJSON, payload); err != nil {
 		return nil, fmt.Errorf("failed to set event data: %w", err)
 	}
 
 	return &event, nil
 }
 
-func healthCheckHandler(w http.ResponseWriter, _ *http.Request) {
-	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
-	w.WriteHeader(http.StatusOK)
+// CloudEvent extension which contains the original type of a Zendesk event.
+const zendeskEventTypeExtension = "zendeskeventtype"
+
+// CloudEvent extensions which contain attributes of Zendesk tickets.
+const (
+	ticketTypeExtension     = "tickettype"
+	ticketStatusExtension   = "ticketstatus"
+	ticketPriorityExtension = "ticketpriority"
+	ticketBrandExtension    = "ticketbrand"
+)
+
+// setTicketExtensions sets the given attributes of a Zendesk ticket as
+// extensions of the given CloudEvent. Empty attributes are omitted.
+func setTicketExtensions(event *cloudevents.Event, ticketType, status, priority, brand string) {
+	exts := map[string]string{
+		ticketTypeExtension:     ticketType,
+		ticketStatusExtension:   status,
+		ticketPriorityExtension: priority,
+		ticketBrandExtension:    brand,
+	}
+
+	for name, val := range exts {
+		if val != "" {
+			event.SetExtension(name, val)
+		}
+	}
+}
+
+// payloadDigest returns a short digest of the given payload.
+func payloadDigest(payload []byte) string {
+	sum := sha256.Sum256(payload)
+	return hex.EncodeToString(sum[:8])
 }
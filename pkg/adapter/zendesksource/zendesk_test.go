@@ -0,0 +1,336 @@
+/*
+Copyright (c) 2020 TriggerMesh Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package zendesksource
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	cloudeventst "github.com/cloudevents/sdk-go/v2/client/test"
+	"github.com/cloudevents/sdk-go/v2/protocol"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	zapt "go.uber.org/zap/zaptest"
+
+	"github.com/triggermesh/knative-sources/pkg/apis/sources/v1alpha1"
+)
+
+func TestHandleTriggerPayload(t *testing.T) {
+	const user, pass = "zendesk", "s3cr3t"
+
+	tc := map[string]struct {
+		fixture string
+
+		expectID         string
+		expectType       string
+		expectSubject    string
+		expectTime       time.Time
+		expectExtensions map[string]interface{}
+		expectData       []string
+	}{
+		"default template": {
+			fixture: "ticket_created.json",
+
+			expectID:      "42-1605002400-" + v1alpha1.ZendeskTicketCreatedEventType,
+			expectType:    v1alpha1.ZendeskTicketCreatedEventType,
+			expectSubject: "42",
+			expectTime:    time.Unix(1605002400, 0),
+			expectExtensions: map[string]interface{}{
+				ticketTypeExtension:     "Incident",
+				ticketStatusExtension:   "New",
+				ticketPriorityExtension: "High",
+				ticketBrandExtension:    "Acme",
+			},
+			expectData: []string{"ticket", "current_user", "satisfaction"},
+		},
+		"custom template": {
+			fixture: "ticket_solved_custom_template.json",
+
+			// the update time is not part of the payload, the
+			// digest of the payload is used instead
+			expectID:      "43-" + digestPlaceholder + "-" + v1alpha1.ZendeskTicketSolvedEventType,
+			expectType:    v1alpha1.ZendeskTicketSolvedEventType,
+			expectSubject: "43",
+			expectExtensions: map[string]interface{}{
+				ticketStatusExtension: "Solved",
+			},
+			expectData: []string{"ticket", "team"},
+		},
+		"Trigger of a prior release": {
+			fixture: "ticket_created_legacy.json",
+
+			expectID:      "44-1605002700-" + v1alpha1.ZendeskTicketCreatedEventType,
+			expectType:    v1alpha1.ZendeskTicketCreatedEventType,
+			expectSubject: "44",
+			expectTime:    time.Unix(1605002700, 0),
+			expectExtensions: map[string]interface{}{
+				ticketStatusExtension: "Open",
+			},
+			expectData: []string{"ticket"},
+		},
+		"user event of a webhook": {
+			fixture: "user_updated.json",
+
+			expectID:      "01GC1P9MHC0EMVA3MJP5RNZ5DH",
+			expectType:    v1alpha1.ZendeskUserUpdatedEventType,
+			expectSubject: "1500000000001",
+			expectTime:    time.Unix(1605002400, 0),
+			expectExtensions: map[string]interface{}{
+				zendeskEventTypeExtension: "zen:event-type:user.name_changed",
+			},
+			expectData: []string{"account_id", "detail", "event", "id", "subject", "time", "type",
+				"zendesk_event_version"},
+		},
+		"organization event of a webhook": {
+			fixture: "organization_created.json",
+
+			expectID:      "01GC1PAQ9RXFA2NDWBVC4ZVD7C",
+			expectType:    v1alpha1.ZendeskOrganizationCreatedEventType,
+			expectSubject: "1600000000002",
+			expectTime:    time.Unix(1605002700, 0),
+			expectExtensions: map[string]interface{}{
+				zendeskEventTypeExtension: "zen:event-type:organization.created",
+			},
+			expectData: []string{"account_id", "detail", "event", "id", "subject", "time", "type",
+				"zendesk_event_version"},
+		},
+	}
+
+	for name, c := range tc {
+		//nolint:scopelint
+		t.Run(name, func(t *testing.T) {
+			payload, err := ioutil.ReadFile(filepath.Join("testdata", c.fixture))
+			require.NoError(t, err)
+
+			ceClient, chEvent := cloudeventst.NewMockSenderClient(t, 1)
+
+			h := &zendeskAPIHandler{
+				auth:        &basicAuthenticator{username: user, password: pass},
+				ceClient:    ceClient,
+				eventsource: "example.zendesk.com/my-source",
+				logger:      zapt.NewLogger(t).Sugar(),
+			}
+
+			req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(payload))
+			req.SetBasicAuth(user, pass)
+			rec := httptest.NewRecorder()
+
+			h.handleAll(rec, req)
+
+			require.Equal(t, http.StatusNoContent, rec.Code)
+			require.Len(t, chEvent, 1, "Expected a CloudEvent to be sent")
+
+			ev := <-chEvent
+
+			expectID := strings.Replace(c.expectID, digestPlaceholder, payloadDigest(payload), 1)
+			assert.Equal(t, expectID, ev.ID())
+			assert.Equal(t, c.expectType, ev.Type())
+			assert.Equal(t, "example.zendesk.com/my-source", ev.Source())
+			assert.Equal(t, c.expectSubject, ev.Subject())
+			assert.Equal(t, c.expectTime.UTC(), ev.Time().UTC())
+			assert.Equal(t, c.expectExtensions, ev.Extensions())
+
+			data := make(map[string]json.RawMessage)
+			require.NoError(t, ev.DataAs(&data))
+
+			attrs := make([]string, 0, len(data))
+			for attr := range data {
+				attrs = append(attrs, attr)
+			}
+			assert.ElementsMatch(t, c.expectData, attrs, "Unexpected attributes in the event data")
+		})
+	}
+}
+
+// digestPlaceholder is substituted with the digest of the payload in
+// expected CloudEvent IDs.
+const digestPlaceholder = "<digest>"
+
+func TestHandleAll(t *testing.T) {
+	const user, pass = "zendesk", "s3cr3t"
+
+	const (
+		validPayload = `{"event_type": "com.zendesk.ticket.created", "ticket": {"id": 42}}`
+		timestamp    = "2020-11-10T10:00:00Z"
+	)
+
+	now := fixedTime(time.Date(2020, 11, 10, 10, 1, 0, 0, time.UTC))
+
+	basicAuth := &basicAuthenticator{username: user, password: pass}
+
+	tc := map[string]struct {
+		auth     requestAuthenticator
+		body     io.Reader
+		setAuth  func(*http.Request)
+		sinkNACK bool
+
+		expectCode     int
+		expectContains string
+		expectEvent    bool
+	}{
+		"valid request": {
+			auth:    basicAuth,
+			body:    strings.NewReader(validPayload),
+			setAuth: func(r *http.Request) { r.SetBasicAuth(user, pass) },
+
+			expectCode:  http.StatusNoContent,
+			expectEvent: true,
+		},
+		"nil body": {
+			auth:    basicAuth,
+			setAuth: func(r *http.Request) { r.SetBasicAuth(user, pass) },
+
+			expectCode:     http.StatusBadRequest,
+			expectContains: "request without body not supported",
+		},
+		"wrong credentials": {
+			auth:    basicAuth,
+			body:    strings.NewReader(validPayload),
+			setAuth: func(r *http.Request) { r.SetBasicAuth(user, "wrong") },
+
+			expectCode:     http.StatusUnauthorized,
+			expectContains: http.StatusText(http.StatusUnauthorized),
+		},
+		"missing credentials": {
+			auth: basicAuth,
+			body: strings.NewReader(validPayload),
+
+			expectCode:     http.StatusUnauthorized,
+			expectContains: http.StatusText(http.StatusUnauthorized),
+		},
+		"wrong signature": {
+			auth: &signatureVerifier{
+				secrets: []signingSecretGetter{staticSigningSecret{secret: "secret"}},
+				time:    now,
+			},
+			body: strings.NewReader(validPayload),
+			setAuth: func(r *http.Request) {
+				r.Header.Set(signatureTimestampHeader, timestamp)
+				r.Header.Set(signatureHeader, sign("other-secret", timestamp, validPayload))
+			},
+
+			expectCode:     http.StatusUnauthorized,
+			expectContains: http.StatusText(http.StatusUnauthorized),
+		},
+		"signing secret unavailable": {
+			auth: &signatureVerifier{
+				secrets: []signingSecretGetter{staticSigningSecret{err: errors.New("connection refused")}},
+				time:    now,
+			},
+			body: strings.NewReader(validPayload),
+			setAuth: func(r *http.Request) {
+				r.Header.Set(signatureTimestampHeader, timestamp)
+				r.Header.Set(signatureHeader, sign("secret", timestamp, validPayload))
+			},
+
+			expectCode:     http.StatusServiceUnavailable,
+			expectContains: "error retrieving webhook signing secret: connection refused",
+		},
+		"not a JSON payload": {
+			auth:    basicAuth,
+			body:    strings.NewReader("this is not JSON"),
+			setAuth: func(r *http.Request) { r.SetBasicAuth(user, pass) },
+
+			expectCode:     http.StatusBadRequest,
+			expectContains: "could not unmarshal JSON payload",
+		},
+		"invalid Trigger payload": {
+			auth:    basicAuth,
+			body:    strings.NewReader(`{"ticket": {"id": true}}`),
+			setAuth: func(r *http.Request) { r.SetBasicAuth(user, pass) },
+
+			expectCode:     http.StatusBadRequest,
+			expectContains: "could not unmarshal JSON payload",
+		},
+		"unsupported webhook event": {
+			auth: basicAuth,
+			body: strings.NewReader(`{"id": "01GC1P9MHC0EMVA3MJP5RNZ5DH", ` +
+				`"type": "zen:event-type:user.last_login_changed"}`),
+			setAuth: func(r *http.Request) { r.SetBasicAuth(user, pass) },
+
+			expectCode:     http.StatusBadRequest,
+			expectContains: `unsupported type of Zendesk event "zen:event-type:user.last_login_changed"`,
+		},
+		"sink unavailable": {
+			auth:     basicAuth,
+			body:     strings.NewReader(validPayload),
+			setAuth:  func(r *http.Request) { r.SetBasicAuth(user, pass) },
+			sinkNACK: true,
+
+			expectCode:     http.StatusServiceUnavailable,
+			expectContains: "could not send Cloud Event",
+		},
+	}
+
+	for name, c := range tc {
+		//nolint:scopelint
+		t.Run(name, func(t *testing.T) {
+			mockClient, chEvent := cloudeventst.NewMockSenderClient(t, 1)
+
+			var ceClient cloudevents.Client = mockClient
+			if c.sinkNACK {
+				ceClient = &nackingClient{Client: mockClient}
+			}
+
+			h := &zendeskAPIHandler{
+				auth:        c.auth,
+				ceClient:    ceClient,
+				eventsource: "example.zendesk.com/my-source",
+				logger:      zapt.NewLogger(t).Sugar(),
+			}
+
+			req, err := http.NewRequest(http.MethodPost, "/", c.body)
+			require.NoError(t, err)
+			if c.setAuth != nil {
+				c.setAuth(req)
+			}
+			rec := httptest.NewRecorder()
+
+			h.handleAll(rec, req)
+
+			assert.Equal(t, c.expectCode, rec.Code, "Unexpected response code")
+			assert.Contains(t, rec.Body.String(), c.expectContains)
+
+			if c.expectEvent {
+				assert.Len(t, chEvent, 1, "Expected a CloudEvent to be sent")
+			} else {
+				assert.Len(t, chEvent, 0, "No CloudEvent should be sent")
+			}
+		})
+	}
+}
+
+// nackingClient is a CloudEvents client which fails to send any event, as if
+// the sink was unavailable.
+type nackingClient struct {
+	cloudevents.Client
+}
+
+func (*nackingClient) Send(context.Context, cloudevents.Event) protocol.Result {
+	return protocol.NewReceipt(false, "sink unavailable")
+}
@@ -18,6 +18,7 @@ package v1alpha1
 
 import (
 	"context"
+	"time"
 
 	"go.uber.org/zap"
 
@@ -79,6 +80,20 @@ func (m *EventSourceStatusManager) MarkNoSink() {
 		ReasonSinkNotFound, "The sink does not exist or its URI is not set")
 }
 
+// MarkInvalidSpec sets the Deployed condition to False because the spec of
+// the source is invalid.
+func (m *EventSourceStatusManager) MarkInvalidSpec(messageFormat string, messageA ...interface{}) {
+	m.ConditionSet.Manage(m).MarkFalse(ConditionDeployed, ReasonInvalidSpec, messageFormat, messageA...)
+}
+
+// SetAddress sets the address of the source to the given URL.
+func (m *EventSourceStatusManager) SetAddress(url *apis.URL) {
+	if m.Address == nil {
+		m.Address = &duckv1.Addressable{}
+	}
+	m.Address.URL = url
+}
+
 // PropagateDeploymentAvailability uses the readiness of the provided
 // Deployment to determine whether the Deployed condition should be marked as
 // True or False.
@@ -165,3 +180,26 @@ func (m *EventSourceStatusManager) PropagateServiceAvailability(ksvc *servingv1.
 
 	m.ConditionSet.Manage(m).MarkFalse(ConditionDeployed, reason, msg)
 }
+
+// Default limits of the buffer of an adapter.
+const (
+	EventBufferDefaultMaxSize = 100 << 20 // 100Mi
+	EventBufferDefaultMaxAge  = 24 * time.Hour
+)
+
+// GetMaxSize returns the maximum total size of buffered events, in bytes.
+func (b *EventBuffer) GetMaxSize() int64 {
+	if b.MaxSize == nil || b.MaxSize.Sign() <= 0 {
+		return EventBufferDefaultMaxSize
+	}
+	return b.MaxSize.Value()
+}
+
+// GetMaxAge returns the maximum duration during which the delivery of a
+// buffered event is retried.
+func (b *EventBuffer) GetMaxAge() time.Duration {
+	if b.MaxAge == nil || b.MaxAge.Duration <= 0 {
+		return EventBufferDefaultMaxAge
+	}
+	return b.MaxAge.Duration
+}
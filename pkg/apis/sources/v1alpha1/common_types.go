@@ -18,6 +18,9 @@ package v1alpha1
 
 import (
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"knative.dev/pkg/apis"
 	duckv1 "knative.dev/pkg/apis/duck/v1"
 )
 
@@ -45,3 +48,34 @@ type SecretValueFromSource struct {
 	// The Secret key to select from.
 	SecretKeyRef *corev1.SecretKeySelector `json:"secretKeyRef,omitempty"`
 }
+
+// EventBuffer contains the settings of the on-disk buffer in which the
+// adapter queues events before delivering them to the sink. Buffered events
+// are acknowledged to their origin, and their delivery is retried while the
+// sink is unavailable.
+type EventBuffer struct {
+	// PersistentVolumeClaimName is the name of the PersistentVolumeClaim,
+	// in the namespace of the source, in which events are buffered. The
+	// claim should not be shared with other sources.
+	PersistentVolumeClaimName string `json:"persistentVolumeClaimName"`
+
+	// MaxSize is the maximum total size of buffered events. Events are
+	// rejected once the buffer is full.
+	// Defaults to 100Mi.
+	// +optional
+	MaxSize *resource.Quantity `json:"maxSize,omitempty"`
+
+	// MaxAge is the maximum duration during which the delivery of a
+	// buffered event is retried, after which the event is discarded.
+	// Defaults to 24h.
+	// +optional
+	MaxAge *metav1.Duration `json:"maxAge,omitempty"`
+
+	// PublicURL is the URL at which the adapter is exposed outside of the
+	// cluster, e.g. by an Ingress. Buffering adapters which receive events
+	// over HTTP are deployed as a Deployment behind a Kubernetes Service,
+	// which is only reachable from within the cluster. When set, this URL
+	// is reported as the address of the source instead.
+	// +optional
+	PublicURL *apis.URL `json:"publicURL,omitempty"`
+}
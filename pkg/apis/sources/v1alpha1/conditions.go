@@ -35,6 +35,10 @@ const (
 	// ReasonSinkEmpty is set on a SinkProvided condition when a sink URI is empty.
 	ReasonSinkEmpty = "EmptySinkURI"
 
+	// ReasonInvalidSpec is set on a Deployed condition when the spec of a
+	// source is invalid, and its adapter can therefore not be deployed.
+	ReasonInvalidSpec = "InvalidSpec"
+
 	// ReasonUnavailable is set on a Deployed condition when an adapter in unavailable.
 	ReasonUnavailable = "AdapterUnavailable"
 )
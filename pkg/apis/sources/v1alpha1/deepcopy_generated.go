@@ -21,10 +21,44 @@ limitations under the License.
 package v1alpha1
 
 import (
-	v1 "k8s.io/api/core/v1"
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
+	apis "knative.dev/pkg/apis"
+	duckv1 "knative.dev/pkg/apis/duck/v1"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EventBuffer) DeepCopyInto(out *EventBuffer) {
+	*out = *in
+	if in.MaxSize != nil {
+		in, out := &in.MaxSize, &out.MaxSize
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+	if in.MaxAge != nil {
+		in, out := &in.MaxAge, &out.MaxAge
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.PublicURL != nil {
+		in, out := &in.PublicURL, &out.PublicURL
+		*out = new(apis.URL)
+		(*in).DeepCopyInto(*out)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EventBuffer.
+func (in *EventBuffer) DeepCopy() *EventBuffer {
+	if in == nil {
+		return nil
+	}
+	out := new(EventBuffer)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *EventSourceStatus) DeepCopyInto(out *EventSourceStatus) {
 	*out = *in
@@ -119,6 +153,11 @@ func (in *HTTPSourceSpec) DeepCopyInto(out *HTTPSourceSpec) {
 		**out = **in
 	}
 	in.BasicAuthPassword.DeepCopyInto(&out.BasicAuthPassword)
+	if in.Buffer != nil {
+		in, out := &in.Buffer, &out.Buffer
+		*out = new(EventBuffer)
+		(*in).DeepCopyInto(*out)
+	}
 	return
 }
 
@@ -137,7 +176,7 @@ func (in *SecretValueFromSource) DeepCopyInto(out *SecretValueFromSource) {
 	*out = *in
 	if in.SecretKeyRef != nil {
 		in, out := &in.SecretKeyRef, &out.SecretKeyRef
-		*out = new(v1.SecretKeySelector)
+		*out = new(corev1.SecretKeySelector)
 		(*in).DeepCopyInto(*out)
 	}
 	return
@@ -153,6 +192,193 @@ func (in *SecretValueFromSource) DeepCopy() *SecretValueFromSource {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SlackApp) DeepCopyInto(out *SlackApp) {
+	*out = *in
+	if in.SigningSecret != nil {
+		in, out := &in.SigningSecret, &out.SigningSecret
+		*out = new(SecretValueFromSource)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Sink != nil {
+		in, out := &in.Sink, &out.Sink
+		*out = new(duckv1.Destination)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.EventTypes != nil {
+		in, out := &in.EventTypes, &out.EventTypes
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SlackApp.
+func (in *SlackApp) DeepCopy() *SlackApp {
+	if in == nil {
+		return nil
+	}
+	out := new(SlackApp)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SlackAppManifestOptions) DeepCopyInto(out *SlackAppManifestOptions) {
+	*out = *in
+	if in.AppName != nil {
+		in, out := &in.AppName, &out.AppName
+		*out = new(string)
+		**out = **in
+	}
+	if in.BotEvents != nil {
+		in, out := &in.BotEvents, &out.BotEvents
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.BotScopes != nil {
+		in, out := &in.BotScopes, &out.BotScopes
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SlackAppManifestOptions.
+func (in *SlackAppManifestOptions) DeepCopy() *SlackAppManifestOptions {
+	if in == nil {
+		return nil
+	}
+	out := new(SlackAppManifestOptions)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SlackEventDelivery) DeepCopyInto(out *SlackEventDelivery) {
+	*out = *in
+	if in.Policy != nil {
+		in, out := &in.Policy, &out.Policy
+		*out = new(SlackDeliveryPolicy)
+		**out = **in
+	}
+	if in.DeadLetterSink != nil {
+		in, out := &in.DeadLetterSink, &out.DeadLetterSink
+		*out = new(duckv1.Destination)
+		(*in).DeepCopyInto(*out)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SlackEventDelivery.
+func (in *SlackEventDelivery) DeepCopy() *SlackEventDelivery {
+	if in == nil {
+		return nil
+	}
+	out := new(SlackEventDelivery)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SlackEventEnrichment) DeepCopyInto(out *SlackEventEnrichment) {
+	*out = *in
+	if in.CacheTTL != nil {
+		in, out := &in.CacheTTL, &out.CacheTTL
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SlackEventEnrichment.
+func (in *SlackEventEnrichment) DeepCopy() *SlackEventEnrichment {
+	if in == nil {
+		return nil
+	}
+	out := new(SlackEventEnrichment)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SlackEventFilter) DeepCopyInto(out *SlackEventFilter) {
+	*out = *in
+	if in.IncludeEventTypes != nil {
+		in, out := &in.IncludeEventTypes, &out.IncludeEventTypes
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ExcludeEventTypes != nil {
+		in, out := &in.ExcludeEventTypes, &out.ExcludeEventTypes
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.IncludeEventSubtypes != nil {
+		in, out := &in.IncludeEventSubtypes, &out.IncludeEventSubtypes
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ExcludeEventSubtypes != nil {
+		in, out := &in.ExcludeEventSubtypes, &out.ExcludeEventSubtypes
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.IncludeChannels != nil {
+		in, out := &in.IncludeChannels, &out.IncludeChannels
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ExcludeChannels != nil {
+		in, out := &in.ExcludeChannels, &out.ExcludeChannels
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.IncludeUsers != nil {
+		in, out := &in.IncludeUsers, &out.IncludeUsers
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ExcludeUsers != nil {
+		in, out := &in.ExcludeUsers, &out.ExcludeUsers
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.BotUserID != nil {
+		in, out := &in.BotUserID, &out.BotUserID
+		*out = new(string)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SlackEventFilter.
+func (in *SlackEventFilter) DeepCopy() *SlackEventFilter {
+	if in == nil {
+		return nil
+	}
+	out := new(SlackEventFilter)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SlackSocketMode) DeepCopyInto(out *SlackSocketMode) {
+	*out = *in
+	in.AppToken.DeepCopyInto(&out.AppToken)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SlackSocketMode.
+func (in *SlackSocketMode) DeepCopy() *SlackSocketMode {
+	if in == nil {
+		return nil
+	}
+	out := new(SlackSocketMode)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *SlackSource) DeepCopyInto(out *SlackSource) {
 	*out = *in
@@ -228,6 +454,48 @@ func (in *SlackSourceSpec) DeepCopyInto(out *SlackSourceSpec) {
 		*out = new(string)
 		**out = **in
 	}
+	if in.Apps != nil {
+		in, out := &in.Apps, &out.Apps
+		*out = make([]SlackApp, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Filter != nil {
+		in, out := &in.Filter, &out.Filter
+		*out = new(SlackEventFilter)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.BotToken != nil {
+		in, out := &in.BotToken, &out.BotToken
+		*out = new(SecretValueFromSource)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Enrichment != nil {
+		in, out := &in.Enrichment, &out.Enrichment
+		*out = new(SlackEventEnrichment)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.SocketMode != nil {
+		in, out := &in.SocketMode, &out.SocketMode
+		*out = new(SlackSocketMode)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Buffer != nil {
+		in, out := &in.Buffer, &out.Buffer
+		*out = new(EventBuffer)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Delivery != nil {
+		in, out := &in.Delivery, &out.Delivery
+		*out = new(SlackEventDelivery)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Manifest != nil {
+		in, out := &in.Manifest, &out.Manifest
+		*out = new(SlackAppManifestOptions)
+		(*in).DeepCopyInto(*out)
+	}
 	return
 }
 
@@ -241,12 +509,34 @@ func (in *SlackSourceSpec) DeepCopy() *SlackSourceSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SlackSourceStatus) DeepCopyInto(out *SlackSourceStatus) {
+	*out = *in
+	in.EventSourceStatus.DeepCopyInto(&out.EventSourceStatus)
+	if in.AppManifestRef != nil {
+		in, out := &in.AppManifestRef, &out.AppManifestRef
+		*out = new(corev1.LocalObjectReference)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SlackSourceStatus.
+func (in *SlackSourceStatus) DeepCopy() *SlackSourceStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(SlackSourceStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ValueFromField) DeepCopyInto(out *ValueFromField) {
 	*out = *in
 	if in.ValueFromSecret != nil {
 		in, out := &in.ValueFromSecret, &out.ValueFromSecret
-		*out = new(v1.SecretKeySelector)
+		*out = new(corev1.SecretKeySelector)
 		(*in).DeepCopyInto(*out)
 	}
 	return
@@ -262,6 +552,112 @@ func (in *ValueFromField) DeepCopy() *ValueFromField {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ZendeskAPIError) DeepCopyInto(out *ZendeskAPIError) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ZendeskAPIError.
+func (in *ZendeskAPIError) DeepCopy() *ZendeskAPIError {
+	if in == nil {
+		return nil
+	}
+	out := new(ZendeskAPIError)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ZendeskOAuth) DeepCopyInto(out *ZendeskOAuth) {
+	*out = *in
+	if in.AccessToken != nil {
+		in, out := &in.AccessToken, &out.AccessToken
+		*out = new(SecretValueFromSource)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ClientCredentials != nil {
+		in, out := &in.ClientCredentials, &out.ClientCredentials
+		*out = new(ZendeskOAuthClientCredentials)
+		(*in).DeepCopyInto(*out)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ZendeskOAuth.
+func (in *ZendeskOAuth) DeepCopy() *ZendeskOAuth {
+	if in == nil {
+		return nil
+	}
+	out := new(ZendeskOAuth)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ZendeskOAuthClientCredentials) DeepCopyInto(out *ZendeskOAuthClientCredentials) {
+	*out = *in
+	in.ClientSecret.DeepCopyInto(&out.ClientSecret)
+	if in.Scopes != nil {
+		in, out := &in.Scopes, &out.Scopes
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ZendeskOAuthClientCredentials.
+func (in *ZendeskOAuthClientCredentials) DeepCopy() *ZendeskOAuthClientCredentials {
+	if in == nil {
+		return nil
+	}
+	out := new(ZendeskOAuthClientCredentials)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ZendeskPayloadTemplate) DeepCopyInto(out *ZendeskPayloadTemplate) {
+	*out = *in
+	if in.ValueFromConfigMap != nil {
+		in, out := &in.ValueFromConfigMap, &out.ValueFromConfigMap
+		*out = new(corev1.ConfigMapKeySelector)
+		(*in).DeepCopyInto(*out)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ZendeskPayloadTemplate.
+func (in *ZendeskPayloadTemplate) DeepCopy() *ZendeskPayloadTemplate {
+	if in == nil {
+		return nil
+	}
+	out := new(ZendeskPayloadTemplate)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ZendeskPolling) DeepCopyInto(out *ZendeskPolling) {
+	*out = *in
+	if in.Interval != nil {
+		in, out := &in.Interval, &out.Interval
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ZendeskPolling.
+func (in *ZendeskPolling) DeepCopy() *ZendeskPolling {
+	if in == nil {
+		return nil
+	}
+	out := new(ZendeskPolling)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ZendeskSource) DeepCopyInto(out *ZendeskSource) {
 	*out = *in
@@ -328,7 +724,32 @@ func (in *ZendeskSourceSpec) DeepCopyInto(out *ZendeskSourceSpec) {
 	*out = *in
 	in.SourceSpec.DeepCopyInto(&out.SourceSpec)
 	in.Token.DeepCopyInto(&out.Token)
+	if in.OAuth != nil {
+		in, out := &in.OAuth, &out.OAuth
+		*out = new(ZendeskOAuth)
+		(*in).DeepCopyInto(*out)
+	}
 	in.WebhookPassword.DeepCopyInto(&out.WebhookPassword)
+	if in.Events != nil {
+		in, out := &in.Events, &out.Events
+		*out = make([]ZendeskEvent, len(*in))
+		copy(*out, *in)
+	}
+	if in.Trigger != nil {
+		in, out := &in.Trigger, &out.Trigger
+		*out = new(ZendeskTriggerSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Polling != nil {
+		in, out := &in.Polling, &out.Polling
+		*out = new(ZendeskPolling)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Buffer != nil {
+		in, out := &in.Buffer, &out.Buffer
+		*out = new(EventBuffer)
+		(*in).DeepCopyInto(*out)
+	}
 	return
 }
 
@@ -346,6 +767,20 @@ func (in *ZendeskSourceSpec) DeepCopy() *ZendeskSourceSpec {
 func (in *ZendeskSourceStatus) DeepCopyInto(out *ZendeskSourceStatus) {
 	*out = *in
 	in.EventSourceStatus.DeepCopyInto(&out.EventSourceStatus)
+	if in.Triggers != nil {
+		in, out := &in.Triggers, &out.Triggers
+		*out = make([]ZendeskTriggerStatus, len(*in))
+		copy(*out, *in)
+	}
+	if in.LastSyncTime != nil {
+		in, out := &in.LastSyncTime, &out.LastSyncTime
+		*out = (*in).DeepCopy()
+	}
+	if in.LastSyncError != nil {
+		in, out := &in.LastSyncError, &out.LastSyncError
+		*out = new(ZendeskAPIError)
+		**out = **in
+	}
 	return
 }
 
@@ -358,3 +793,87 @@ func (in *ZendeskSourceStatus) DeepCopy() *ZendeskSourceStatus {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ZendeskTriggerCondition) DeepCopyInto(out *ZendeskTriggerCondition) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ZendeskTriggerCondition.
+func (in *ZendeskTriggerCondition) DeepCopy() *ZendeskTriggerCondition {
+	if in == nil {
+		return nil
+	}
+	out := new(ZendeskTriggerCondition)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ZendeskTriggerConditions) DeepCopyInto(out *ZendeskTriggerConditions) {
+	*out = *in
+	if in.All != nil {
+		in, out := &in.All, &out.All
+		*out = make([]ZendeskTriggerCondition, len(*in))
+		copy(*out, *in)
+	}
+	if in.Any != nil {
+		in, out := &in.Any, &out.Any
+		*out = make([]ZendeskTriggerCondition, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ZendeskTriggerConditions.
+func (in *ZendeskTriggerConditions) DeepCopy() *ZendeskTriggerConditions {
+	if in == nil {
+		return nil
+	}
+	out := new(ZendeskTriggerConditions)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ZendeskTriggerSpec) DeepCopyInto(out *ZendeskTriggerSpec) {
+	*out = *in
+	if in.PayloadTemplate != nil {
+		in, out := &in.PayloadTemplate, &out.PayloadTemplate
+		*out = new(ZendeskPayloadTemplate)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = new(ZendeskTriggerConditions)
+		(*in).DeepCopyInto(*out)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ZendeskTriggerSpec.
+func (in *ZendeskTriggerSpec) DeepCopy() *ZendeskTriggerSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ZendeskTriggerSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ZendeskTriggerStatus) DeepCopyInto(out *ZendeskTriggerStatus) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ZendeskTriggerStatus.
+func (in *ZendeskTriggerStatus) DeepCopy() *ZendeskTriggerStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ZendeskTriggerStatus)
+	in.DeepCopyInto(out)
+	return out
+}
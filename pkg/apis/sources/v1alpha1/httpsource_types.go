@@ -64,6 +64,11 @@ type HTTPSourceSpec struct {
 	// BasicAuthPassword used for basic authentication.
 	// +optional
 	BasicAuthPassword SecretValueFromSource `json:"basicAuthPassword,omitempty"`
+
+	// Buffer makes the adapter queue events on disk until they are
+	// delivered to the sink.
+	// +optional
+	Buffer *EventBuffer `json:"buffer,omitempty"`
 }
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
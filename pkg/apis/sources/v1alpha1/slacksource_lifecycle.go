@@ -47,7 +47,7 @@ func (s *SlackSource) GetSink() *duckv1.Destination {
 func (s *SlackSource) GetStatusManager() *EventSourceStatusManager {
 	return &EventSourceStatusManager{
 		ConditionSet:      s.GetConditionSet(),
-		EventSourceStatus: &s.Status,
+		EventSourceStatus: &s.Status.EventSourceStatus,
 	}
 }
 
@@ -59,11 +59,75 @@ func (s *SlackSource) AsEventSource() string {
 // Supported event types
 const (
 	SlackGenericEventType = "com.slack.events"
+	// SlackInteractionEventType is generated upon user interactions with
+	// the Slack app, such as shortcuts, buttons and modals.
+	SlackInteractionEventType = "com.slack.interactivity"
+	// SlackAppRateLimitedEventType is generated when Slack stops
+	// delivering events to the app because it exceeded its rate limit.
+	SlackAppRateLimitedEventType = "com.slack.app.ratelimited"
+	// SlackAppUninstalledEventType is generated when the app is
+	// uninstalled from a workspace.
+	SlackAppUninstalledEventType = "com.slack.app.uninstalled"
+	// SlackTokensRevokedEventType is generated when API tokens of the app
+	// are revoked.
+	SlackTokensRevokedEventType = "com.slack.tokens.revoked"
+)
+
+// Types of the events accepted as replies from the sink
+const (
+	// SlackPostMessageEventType is the type of replies which contain
+	// a message to post to Slack using the chat.postMessage method.
+	SlackPostMessageEventType = "com.slack.chat.postmessage"
 )
 
 // GetEventTypes implements EventSource.
 func (*SlackSource) GetEventTypes() []string {
 	return []string{
 		SlackGenericEventType,
+		SlackInteractionEventType,
+		SlackAppRateLimitedEventType,
+		SlackAppUninstalledEventType,
+		SlackTokensRevokedEventType,
 	}
 }
+
+// Status conditions
+const (
+	// SlackConditionAppHealthy has status True when Slack delivers events
+	// to the app. It doesn't affect the readiness of the source.
+	SlackConditionAppHealthy pkgapis.ConditionType = "SlackAppHealthy"
+)
+
+// Reasons for status conditions
+const (
+	// SlackReasonAppRateLimited is set on a SlackAppHealthy condition when
+	// Slack notified that the app exceeded its rate limit.
+	SlackReasonAppRateLimited = "AppRateLimited"
+	// SlackReasonAppUninstalled is set on a SlackAppHealthy condition when
+	// Slack notified that the app was uninstalled.
+	SlackReasonAppUninstalled = "AppUninstalled"
+	// SlackReasonTokensRevoked is set on a SlackAppHealthy condition when
+	// Slack notified that API tokens of the app were revoked.
+	SlackReasonTokensRevoked = "TokensRevoked"
+)
+
+// Keys of the ConfigMap through which the adapter reports notifications
+// received from Slack about the app. Each key contains the time of the most
+// recent notification of its kind, in the RFC 3339 format, and is removed by
+// the adapter once Slack resumes the delivery of events.
+const (
+	SlackAppStatusRateLimitedKey   = "rateLimited"
+	SlackAppStatusUninstalledKey   = "uninstalled"
+	SlackAppStatusTokensRevokedKey = "tokensRevoked"
+)
+
+// MarkAppHealthy sets the SlackAppHealthy condition to True.
+func (s *SlackSourceStatus) MarkAppHealthy() {
+	eventSourceConditionSet.Manage(s).MarkTrue(SlackConditionAppHealthy)
+}
+
+// MarkAppUnhealthy sets the SlackAppHealthy condition to False with the
+// given reason and message.
+func (s *SlackSourceStatus) MarkAppUnhealthy(reason, messageFormat string, messageA ...interface{}) {
+	eventSourceConditionSet.Manage(s).MarkFalse(SlackConditionAppHealthy, reason, messageFormat, messageA...)
+}
@@ -17,6 +17,7 @@ limitations under the License.
 package v1alpha1
 
 import (
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 
@@ -33,7 +34,7 @@ type SlackSource struct {
 	metav1.ObjectMeta `json:"metadata,omitempty"`
 
 	Spec   SlackSourceSpec   `json:"spec,omitempty"`
-	Status EventSourceStatus `json:"status,omitempty"`
+	Status SlackSourceStatus `json:"status,omitempty"`
 }
 
 // Check the interfaces the event source should be implementing.
@@ -62,6 +63,213 @@ type SlackSourceSpec struct {
 	// applications shared an endpoint. See: https://api.slack.com/events-api
 	// +optional
 	AppID *string `json:"appID,omitempty"`
+
+	// Apps is a list of Slack applications sharing the source's endpoint.
+	// Each request is verified against the signing secret of the
+	// application it claims to originate from, and events from
+	// applications that are not listed are ignored.
+	// +optional
+	Apps []SlackApp `json:"apps,omitempty"`
+
+	// Filter restricts the Slack events which are forwarded to the sink.
+	// Events which are filtered out are acknowledged to Slack and dropped.
+	// +optional
+	Filter *SlackEventFilter `json:"filter,omitempty"`
+
+	// BotToken is a Slack bot token used by the source to interact with
+	// the Slack Web API on behalf of the application.
+	// See: https://api.slack.com/authentication/token-types#bot
+	// +optional
+	BotToken *SecretValueFromSource `json:"botToken,omitempty"`
+
+	// Replies enables the posting to Slack of replies of type
+	// "com.slack.chat.postmessage" returned by the sink. Events are then
+	// delivered to the sink synchronously, in order to receive its reply.
+	// Requires a BotToken with the "chat:write" scope.
+	// +optional
+	Replies bool `json:"replies,omitempty"`
+
+	// Enrichment enables the resolution of the Slack user and channel IDs
+	// contained in events to their names and emails via the Slack Web API.
+	// Requires a BotToken with the scopes "users:read", "users:read.email"
+	// and "channels:read".
+	// +optional
+	Enrichment *SlackEventEnrichment `json:"enrichment,omitempty"`
+
+	// SocketMode makes the source receive events over a WebSocket
+	// connection opened to Slack instead of exposing a public HTTP
+	// endpoint. The adapter is then deployed as a non-addressable
+	// Deployment. See: https://api.slack.com/apis/connections/socket
+	// +optional
+	SocketMode *SlackSocketMode `json:"socketMode,omitempty"`
+
+	// Buffer makes the adapter queue events on disk until they are
+	// delivered to the sink. Outside of Socket Mode, the public URL of
+	// the buffer is required. Not supported with Replies or the
+	// DeadLetter delivery policy.
+	// +optional
+	Buffer *EventBuffer `json:"buffer,omitempty"`
+
+	// Delivery defines how the source reacts when events can not be
+	// delivered to the sink.
+	// +optional
+	Delivery *SlackEventDelivery `json:"delivery,omitempty"`
+
+	// Manifest contains settings of the Slack app manifest generated for
+	// the source. See: https://api.slack.com/reference/manifests
+	// +optional
+	Manifest *SlackAppManifestOptions `json:"manifest,omitempty"`
+}
+
+// SlackApp contains the settings of a Slack application which sends events
+// to a shared SlackSource endpoint.
+type SlackApp struct {
+	// AppID identifies the Slack application.
+	AppID string `json:"appID"`
+
+	// SigningSecret can be set to the value of the application's request
+	// signing secret to authenticate callbacks.
+	// +optional
+	SigningSecret *SecretValueFromSource `json:"signingSecret,omitempty"`
+
+	// Sink overrides the source's sink for events generated by this
+	// application.
+	// +optional
+	Sink *duckv1.Destination `json:"sink,omitempty"`
+
+	// EventTypes restricts the events forwarded for this application to
+	// the given Slack event types (e.g. "message", "app_mention").
+	// All event types are forwarded when empty.
+	// +optional
+	EventTypes []string `json:"eventTypes,omitempty"`
+}
+
+// SlackEventFilter defines the Slack events which are forwarded to the sink.
+// Include lists only forward the events that match at least one of their
+// entries, while exclude lists drop the events that match any of their
+// entries.
+type SlackEventFilter struct {
+	// IncludeEventTypes is a list of Slack event types (e.g. "message").
+	// +optional
+	IncludeEventTypes []string `json:"includeEventTypes,omitempty"`
+	// ExcludeEventTypes is a list of Slack event types (e.g. "message").
+	// +optional
+	ExcludeEventTypes []string `json:"excludeEventTypes,omitempty"`
+
+	// IncludeEventSubtypes is a list of Slack event subtypes (e.g.
+	// "channel_join"). Events without a subtype are not affected.
+	// +optional
+	IncludeEventSubtypes []string `json:"includeEventSubtypes,omitempty"`
+	// ExcludeEventSubtypes is a list of Slack event subtypes (e.g.
+	// "channel_join"). Events without a subtype are not affected.
+	// +optional
+	ExcludeEventSubtypes []string `json:"excludeEventSubtypes,omitempty"`
+
+	// IncludeChannels is a list of Slack channel IDs. Events which do not
+	// refer to a channel are not affected.
+	// +optional
+	IncludeChannels []string `json:"includeChannels,omitempty"`
+	// ExcludeChannels is a list of Slack channel IDs. Events which do not
+	// refer to a channel are not affected.
+	// +optional
+	ExcludeChannels []string `json:"excludeChannels,omitempty"`
+
+	// IncludeUsers is a list of Slack user IDs. Events which do not refer
+	// to a user are not affected.
+	// +optional
+	IncludeUsers []string `json:"includeUsers,omitempty"`
+	// ExcludeUsers is a list of Slack user IDs. Events which do not refer
+	// to a user are not affected.
+	// +optional
+	ExcludeUsers []string `json:"excludeUsers,omitempty"`
+
+	// DropBotMessages drops messages posted by bots.
+	// +optional
+	DropBotMessages bool `json:"dropBotMessages,omitempty"`
+
+	// BotUserID is the user ID of the bot of the Slack application. Events
+	// generated by this user are dropped, which prevents the application
+	// from reacting to its own messages.
+	// +optional
+	BotUserID *string `json:"botUserID,omitempty"`
+}
+
+// SlackEventEnrichment defines how Slack events are enriched with data
+// obtained from the Slack Web API.
+type SlackEventEnrichment struct {
+	// CacheTTL is the duration for which the details of Slack users and
+	// channels are cached by the adapter. Defaults to 10m.
+	// +optional
+	CacheTTL *metav1.Duration `json:"cacheTTL,omitempty"`
+}
+
+// SlackSocketMode contains the settings of the Socket Mode connection to
+// Slack.
+type SlackSocketMode struct {
+	// AppToken is an app-level token with the "connections:write" scope,
+	// used to open Socket Mode connections.
+	// See: https://api.slack.com/authentication/token-types#app
+	AppToken SecretValueFromSource `json:"appToken"`
+}
+
+// SlackEventDelivery defines how the source reacts when events can not be
+// delivered to the sink.
+type SlackEventDelivery struct {
+	// Policy is the action taken when the sink fails to accept an event.
+	// Defaults to Retry.
+	// +optional
+	Policy *SlackDeliveryPolicy `json:"policy,omitempty"`
+
+	// DeadLetterSink is the destination of the events which could not be
+	// delivered to the sink. Required by the DeadLetter policy.
+	// +optional
+	DeadLetterSink *duckv1.Destination `json:"deadLetterSink,omitempty"`
+}
+
+// SlackDeliveryPolicy is the action taken when the sink fails to accept an
+// event.
+type SlackDeliveryPolicy string
+
+// Supported delivery policies.
+const (
+	// SlackDeliveryPolicyRetry rejects the event, so that Slack retries
+	// its delivery later.
+	// See: https://api.slack.com/apis/connections/events-api#the-events-api__field-guide__error-handling__failure-conditions
+	SlackDeliveryPolicyRetry SlackDeliveryPolicy = "Retry"
+	// SlackDeliveryPolicyDeadLetter acknowledges the event to Slack and
+	// sends it to the dead-letter sink.
+	SlackDeliveryPolicyDeadLetter SlackDeliveryPolicy = "DeadLetter"
+)
+
+// SlackAppManifestOptions contains settings of the Slack app manifest
+// generated for the source.
+type SlackAppManifestOptions struct {
+	// AppName is the name of the Slack app. Defaults to the name of the
+	// source.
+	// +optional
+	AppName *string `json:"appName,omitempty"`
+
+	// BotEvents is a list of bot events the Slack app subscribes to (e.g.
+	// "app_mention", "message.channels").
+	// See: https://api.slack.com/events
+	// +optional
+	BotEvents []string `json:"botEvents,omitempty"`
+
+	// BotScopes is a list of OAuth scopes requested for the bot user of the
+	// Slack app, in addition to the scopes required by the source's
+	// features. See: https://api.slack.com/scopes
+	// +optional
+	BotScopes []string `json:"botScopes,omitempty"`
+}
+
+// SlackSourceStatus defines the observed state of the event source.
+type SlackSourceStatus struct {
+	EventSourceStatus `json:",inline"`
+
+	// AppManifestRef references the ConfigMap which contains the Slack app
+	// manifest generated for the source, in the YAML and JSON formats.
+	// +optional
+	AppManifestRef *corev1.LocalObjectReference `json:"appManifestRef,omitempty"`
 }
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
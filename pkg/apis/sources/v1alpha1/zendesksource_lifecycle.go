@@ -17,6 +17,9 @@ limitations under the License.
 package v1alpha1
 
 import (
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 
 	"knative.dev/pkg/apis"
@@ -71,18 +74,219 @@ func ZendeskSourceName(subdomain, name string) string {
 const (
 	// ZendeskTicketCreatedEventType is generated upon creation of a Ticket.
 	ZendeskTicketCreatedEventType = "com.zendesk.ticket.created"
+	// ZendeskTicketUpdatedEventType is generated upon update of a Ticket.
+	ZendeskTicketUpdatedEventType = "com.zendesk.ticket.updated"
+	// ZendeskTicketSolvedEventType is generated when a Ticket is solved.
+	ZendeskTicketSolvedEventType = "com.zendesk.ticket.solved"
+	// ZendeskTicketReopenedEventType is generated when a solved Ticket is
+	// reopened.
+	ZendeskTicketReopenedEventType = "com.zendesk.ticket.reopened"
+	// ZendeskTicketCommentAddedEventType is generated when a comment is
+	// added to a Ticket.
+	ZendeskTicketCommentAddedEventType = "com.zendesk.ticket.comment.added"
+	// ZendeskTicketAssigneeChangedEventType is generated when the assignee
+	// of a Ticket changes.
+	ZendeskTicketAssigneeChangedEventType = "com.zendesk.ticket.assignee.changed"
+	// ZendeskTicketSatisfactionRatedEventType is generated when the
+	// satisfaction of the requester of a Ticket is rated.
+	ZendeskTicketSatisfactionRatedEventType = "com.zendesk.ticket.satisfaction.rated"
+
+	// ZendeskUserCreatedEventType is generated upon creation of a User.
+	ZendeskUserCreatedEventType = "com.zendesk.user.created"
+	// ZendeskUserUpdatedEventType is generated upon update of a User.
+	ZendeskUserUpdatedEventType = "com.zendesk.user.updated"
+	// ZendeskUserDeletedEventType is generated upon deletion of a User.
+	ZendeskUserDeletedEventType = "com.zendesk.user.deleted"
+	// ZendeskOrganizationCreatedEventType is generated upon creation of an
+	// Organization.
+	ZendeskOrganizationCreatedEventType = "com.zendesk.organization.created"
+	// ZendeskOrganizationUpdatedEventType is generated upon update of an
+	// Organization.
+	ZendeskOrganizationUpdatedEventType = "com.zendesk.organization.updated"
+	// ZendeskOrganizationDeletedEventType is generated upon deletion of an
+	// Organization.
+	ZendeskOrganizationDeletedEventType = "com.zendesk.organization.deleted"
 )
 
+// zendeskEventTypes maps the supported Zendesk events to the type of the
+// CloudEvents generated for them.
+var zendeskEventTypes = map[ZendeskEvent]string{
+	ZendeskTicketCreated:           ZendeskTicketCreatedEventType,
+	ZendeskTicketUpdated:           ZendeskTicketUpdatedEventType,
+	ZendeskTicketSolved:            ZendeskTicketSolvedEventType,
+	ZendeskTicketReopened:          ZendeskTicketReopenedEventType,
+	ZendeskTicketCommentAdded:      ZendeskTicketCommentAddedEventType,
+	ZendeskTicketAssigneeChanged:   ZendeskTicketAssigneeChangedEventType,
+	ZendeskTicketSatisfactionRated: ZendeskTicketSatisfactionRatedEventType,
+	ZendeskUserCreated:             ZendeskUserCreatedEventType,
+	ZendeskUserUpdated:             ZendeskUserUpdatedEventType,
+	ZendeskUserDeleted:             ZendeskUserDeletedEventType,
+	ZendeskOrganizationCreated:     ZendeskOrganizationCreatedEventType,
+	ZendeskOrganizationUpdated:     ZendeskOrganizationUpdatedEventType,
+	ZendeskOrganizationDeleted:     ZendeskOrganizationDeletedEventType,
+}
+
+// EventType returns the type of the CloudEvents generated for the Zendesk
+// event, or an empty string if the event is not supported.
+func (e ZendeskEvent) EventType() string {
+	return zendeskEventTypes[e]
+}
+
+// ZendeskWebhookEventTypePrefix is the prefix of the types of the Zendesk
+// events which webhooks can subscribe to.
+const ZendeskWebhookEventTypePrefix = "zen:event-type:"
+
+// zendeskWebhookEventTypes maps the Zendesk events which are notified by a
+// webhook subscribed to Zendesk event types, instead of Triggers, to these
+// event types. Frequent and sensitive changes, such as logins and password
+// changes, are not notified.
+// See: https://developer.zendesk.com/api-reference/webhooks/event-types/webhook-event-types/
+var zendeskWebhookEventTypes = map[ZendeskEvent][]string{
+	ZendeskUserCreated: {
+		"user.created",
+	},
+	ZendeskUserUpdated: {
+		"user.active_changed",
+		"user.alias_changed",
+		"user.custom_field_changed",
+		"user.custom_role_changed",
+		"user.default_group_changed",
+		"user.details_changed",
+		"user.external_id_changed",
+		"user.group_membership_created",
+		"user.group_membership_deleted",
+		"user.identity_changed",
+		"user.identity_created",
+		"user.identity_deleted",
+		"user.name_changed",
+		"user.notes_changed",
+		"user.organization_membership_created",
+		"user.organization_membership_deleted",
+		"user.role_changed",
+		"user.tags_changed",
+		"user.time_zone_changed",
+	},
+	ZendeskUserDeleted: {
+		"user.deleted",
+	},
+	ZendeskOrganizationCreated: {
+		"organization.created",
+	},
+	ZendeskOrganizationUpdated: {
+		"organization.custom_field_changed",
+		"organization.external_id_changed",
+		"organization.group_changed",
+		"organization.name_changed",
+		"organization.tags_changed",
+	},
+	ZendeskOrganizationDeleted: {
+		"organization.deleted",
+	},
+}
+
+// WebhookEventTypes returns the types of the Zendesk events a webhook must
+// subscribe to in order to be notified about the Zendesk event, or nil if the
+// event is notified by Triggers.
+func (e ZendeskEvent) WebhookEventTypes() []string {
+	types := zendeskWebhookEventTypes[e]
+	if types == nil {
+		return nil
+	}
+
+	prefixed := make([]string, len(types))
+	for i, t := range types {
+		prefixed[i] = ZendeskWebhookEventTypePrefix + t
+	}
+	return prefixed
+}
+
+// ZendeskEventOfWebhookEventType returns the Zendesk event notified by a
+// webhook subscribed to the given Zendesk event type, and whether that event
+// type is supported.
+func ZendeskEventOfWebhookEventType(typ string) (ZendeskEvent, bool) {
+	for ev, types := range zendeskWebhookEventTypes {
+		for _, t := range types {
+			if ZendeskWebhookEventTypePrefix+t == typ {
+				return ev, true
+			}
+		}
+	}
+	return "", false
+}
+
+// GetWebhookEventTypes returns the types of the Zendesk events the webhook of
+// the source must subscribe to, in the order of the source's events.
+func (s *ZendeskSource) GetWebhookEventTypes() []string {
+	var types []string
+	for _, e := range s.GetEvents() {
+		types = append(types, e.WebhookEventTypes()...)
+	}
+	return types
+}
+
+// GetEvents returns the Zendesk events the source subscribes to.
+func (s *ZendeskSource) GetEvents() []ZendeskEvent {
+	if len(s.Spec.Events) == 0 {
+		return []ZendeskEvent{ZendeskTicketCreated}
+	}
+	return s.Spec.Events
+}
+
+// GetNotificationAPI returns the Zendesk API through which the adapter of the
+// source is notified.
+func (s *ZendeskSource) GetNotificationAPI() ZendeskNotificationAPI {
+	if s.Spec.NotificationAPI == "" {
+		return ZendeskNotificationAPITargets
+	}
+	return s.Spec.NotificationAPI
+}
+
+// ZendeskDefaultOAuthScopes are the scopes requested for access tokens by
+// default, which grant access to the Zendesk objects managed by the controller.
+var ZendeskDefaultOAuthScopes = []string{
+	"triggers:read", "triggers:write",
+	"targets:read", "targets:write",
+	"webhooks:read", "webhooks:write",
+}
+
+// GetScopes returns the scopes requested for access tokens.
+func (c *ZendeskOAuthClientCredentials) GetScopes() []string {
+	if len(c.Scopes) == 0 {
+		return ZendeskDefaultOAuthScopes
+	}
+	return c.Scopes
+}
+
+// ZendeskDefaultPollingInterval is the default interval between two polls of
+// the Zendesk API.
+const ZendeskDefaultPollingInterval = time.Minute
+
+// GetPollingInterval returns the interval between two polls of the Zendesk
+// API by the source.
+func (p *ZendeskPolling) GetPollingInterval() time.Duration {
+	if p.Interval == nil || p.Interval.Duration <= 0 {
+		return ZendeskDefaultPollingInterval
+	}
+	return p.Interval.Duration
+}
+
 // GetEventTypes implements EventSource.
-func (*ZendeskSource) GetEventTypes() []string {
-	return []string{
-		ZendeskTicketCreatedEventType,
+func (s *ZendeskSource) GetEventTypes() []string {
+	events := s.GetEvents()
+
+	types := make([]string, 0, len(events))
+	for _, e := range events {
+		if typ := e.EventType(); typ != "" {
+			types = append(types, typ)
+		}
 	}
+
+	return types
 }
 
 // Status conditions
 const (
-	// ZendeskConditionTargetSynced has status True when the Zendesk Target and Trigger have been synced.
+	// ZendeskConditionTargetSynced has status True when the Zendesk Target (or webhook) and Triggers have been synced.
 	ZendeskConditionTargetSynced apis.ConditionType = "TargetSynced"
 )
 
@@ -94,6 +298,17 @@ const (
 	ZendeskReasonNoSecret = "MissingSecret"
 	// ZendeskReasonFailedSync is set on a TargetSynced condition when a CRUD API call returns an error.
 	ZendeskReasonFailedSync = "FailedSync"
+	// ZendeskReasonAPIAccessDenied is set on a TargetSynced condition when the Zendesk API rejects the
+	// credentials of the controller.
+	ZendeskReasonAPIAccessDenied = "APIAccessDenied"
+	// ZendeskReasonAPIRateLimited is set on a TargetSynced condition when the Zendesk API rate limits
+	// the controller.
+	ZendeskReasonAPIRateLimited = "APIRateLimited"
+	// ZendeskReasonInvalidTrigger is set on a TargetSynced condition when the Trigger settings are invalid.
+	ZendeskReasonInvalidTrigger = "InvalidTrigger"
+	// ZendeskReasonPolling is set on a TargetSynced condition when the source polls Zendesk, and
+	// therefore requires no Target.
+	ZendeskReasonPolling = "Polling"
 )
 
 // zendeskSourceConditionSet is a set of status conditions for ZendeskSource
@@ -107,9 +322,28 @@ func (s *ZendeskSourceStatus) MarkTargetSynced() {
 	zendeskSourceConditionSet.Manage(s).MarkTrue(ZendeskConditionTargetSynced)
 }
 
+// MarkTargetSyncedAt sets the TargetSynced condition to True, and clears the
+// last synchronization error. The last synchronization time is set to the
+// given time if the synchronization modified Zendesk objects, or if the
+// condition was not already True.
+func (s *ZendeskSourceStatus) MarkTargetSyncedAt(t metav1.Time, modified bool) {
+	if modified || s.LastSyncTime == nil || !s.GetCondition(ZendeskConditionTargetSynced).IsTrue() {
+		s.LastSyncTime = &t
+	}
+	s.LastSyncError = nil
+	s.MarkTargetSynced()
+}
+
 // MarkTargetNotSynced sets the TargetSynced condition to False with the given
 // reason and associated message.
 func (s *ZendeskSourceStatus) MarkTargetNotSynced(reason, msg string) {
 	zendeskSourceConditionSet.Manage(s).MarkFalse(ZendeskConditionTargetSynced,
-		ZendeskReasonFailedSync, msg)
+		reason, msg)
+}
+
+// MarkTargetNotRequired sets the TargetSynced condition to True for sources
+// which poll Zendesk instead of being notified through a Target.
+func (s *ZendeskSourceStatus) MarkTargetNotRequired() {
+	zendeskSourceConditionSet.Manage(s).MarkTrueWithReason(ZendeskConditionTargetSynced,
+		ZendeskReasonPolling, "The source polls the Zendesk API for events")
 }
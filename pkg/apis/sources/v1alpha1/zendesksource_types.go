@@ -17,6 +17,7 @@ limitations under the License.
 package v1alpha1
 
 import (
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 
@@ -55,27 +56,290 @@ type ZendeskSourceSpec struct {
 
 	// Token identifies the API token used for creating the proper credentials to interface with Zendesk
 	// allowing the source to auto-register the webhook to authenticate callbacks.
+	// Optional with the Targets notification API when OAuth is set.
+	// +optional
 	Token SecretValueFromSource `json:"token,omitempty"`
 
+	// OAuth authenticates the controller against the Zendesk API with an
+	// OAuth access token instead of the API token of a user.
+	// +optional
+	OAuth *ZendeskOAuth `json:"oauth,omitempty"`
+
 	// Email identifies the email used for creating the proper credentials to interface with Zendesk
 	// allowing the source to auto-register the webhook to authenticate callbacks.
 	Email string `json:"email,omitempty"`
 
+	// NotificationAPI is the Zendesk API used to notify the adapter about
+	// events. Defaults to Targets.
+	// +optional
+	NotificationAPI ZendeskNotificationAPI `json:"notificationAPI,omitempty"`
+
 	// WebhookPassword used for basic authentication for events sent from Zendesk
-	// to the adapter.
+	// to the adapter. Only used with the Targets notification API.
 	WebhookPassword SecretValueFromSource `json:"webhookPassword,omitempty"`
 
 	// WebhookUsername used for basic authentication for events sent from Zendesk
-	// to the adapter.
+	// to the adapter. Only used with the Targets notification API.
 	WebhookUsername string `json:"webhookUsername,omitempty"`
 
 	// Subdomain identifies Zendesk subdomain
 	Subdomain string `json:"subdomain,omitempty"`
+
+	// Events is a list of Zendesk events the source subscribes to. A
+	// Zendesk Trigger is created for each ticket event, and a Zendesk
+	// webhook is subscribed to user and organization events, which
+	// require the Webhooks notification API.
+	// Defaults to TicketCreated.
+	// +optional
+	Events []ZendeskEvent `json:"events,omitempty"`
+
+	// Trigger customizes the Zendesk Triggers created for the source.
+	// +optional
+	Trigger *ZendeskTriggerSpec `json:"trigger,omitempty"`
+
+	// Polling makes the source poll the Zendesk Incremental Exports API
+	// for ticket events instead of being notified by Zendesk, for setups
+	// where Zendesk can not reach the cluster. The adapter is then
+	// deployed as a non-addressable Deployment, and no Zendesk Target,
+	// webhook or Trigger is created.
+	// See: https://developer.zendesk.com/api-reference/ticketing/ticket-management/incremental_exports/
+	// +optional
+	Polling *ZendeskPolling `json:"polling,omitempty"`
+
+	// Buffer makes the adapter queue events on disk until they are
+	// delivered to the sink. Outside of polling mode, the public URL of
+	// the buffer is required.
+	// +optional
+	Buffer *EventBuffer `json:"buffer,omitempty"`
+}
+
+// ZendeskOAuth contains the OAuth credentials used by the controller to
+// authenticate against the Zendesk API.
+type ZendeskOAuth struct {
+	// Optional: no more than one of the following may be specified.
+
+	// AccessToken is an OAuth access token, sent as a bearer token.
+	// +optional
+	AccessToken *SecretValueFromSource `json:"accessToken,omitempty"`
+	// ClientCredentials of an OAuth client, which the controller exchanges
+	// for access tokens, and refreshes upon expiration.
+	// +optional
+	ClientCredentials *ZendeskOAuthClientCredentials `json:"clientCredentials,omitempty"`
+}
+
+// ZendeskOAuthClientCredentials are the credentials of a Zendesk OAuth client.
+// See: https://developer.zendesk.com/documentation/ticketing/working-with-oauth/creating-and-using-oauth-tokens-with-the-api/
+type ZendeskOAuthClientCredentials struct {
+	// Unique identifier of the OAuth client.
+	ClientID string `json:"clientID"`
+	// Secret of the OAuth client.
+	ClientSecret SecretValueFromSource `json:"clientSecret"`
+	// Scopes requested for access tokens.
+	// Defaults to read and write access to Triggers, Targets and webhooks.
+	// +optional
+	Scopes []string `json:"scopes,omitempty"`
+}
+
+// ZendeskTriggerSpec customizes the Zendesk Triggers which notify the source
+// about events.
+type ZendeskTriggerSpec struct {
+	// PayloadTemplate is a template of the JSON object sent to the source
+	// by each Trigger. It may contain Zendesk placeholders, such as
+	// {{ticket.id}}, which must be quoted unless they render as numbers.
+	// The "event_type" attribute is reserved.
+	// Defaults to a template which contains most attributes of the ticket.
+	// See: https://support.zendesk.com/hc/en-us/articles/203662156
+	// +optional
+	PayloadTemplate *ZendeskPayloadTemplate `json:"payloadTemplate,omitempty"`
+
+	// Conditions which must be met, in addition to the conditions of the
+	// subscribed event, for Triggers to notify the source.
+	// +optional
+	Conditions *ZendeskTriggerConditions `json:"conditions,omitempty"`
+}
+
+// ZendeskPayloadTemplate is a payload template that can be defined either
+// explicitly or sourced from a ConfigMap.
+type ZendeskPayloadTemplate struct {
+	// Optional: no more than one of the following may be specified.
+
+	// Template value.
+	// +optional
+	Value string `json:"value,omitempty"`
+	// Template value from a Kubernetes ConfigMap.
+	// +optional
+	ValueFromConfigMap *corev1.ConfigMapKeySelector `json:"valueFromConfigMap,omitempty"`
+}
+
+// ZendeskTriggerConditions are conditions on the attributes of tickets. All
+// the conditions in All must be met, as well as at least one of the
+// conditions in Any, if any.
+type ZendeskTriggerConditions struct {
+	// +optional
+	All []ZendeskTriggerCondition `json:"all,omitempty"`
+	// +optional
+	Any []ZendeskTriggerCondition `json:"any,omitempty"`
+}
+
+// ZendeskTriggerCondition is a condition on an attribute of a ticket.
+type ZendeskTriggerCondition struct {
+	// Attribute of the ticket.
+	Field ZendeskConditionField `json:"field"`
+	// Operator of the condition. Tags support Includes and NotIncludes,
+	// Priority supports Is, IsNot, LessThan and GreaterThan, other fields
+	// support Is and IsNot.
+	// Defaults to Includes for Tags, Is for other fields.
+	// +optional
+	Operator ZendeskConditionOperator `json:"operator,omitempty"`
+	// Value the attribute is compared to: the ID of a group, brand or
+	// form, a space-separated list of tags, or a priority (low, normal,
+	// high, urgent).
+	Value string `json:"value"`
+}
+
+// ZendeskConditionField is an attribute of a ticket which Trigger conditions
+// apply to.
+type ZendeskConditionField string
+
+// Supported fields of Trigger conditions.
+const (
+	ZendeskConditionFieldGroup    ZendeskConditionField = "Group"
+	ZendeskConditionFieldBrand    ZendeskConditionField = "Brand"
+	ZendeskConditionFieldTags     ZendeskConditionField = "Tags"
+	ZendeskConditionFieldPriority ZendeskConditionField = "Priority"
+	ZendeskConditionFieldForm     ZendeskConditionField = "Form"
+)
+
+// ZendeskConditionOperator is an operator of a Trigger condition.
+type ZendeskConditionOperator string
+
+// Supported operators of Trigger conditions.
+const (
+	ZendeskConditionOperatorIs          ZendeskConditionOperator = "Is"
+	ZendeskConditionOperatorIsNot       ZendeskConditionOperator = "IsNot"
+	ZendeskConditionOperatorIncludes    ZendeskConditionOperator = "Includes"
+	ZendeskConditionOperatorNotIncludes ZendeskConditionOperator = "NotIncludes"
+	ZendeskConditionOperatorLessThan    ZendeskConditionOperator = "LessThan"
+	ZendeskConditionOperatorGreaterThan ZendeskConditionOperator = "GreaterThan"
+)
+
+// ZendeskPolling contains the settings of the polling of the Zendesk
+// Incremental Exports API.
+type ZendeskPolling struct {
+	// Interval between two polls of the Zendesk API. Zendesk limits
+	// Incremental Exports to 10 requests per minute.
+	// Defaults to 1m.
+	// +optional
+	Interval *metav1.Duration `json:"interval,omitempty"`
 }
 
+// ZendeskNotificationAPI is a Zendesk API through which Zendesk Triggers
+// notify the adapter.
+type ZendeskNotificationAPI string
+
+// Supported Zendesk notification APIs.
+const (
+	// ZendeskNotificationAPITargets notifies the adapter through a legacy
+	// HTTP Target, which authenticates with Basic credentials.
+	ZendeskNotificationAPITargets ZendeskNotificationAPI = "Targets"
+	// ZendeskNotificationAPIWebhooks notifies the adapter through a
+	// webhook, which signs its requests.
+	ZendeskNotificationAPIWebhooks ZendeskNotificationAPI = "Webhooks"
+)
+
+// ZendeskEvent is a Zendesk event which can be subscribed to.
+type ZendeskEvent string
+
+// Supported Zendesk events.
+const (
+	// ZendeskTicketCreated occurs when a ticket is created.
+	ZendeskTicketCreated ZendeskEvent = "TicketCreated"
+	// ZendeskTicketUpdated occurs when a ticket is updated.
+	ZendeskTicketUpdated ZendeskEvent = "TicketUpdated"
+	// ZendeskTicketSolved occurs when the status of a ticket changes to
+	// solved.
+	ZendeskTicketSolved ZendeskEvent = "TicketSolved"
+	// ZendeskTicketReopened occurs when the status of a solved ticket
+	// changes.
+	ZendeskTicketReopened ZendeskEvent = "TicketReopened"
+	// ZendeskTicketCommentAdded occurs when a public or private comment is
+	// added to a ticket.
+	ZendeskTicketCommentAdded ZendeskEvent = "TicketCommentAdded"
+	// ZendeskTicketAssigneeChanged occurs when the assignee of a ticket
+	// changes.
+	ZendeskTicketAssigneeChanged ZendeskEvent = "TicketAssigneeChanged"
+	// ZendeskTicketSatisfactionRated occurs when the requester of a ticket
+	// rates their satisfaction.
+	ZendeskTicketSatisfactionRated ZendeskEvent = "TicketSatisfactionRated"
+
+	// ZendeskUserCreated occurs when a user is created.
+	ZendeskUserCreated ZendeskEvent = "UserCreated"
+	// ZendeskUserUpdated occurs when an attribute, identity or membership
+	// of a user changes.
+	ZendeskUserUpdated ZendeskEvent = "UserUpdated"
+	// ZendeskUserDeleted occurs when a user is deleted.
+	ZendeskUserDeleted ZendeskEvent = "UserDeleted"
+	// ZendeskOrganizationCreated occurs when an organization is created.
+	ZendeskOrganizationCreated ZendeskEvent = "OrganizationCreated"
+	// ZendeskOrganizationUpdated occurs when an attribute of an
+	// organization changes.
+	ZendeskOrganizationUpdated ZendeskEvent = "OrganizationUpdated"
+	// ZendeskOrganizationDeleted occurs when an organization is deleted.
+	ZendeskOrganizationDeleted ZendeskEvent = "OrganizationDeleted"
+)
+
 // ZendeskSourceStatus defines the observed state of the event source.
 type ZendeskSourceStatus struct {
 	EventSourceStatus `json:",inline"`
+
+	// TargetCredentialsDigest is a digest of the credentials set on the
+	// Zendesk Target by the controller. It allows detecting changes of
+	// these credentials, which can not be read back from the Zendesk API.
+	// +optional
+	TargetCredentialsDigest string `json:"targetCredentialsDigest,omitempty"`
+
+	// TargetID is the ID of the Zendesk Target, or webhook, which notifies
+	// the adapter.
+	// +optional
+	TargetID string `json:"targetID,omitempty"`
+
+	// EventsWebhookID is the ID of the Zendesk webhook which notifies the
+	// adapter about user and organization events.
+	// +optional
+	EventsWebhookID string `json:"eventsWebhookID,omitempty"`
+
+	// Triggers are the Zendesk Triggers created for the events the source
+	// subscribes to.
+	// +optional
+	Triggers []ZendeskTriggerStatus `json:"triggers,omitempty"`
+
+	// LastSyncTime is the time at which the Zendesk Target and Triggers
+	// were last modified, or found in sync after a failure.
+	// +optional
+	LastSyncTime *metav1.Time `json:"lastSyncTime,omitempty"`
+
+	// LastSyncError describes the last error returned by the Zendesk API
+	// while synchronizing the Zendesk Target and Triggers. It is cleared
+	// upon successful synchronization.
+	// +optional
+	LastSyncError *ZendeskAPIError `json:"lastSyncError,omitempty"`
+}
+
+// ZendeskTriggerStatus is the observed state of a Zendesk Trigger.
+type ZendeskTriggerStatus struct {
+	// Event the Trigger notifies about.
+	Event ZendeskEvent `json:"event"`
+	// ID of the Trigger.
+	ID int64 `json:"id"`
+}
+
+// ZendeskAPIError is an error returned by the Zendesk API.
+type ZendeskAPIError struct {
+	// HTTP status code of the response, if a response was received.
+	// +optional
+	StatusCode int `json:"statusCode,omitempty"`
+	// Message describing the error.
+	Message string `json:"message"`
 }
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
@@ -24,10 +24,12 @@ import (
 	appsclientv1 "k8s.io/client-go/kubernetes/typed/apps/v1"
 	coreclientv1 "k8s.io/client-go/kubernetes/typed/core/v1"
 	appslistersv1 "k8s.io/client-go/listers/apps/v1"
+	corelistersv1 "k8s.io/client-go/listers/core/v1"
 	"k8s.io/client-go/tools/cache"
 
 	k8sclient "knative.dev/pkg/client/injection/kube/client"
 	deploymentinformerv1 "knative.dev/pkg/client/injection/kube/informers/apps/v1/deployment"
+	k8sserviceinformerv1 "knative.dev/pkg/client/injection/kube/informers/core/v1/service"
 	"knative.dev/pkg/controller"
 	"knative.dev/pkg/resolver"
 	servingclientv1 "knative.dev/serving/pkg/client/clientset/versioned/typed/serving/v1"
@@ -47,6 +49,16 @@ type GenericDeploymentReconciler struct {
 	Lister func(namespace string) appslistersv1.DeploymentNamespaceLister
 }
 
+// GenericAddressableDeploymentReconciler contains interfaces shared across
+// reconcilers of Deployments which are exposed by a Kubernetes Service.
+type GenericAddressableDeploymentReconciler struct {
+	GenericDeploymentReconciler
+	// API clients
+	ServiceClient func(namespace string) coreclientv1.ServiceInterface
+	// objects listers
+	ServiceLister func(namespace string) corelistersv1.ServiceNamespaceLister
+}
+
 // GenericServiceReconciler contains interfaces shared across Service reconcilers.
 type GenericServiceReconciler struct {
 	// URI resolver for sinks
@@ -81,6 +93,30 @@ func NewGenericDeploymentReconciler(ctx context.Context, gvk schema.GroupVersion
 	return r
 }
 
+// NewGenericAddressableDeploymentReconciler creates a new
+// GenericAddressableDeploymentReconciler and attaches a default event handler
+// to its Deployment and Kubernetes Service informers.
+func NewGenericAddressableDeploymentReconciler(ctx context.Context, gvk schema.GroupVersionKind,
+	resolverCallback func(types.NamespacedName),
+	adapterHandlerFn func(obj interface{}),
+) GenericAddressableDeploymentReconciler {
+
+	informer := k8sserviceinformerv1.Get(ctx)
+
+	r := GenericAddressableDeploymentReconciler{
+		GenericDeploymentReconciler: NewGenericDeploymentReconciler(ctx, gvk, resolverCallback, adapterHandlerFn),
+		ServiceClient:               k8sclient.Get(ctx).CoreV1().Services,
+		ServiceLister:               informer.Lister().Services,
+	}
+
+	informer.Informer().AddEventHandler(cache.FilteringResourceEventHandler{
+		FilterFunc: controller.FilterControllerGVK(gvk),
+		Handler:    controller.HandleAll(adapterHandlerFn),
+	})
+
+	return r
+}
+
 // NewGenericServiceReconciler creates a new GenericServiceReconciler and
 // attaches a default event handler to its Service informer.
 func NewGenericServiceReconciler(ctx context.Context, gvk schema.GroupVersionKind,
@@ -0,0 +1,67 @@
+/*
+Copyright (c) 2020 TriggerMesh Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package buffer configures adapters to buffer events on a persistent volume
+// before delivering them to their sink.
+package buffer
+
+import (
+	"strconv"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/triggermesh/knative-sources/pkg/apis/sources/v1alpha1"
+	"github.com/triggermesh/knative-sources/pkg/reconciler/common"
+	"github.com/triggermesh/knative-sources/pkg/reconciler/common/resource"
+)
+
+const (
+	// name of the volume in which an adapter buffers events
+	bufferVolumeName = "buffer"
+	// path at which the buffer volume is mounted in the adapter's container
+	bufferMountPath = "/var/lib/adapter/buffer"
+)
+
+// AdapterOption returns an option which mounts the PersistentVolumeClaim of the
+// given event buffer in an adapter Deployment, and configures the adapter to
+// buffer events in it. The option has no effect if buf is nil.
+func AdapterOption(buf *v1alpha1.EventBuffer) resource.ObjectOption {
+	return func(object interface{}) {
+		if buf == nil {
+			return
+		}
+
+		opts := []resource.ObjectOption{
+			resource.Volume(bufferVolumeName, corev1.VolumeSource{
+				PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+					ClaimName: buf.PersistentVolumeClaimName,
+				},
+			}),
+			resource.VolumeMount(bufferVolumeName, bufferMountPath),
+
+			resource.EnvVar(common.EnvBufferDir, bufferMountPath),
+			resource.EnvVar(common.EnvBufferMaxBytes, strconv.FormatInt(buf.GetMaxSize(), 10)),
+			resource.EnvVar(common.EnvBufferMaxAge, buf.GetMaxAge().String()),
+
+			// a buffer must never be written by two Pods at once
+			resource.RecreateStrategy(),
+		}
+
+		for _, opt := range opts {
+			opt(object)
+		}
+	}
+}
@@ -0,0 +1,274 @@
+/*
+Copyright (c) 2020 TriggerMesh Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	coreclientv1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	rbacclientv1 "k8s.io/client-go/kubernetes/typed/rbac/v1"
+	corelistersv1 "k8s.io/client-go/listers/core/v1"
+	rbaclistersv1 "k8s.io/client-go/listers/rbac/v1"
+
+	k8sclient "knative.dev/pkg/client/injection/kube/client"
+	configmapinformerv1 "knative.dev/pkg/client/injection/kube/informers/core/v1/configmap"
+	serviceaccountinformerv1 "knative.dev/pkg/client/injection/kube/informers/core/v1/serviceaccount"
+	roleinformerv1 "knative.dev/pkg/client/injection/kube/informers/rbac/v1/role"
+	rolebindinginformerv1 "knative.dev/pkg/client/injection/kube/informers/rbac/v1/rolebinding"
+	"knative.dev/pkg/kmeta"
+	"knative.dev/pkg/reconciler"
+
+	"github.com/triggermesh/knative-sources/pkg/apis/sources/v1alpha1"
+	"github.com/triggermesh/knative-sources/pkg/reconciler/common/event"
+)
+
+// GenericConfigMapReconciler reconciles ConfigMaps owned by sources which
+// their adapter accesses at runtime (e.g. to persist its state), along with
+// the ServiceAccount, Role and RoleBinding which grant the adapter access to
+// those ConfigMaps.
+type GenericConfigMapReconciler struct {
+	// API clients
+	ConfigMapClient      func(namespace string) coreclientv1.ConfigMapInterface
+	ServiceAccountClient func(namespace string) coreclientv1.ServiceAccountInterface
+	RoleClient           func(namespace string) rbacclientv1.RoleInterface
+	RoleBindingClient    func(namespace string) rbacclientv1.RoleBindingInterface
+	// objects listers
+	ConfigMapLister      func(namespace string) corelistersv1.ConfigMapNamespaceLister
+	ServiceAccountLister func(namespace string) corelistersv1.ServiceAccountNamespaceLister
+	RoleLister           func(namespace string) rbaclistersv1.RoleNamespaceLister
+	RoleBindingLister    func(namespace string) rbaclistersv1.RoleBindingNamespaceLister
+}
+
+// NewGenericConfigMapReconciler creates a new GenericConfigMapReconciler.
+// Unlike other generic reconcilers, it doesn't attach any event handler to
+// its informers, since adapters may update their ConfigMap frequently.
+func NewGenericConfigMapReconciler(ctx context.Context) GenericConfigMapReconciler {
+	kubeClient := k8sclient.Get(ctx)
+
+	return GenericConfigMapReconciler{
+		ConfigMapClient:      kubeClient.CoreV1().ConfigMaps,
+		ServiceAccountClient: kubeClient.CoreV1().ServiceAccounts,
+		RoleClient:           kubeClient.RbacV1().Roles,
+		RoleBindingClient:    kubeClient.RbacV1().RoleBindings,
+		ConfigMapLister:      configmapinformerv1.Get(ctx).Lister().ConfigMaps,
+		ServiceAccountLister: serviceaccountinformerv1.Get(ctx).Lister().ServiceAccounts,
+		RoleLister:           roleinformerv1.Get(ctx).Lister().Roles,
+		RoleBindingLister:    rolebindinginformerv1.Get(ctx).Lister().RoleBindings,
+	}
+}
+
+// AdapterServiceAccountName returns the name of the ServiceAccount of the
+// adapter of the given source.
+func AdapterServiceAccountName(src v1alpha1.EventSource) string {
+	return kmeta.ChildName(AdapterName(src)+"-", src.GetName())
+}
+
+// NewAdapterConfigMap returns an empty ConfigMap with the given name, owned
+// by the given source. Its data is written by the source's adapter.
+func NewAdapterConfigMap(src v1alpha1.EventSource, name string) *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		ObjectMeta: adapterObjectMeta(src, name),
+	}
+}
+
+// ReconcileAdapterConfigMap ensures the ConfigMap with the given name exists,
+// and that the adapter of the source is allowed to perform the given verbs
+// on it using its own ServiceAccount. The data of an existing ConfigMap is
+// never overwritten. The current ConfigMap is returned.
+func (r *GenericConfigMapReconciler) ReconcileAdapterConfigMap(ctx context.Context, src v1alpha1.EventSource,
+	name string, verbs ...string) (*corev1.ConfigMap, error) {
+
+	if err := r.reconcileServiceAccount(ctx, newAdapterServiceAccount(src)); err != nil {
+		return nil, err
+	}
+	if err := r.reconcileRole(ctx, newAdapterConfigMapRole(src, name, verbs)); err != nil {
+		return nil, err
+	}
+	if err := r.reconcileRoleBinding(ctx, newAdapterRoleBinding(src)); err != nil {
+		return nil, err
+	}
+	return r.reconcileConfigMap(ctx, NewAdapterConfigMap(src, name))
+}
+
+// reconcileConfigMap ensures the given ConfigMap exists, and returns it.
+func (r *GenericConfigMapReconciler) reconcileConfigMap(ctx context.Context,
+	desired *corev1.ConfigMap) (*corev1.ConfigMap, error) {
+
+	current, err := r.ConfigMapLister(desired.Namespace).Get(desired.Name)
+	switch {
+	case apierrors.IsNotFound(err):
+		current, err = r.ConfigMapClient(desired.Namespace).Create(ctx, desired, metav1.CreateOptions{})
+		if err != nil {
+			return nil, reconciler.NewEvent(corev1.EventTypeWarning, ReasonFailedAdapterCreate,
+				"Failed to create adapter ConfigMap %q: %s", desired.Name, err)
+		}
+		event.Normal(ctx, ReasonAdapterCreate, "Created adapter ConfigMap %q", desired.Name)
+
+	case err != nil:
+		return nil, fmt.Errorf("failed to get adapter ConfigMap from cache: %w", err)
+	}
+
+	return current, nil
+}
+
+// reconcileServiceAccount ensures the given ServiceAccount exists.
+func (r *GenericConfigMapReconciler) reconcileServiceAccount(ctx context.Context, desired *corev1.ServiceAccount) error {
+	_, err := r.ServiceAccountLister(desired.Namespace).Get(desired.Name)
+	switch {
+	case apierrors.IsNotFound(err):
+		_, err := r.ServiceAccountClient(desired.Namespace).Create(ctx, desired, metav1.CreateOptions{})
+		if err != nil {
+			return reconciler.NewEvent(corev1.EventTypeWarning, ReasonFailedAdapterCreate,
+				"Failed to create adapter ServiceAccount %q: %s", desired.Name, err)
+		}
+		event.Normal(ctx, ReasonAdapterCreate, "Created adapter ServiceAccount %q", desired.Name)
+
+	case err != nil:
+		return fmt.Errorf("failed to get adapter ServiceAccount from cache: %w", err)
+	}
+
+	return nil
+}
+
+// reconcileRole ensures the given Role exists and is up-to-date.
+func (r *GenericConfigMapReconciler) reconcileRole(ctx context.Context, desired *rbacv1.Role) error {
+	current, err := r.RoleLister(desired.Namespace).Get(desired.Name)
+	switch {
+	case apierrors.IsNotFound(err):
+		_, err := r.RoleClient(desired.Namespace).Create(ctx, desired, metav1.CreateOptions{})
+		if err != nil {
+			return reconciler.NewEvent(corev1.EventTypeWarning, ReasonFailedAdapterCreate,
+				"Failed to create adapter Role %q: %s", desired.Name, err)
+		}
+		event.Normal(ctx, ReasonAdapterCreate, "Created adapter Role %q", desired.Name)
+
+	case err != nil:
+		return fmt.Errorf("failed to get adapter Role from cache: %w", err)
+
+	case !equality.Semantic.DeepEqual(desired.Rules, current.Rules):
+		// copy before mutating the object from the informer's cache
+		current = current.DeepCopy()
+		current.Rules = desired.Rules
+
+		if _, err := r.RoleClient(desired.Namespace).Update(ctx, current, metav1.UpdateOptions{}); err != nil {
+			return reconciler.NewEvent(corev1.EventTypeWarning, ReasonFailedAdapterUpdate,
+				"Failed to update adapter Role %q: %s", desired.Name, err)
+		}
+		event.Normal(ctx, ReasonAdapterUpdate, "Updated adapter Role %q", desired.Name)
+	}
+
+	return nil
+}
+
+// reconcileRoleBinding ensures the given RoleBinding exists and is
+// up-to-date.
+func (r *GenericConfigMapReconciler) reconcileRoleBinding(ctx context.Context, desired *rbacv1.RoleBinding) error {
+	current, err := r.RoleBindingLister(desired.Namespace).Get(desired.Name)
+	switch {
+	case apierrors.IsNotFound(err):
+		_, err := r.RoleBindingClient(desired.Namespace).Create(ctx, desired, metav1.CreateOptions{})
+		if err != nil {
+			return reconciler.NewEvent(corev1.EventTypeWarning, ReasonFailedAdapterCreate,
+				"Failed to create adapter RoleBinding %q: %s", desired.Name, err)
+		}
+		event.Normal(ctx, ReasonAdapterCreate, "Created adapter RoleBinding %q", desired.Name)
+
+	case err != nil:
+		return fmt.Errorf("failed to get adapter RoleBinding from cache: %w", err)
+
+	// the roleRef of a RoleBinding is immutable and always refers to
+	// the same Role
+	case !equality.Semantic.DeepEqual(desired.Subjects, current.Subjects):
+		// copy before mutating the object from the informer's cache
+		current = current.DeepCopy()
+		current.Subjects = desired.Subjects
+
+		if _, err := r.RoleBindingClient(desired.Namespace).Update(ctx, current, metav1.UpdateOptions{}); err != nil {
+			return reconciler.NewEvent(corev1.EventTypeWarning, ReasonFailedAdapterUpdate,
+				"Failed to update adapter RoleBinding %q: %s", desired.Name, err)
+		}
+		event.Normal(ctx, ReasonAdapterUpdate, "Updated adapter RoleBinding %q", desired.Name)
+	}
+
+	return nil
+}
+
+// newAdapterServiceAccount returns the ServiceAccount of the adapter of the
+// given source.
+func newAdapterServiceAccount(src v1alpha1.EventSource) *corev1.ServiceAccount {
+	return &corev1.ServiceAccount{
+		ObjectMeta: adapterObjectMeta(src, AdapterServiceAccountName(src)),
+	}
+}
+
+// newAdapterConfigMapRole returns a Role which allows the given verbs on the
+// ConfigMap with the given name.
+func newAdapterConfigMapRole(src v1alpha1.EventSource, cmName string, verbs []string) *rbacv1.Role {
+	return &rbacv1.Role{
+		ObjectMeta: adapterObjectMeta(src, AdapterServiceAccountName(src)),
+		Rules: []rbacv1.PolicyRule{{
+			APIGroups:     []string{corev1.GroupName},
+			Resources:     []string{"configmaps"},
+			ResourceNames: []string{cmName},
+			Verbs:         verbs,
+		}},
+	}
+}
+
+// newAdapterRoleBinding returns a RoleBinding which binds the Role of the
+// adapter of the given source to the adapter's ServiceAccount.
+func newAdapterRoleBinding(src v1alpha1.EventSource) *rbacv1.RoleBinding {
+	name := AdapterServiceAccountName(src)
+
+	return &rbacv1.RoleBinding{
+		ObjectMeta: adapterObjectMeta(src, name),
+		RoleRef: rbacv1.RoleRef{
+			APIGroup: rbacv1.GroupName,
+			Kind:     "Role",
+			Name:     name,
+		},
+		Subjects: []rbacv1.Subject{{
+			Kind:      rbacv1.ServiceAccountKind,
+			Namespace: src.GetNamespace(),
+			Name:      name,
+		}},
+	}
+}
+
+// adapterObjectMeta returns the metadata of an object with the given name,
+// which is owned by the given source and used by its adapter.
+func adapterObjectMeta(src v1alpha1.EventSource, name string) metav1.ObjectMeta {
+	return metav1.ObjectMeta{
+		Namespace: src.GetNamespace(),
+		Name:      name,
+		Labels: map[string]string{
+			AppNameLabel:      AdapterName(src),
+			AppInstanceLabel:  src.GetName(),
+			AppPartOfLabel:    PartOf,
+			AppManagedByLabel: ManagedBy,
+		},
+		OwnerReferences: []metav1.OwnerReference{
+			*kmeta.NewControllerRef(src),
+		},
+	}
+}
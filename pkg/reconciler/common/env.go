@@ -23,4 +23,8 @@ const (
 	EnvSink      = "K_SINK"
 
 	EnvMetricsPrometheusPort = "METRICS_PROMETHEUS_PORT"
+
+	EnvBufferDir      = "BUFFER_DIR"
+	EnvBufferMaxBytes = "BUFFER_MAX_BYTES"
+	EnvBufferMaxAge   = "BUFFER_MAX_AGE"
 )
@@ -26,6 +26,10 @@ const (
 	ReasonFailedAdapterCreate = "FailedAdapterCreate"
 	// ReasonFailedAdapterUpdate indicates that the update of an adapter object failed.
 	ReasonFailedAdapterUpdate = "FailedAdapterUpdate"
+	// ReasonAdapterDelete indicates that an adapter object was successfully deleted.
+	ReasonAdapterDelete = "DeleteAdapter"
+	// ReasonFailedAdapterDelete indicates that the deletion of an adapter object failed.
+	ReasonFailedAdapterDelete = "FailedAdapterDelete"
 
 	// ReasonBadSinkURI indicates that the URI of a sink can't be determined.
 	ReasonBadSinkURI = "BadSinkURI"
@@ -26,9 +26,12 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/intstr"
 
 	"knative.dev/pkg/apis"
 	"knative.dev/pkg/controller"
+	"knative.dev/pkg/kmeta"
+	"knative.dev/pkg/network"
 	"knative.dev/pkg/reconciler"
 	"knative.dev/serving/pkg/apis/serving"
 	servingv1 "knative.dev/serving/pkg/apis/serving/v1"
@@ -131,6 +134,27 @@ func (r *GenericDeploymentReconciler) FindAdapter(src v1alpha1.EventSource) (*ap
 	return o.(*appsv1.Deployment), nil
 }
 
+// DeleteAdapter deletes the adapter Deployment of the given source, if it
+// exists.
+func (r *GenericDeploymentReconciler) DeleteAdapter(ctx context.Context, src v1alpha1.EventSource) error {
+	adapter, err := r.FindAdapter(src)
+	switch {
+	case apierrors.IsNotFound(err):
+		return nil
+	case err != nil:
+		return fmt.Errorf("failed to get adapter Deployment from cache: %w", err)
+	}
+
+	err = r.Client(adapter.Namespace).Delete(ctx, adapter.Name, metav1.DeleteOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return reconciler.NewEvent(corev1.EventTypeWarning, ReasonFailedAdapterDelete,
+			"Failed to delete adapter Deployment %q: %s", adapter.Name, err)
+	}
+	event.Normal(ctx, ReasonAdapterDelete, "Deleted adapter Deployment %q", adapter.Name)
+
+	return nil
+}
+
 // syncAdapterDeployment synchronizes the desired state of an adapter Deployment
 // against its current state in the running cluster.
 func (r *GenericDeploymentReconciler) syncAdapterDeployment(ctx context.Context,
@@ -157,6 +181,159 @@ func (r *GenericDeploymentReconciler) syncAdapterDeployment(ctx context.Context,
 	return adapter, nil
 }
 
+// ReconcileAddressableSource reconciles an event source type whose adapter
+// runs as a Deployment exposed by a Kubernetes Service. The cluster-local URL
+// of the Service is reported as the address of the source, unless a public
+// URL is given.
+func (r *GenericAddressableDeploymentReconciler) ReconcileAddressableSource(ctx context.Context,
+	adb AdapterDeploymentBuilderFunc, publicURL *apis.URL) reconciler.Event {
+
+	if err := r.ReconcileSource(ctx, adb); err != nil {
+		return err
+	}
+
+	src := v1alpha1.SourceFromContext(ctx)
+
+	svc, err := r.reconcileAdapterK8sService(ctx, NewAdapterK8sService(src))
+	if err != nil {
+		return fmt.Errorf("failed to reconcile adapter: %w", err)
+	}
+
+	if publicURL == nil {
+		publicURL = &apis.URL{
+			Scheme: "http",
+			Host:   network.GetServiceHostname(svc.Name, svc.Namespace),
+		}
+	}
+	src.GetStatusManager().SetAddress(publicURL)
+
+	return nil
+}
+
+// reconcileAdapterK8sService reconciles the state of the Kubernetes Service
+// which exposes the source's adapter.
+func (r *GenericAddressableDeploymentReconciler) reconcileAdapterK8sService(ctx context.Context,
+	desiredSvc *corev1.Service) (*corev1.Service, error) {
+
+	src := v1alpha1.SourceFromContext(ctx)
+
+	currentSvc, err := r.FindAdapterK8sService(src)
+	switch {
+	case apierrors.IsNotFound(err):
+		svc, err := r.ServiceClient(src.GetNamespace()).Create(ctx, desiredSvc, metav1.CreateOptions{})
+		if err != nil {
+			return nil, reconciler.NewEvent(corev1.EventTypeWarning, ReasonFailedAdapterCreate,
+				"Failed to create adapter Kubernetes Service %q: %s", desiredSvc.Name, err)
+		}
+		event.Normal(ctx, ReasonAdapterCreate, "Created adapter Kubernetes Service %q", svc.Name)
+		return svc, nil
+
+	case err != nil:
+		return nil, fmt.Errorf("failed to get adapter Kubernetes Service from cache: %w", err)
+
+	case semantic.Semantic.DeepEqual(desiredSvc, currentSvc):
+		return currentSvc, nil
+	}
+
+	// the cluster IP and other attributes set by the API server are
+	// immutable, so only the attributes we own are overwritten
+	svc := currentSvc.DeepCopy()
+	svc.Labels = desiredSvc.Labels
+	svc.OwnerReferences = desiredSvc.OwnerReferences
+	svc.Spec.Selector = desiredSvc.Spec.Selector
+	svc.Spec.Ports = desiredSvc.Spec.Ports
+
+	svc, err = r.ServiceClient(svc.Namespace).Update(ctx, svc, metav1.UpdateOptions{})
+	if err != nil {
+		return nil, reconciler.NewEvent(corev1.EventTypeWarning, ReasonFailedAdapterUpdate,
+			"Failed to update adapter Kubernetes Service %q: %s", desiredSvc.Name, err)
+	}
+	event.Normal(ctx, ReasonAdapterUpdate, "Updated adapter Kubernetes Service %q", svc.Name)
+
+	return svc, nil
+}
+
+// FindAdapterK8sService returns the Kubernetes Service which exposes the
+// adapter of a given source if it exists.
+func (r *GenericAddressableDeploymentReconciler) FindAdapterK8sService(src v1alpha1.EventSource) (*corev1.Service, error) {
+	o, err := findAdapter(r, src)
+	if err != nil {
+		return nil, err
+	}
+	return o.(*corev1.Service), nil
+}
+
+// DeleteAdapter deletes the adapter Deployment of the given source and the
+// Kubernetes Service which exposes it, if they exist.
+func (r *GenericAddressableDeploymentReconciler) DeleteAdapter(ctx context.Context, src v1alpha1.EventSource) error {
+	if err := r.DeleteAdapterK8sService(ctx, src); err != nil {
+		return err
+	}
+	return r.GenericDeploymentReconciler.DeleteAdapter(ctx, src)
+}
+
+// DeleteAdapterK8sService deletes the Kubernetes Service which exposes the
+// adapter of the given source, if it exists.
+func (r *GenericAddressableDeploymentReconciler) DeleteAdapterK8sService(ctx context.Context,
+	src v1alpha1.EventSource) error {
+
+	svc, err := r.FindAdapterK8sService(src)
+	switch {
+	case apierrors.IsNotFound(err):
+		return nil
+	case err != nil:
+		return fmt.Errorf("failed to get adapter Kubernetes Service from cache: %w", err)
+	}
+
+	err = r.ServiceClient(svc.Namespace).Delete(ctx, svc.Name, metav1.DeleteOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return reconciler.NewEvent(corev1.EventTypeWarning, ReasonFailedAdapterDelete,
+			"Failed to delete adapter Kubernetes Service %q: %s", svc.Name, err)
+	}
+	event.Normal(ctx, ReasonAdapterDelete, "Deleted adapter Kubernetes Service %q", svc.Name)
+
+	return nil
+}
+
+// adapterHTTPPort is the port on which adapters which receive events over
+// HTTP listen by default.
+const adapterHTTPPort = 8080
+
+// NewAdapterK8sService returns the Kubernetes Service which exposes the
+// adapter Deployment of the given source.
+func NewAdapterK8sService(src v1alpha1.EventSource) *corev1.Service {
+	adapterName := AdapterName(src)
+
+	return &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: src.GetNamespace(),
+			Name:      kmeta.ChildName(adapterName+"-", src.GetName()),
+			Labels: map[string]string{
+				AppNameLabel:      adapterName,
+				AppInstanceLabel:  src.GetName(),
+				AppComponentLabel: AdapterComponent,
+				AppPartOfLabel:    PartOf,
+				AppManagedByLabel: ManagedBy,
+			},
+			OwnerReferences: []metav1.OwnerReference{
+				*kmeta.NewControllerRef(src),
+			},
+		},
+		Spec: corev1.ServiceSpec{
+			// same selector as the adapter Deployment
+			Selector: map[string]string{
+				AppNameLabel:     adapterName,
+				AppInstanceLabel: src.GetName(),
+			},
+			Ports: []corev1.ServicePort{{
+				Name:       "http",
+				Port:       80,
+				TargetPort: intstr.FromInt(adapterHTTPPort),
+			}},
+		},
+	}
+}
+
 // AdapterServiceBuilderFunc builds a Service object for a source's adapter.
 type AdapterServiceBuilderFunc func(sinkURI *apis.URL) *servingv1.Service
 
@@ -243,6 +420,27 @@ func (r *GenericServiceReconciler) FindAdapter(src v1alpha1.EventSource) (*servi
 	return o.(*servingv1.Service), nil
 }
 
+// DeleteAdapter deletes the adapter Service of the given source, if it
+// exists.
+func (r *GenericServiceReconciler) DeleteAdapter(ctx context.Context, src v1alpha1.EventSource) error {
+	adapter, err := r.FindAdapter(src)
+	switch {
+	case apierrors.IsNotFound(err):
+		return nil
+	case err != nil:
+		return fmt.Errorf("failed to get adapter Service from cache: %w", err)
+	}
+
+	err = r.Client(adapter.Namespace).Delete(ctx, adapter.Name, metav1.DeleteOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return reconciler.NewEvent(corev1.EventTypeWarning, ReasonFailedAdapterDelete,
+			"Failed to delete adapter Service %q: %s", adapter.Name, err)
+	}
+	event.Normal(ctx, ReasonAdapterDelete, "Deleted adapter Service %q", adapter.Name)
+
+	return nil
+}
+
 // syncAdapterService synchronizes the desired state of an adapter Service
 // against its current state in the running cluster.
 func (r *GenericServiceReconciler) syncAdapterService(ctx context.Context,
@@ -312,6 +510,18 @@ func findAdapter(genericReconciler interface{}, src v1alpha1.EventSource) (metav
 		}
 
 		gr = servingv1.Resource("service")
+
+	case *GenericAddressableDeploymentReconciler:
+		svcs, err := r.ServiceLister(src.GetNamespace()).List(sel)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, s := range svcs {
+			objs = append(objs, s)
+		}
+
+		gr = corev1.Resource("service")
 	}
 
 	for _, obj := range objs {
@@ -135,6 +135,25 @@ func setEnvVar(envVars *[]corev1.EnvVar, name, value string, valueFrom *corev1.E
 	})
 }
 
+// VolumeMount mounts a volume of the Pod in a Container at the given path.
+func VolumeMount(name, path string) ObjectOption {
+	return func(object interface{}) {
+		var mounts *[]corev1.VolumeMount
+
+		switch o := object.(type) {
+		case *corev1.Container:
+			mounts = &o.VolumeMounts
+		case *appsv1.Deployment, *servingv1.Service:
+			mounts = &firstContainer(o).VolumeMounts
+		}
+
+		*mounts = append(*mounts, corev1.VolumeMount{
+			Name:      name,
+			MountPath: path,
+		})
+	}
+}
+
 // Probe sets the HTTP readiness probe of a Deployment's first container.
 func Probe(path, port string) ObjectOption {
 	return func(object interface{}) {
@@ -60,3 +60,16 @@ func Selector(key, val string) ObjectOption {
 		PodLabel(key, val)(d)
 	}
 }
+
+// RecreateStrategy makes a Deployment terminate its existing Pods before
+// creating new ones, e.g. to prevent two Pods from sharing a volume during
+// rollouts.
+func RecreateStrategy() ObjectOption {
+	return func(object interface{}) {
+		d := object.(*appsv1.Deployment)
+
+		d.Spec.Strategy = appsv1.DeploymentStrategy{
+			Type: appsv1.RecreateDeploymentStrategyType,
+		}
+	}
+}
@@ -42,6 +42,12 @@ func TestNewDeploymentWithDefaultContainer(t *testing.T) {
 		EnvVars(makeEnvVars(2, "MULTI_ENV", "val")...),
 		EnvVar("TEST_ENV2", "val2"),
 		Label("test.label/2", "val2"),
+		ServiceAccount("test-sa"),
+		Volume("test-vol", corev1.VolumeSource{
+			PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: "test-pvc"},
+		}),
+		VolumeMount("test-vol", "/test/path"),
+		RecreateStrategy(),
 	)
 
 	expectKsvc := &appsv1.Deployment{
@@ -60,6 +66,9 @@ func TestNewDeploymentWithDefaultContainer(t *testing.T) {
 					"test.selector/2": "val2",
 				},
 			},
+			Strategy: appsv1.DeploymentStrategy{
+				Type: appsv1.RecreateDeploymentStrategyType,
+			},
 			Template: corev1.PodTemplateSpec{
 				ObjectMeta: metav1.ObjectMeta{
 					Labels: map[string]string{
@@ -70,6 +79,13 @@ func TestNewDeploymentWithDefaultContainer(t *testing.T) {
 					},
 				},
 				Spec: corev1.PodSpec{
+					ServiceAccountName: "test-sa",
+					Volumes: []corev1.Volume{{
+						Name: "test-vol",
+						VolumeSource: corev1.VolumeSource{
+							PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: "test-pvc"},
+						},
+					}},
 					Containers: []corev1.Container{{
 						Name:  defaultContainerName,
 						Image: tImg,
@@ -93,6 +109,10 @@ func TestNewDeploymentWithDefaultContainer(t *testing.T) {
 							Name:  "TEST_ENV2",
 							Value: "val2",
 						}},
+						VolumeMounts: []corev1.VolumeMount{{
+							Name:      "test-vol",
+							MountPath: "/test/path",
+						}},
 						ReadinessProbe: &corev1.Probe{
 							Handler: corev1.Handler{
 								HTTPGet: &corev1.HTTPGetAction{
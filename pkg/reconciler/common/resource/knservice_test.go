@@ -40,6 +40,7 @@ func TestNewServiceWithDefaultContainer(t *testing.T) {
 		EnvVars(makeEnvVars(2, "MULTI_ENV", "val")...),
 		EnvVar("TEST_ENV2", "val2"),
 		Label("test.label/2", "val2"),
+		ServiceAccount("test-sa"),
 	)
 
 	expectKsvc := &servingv1.Service{
@@ -62,6 +63,7 @@ func TestNewServiceWithDefaultContainer(t *testing.T) {
 					},
 					Spec: servingv1.RevisionSpec{
 						PodSpec: corev1.PodSpec{
+							ServiceAccountName: "test-sa",
 							Containers: []corev1.Container{{
 								Name:  defaultContainerName,
 								Image: tImg,
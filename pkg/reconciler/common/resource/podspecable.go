@@ -53,3 +53,34 @@ func Container(c *corev1.Container) ObjectOption {
 		}
 	}
 }
+
+// ServiceAccount sets the ServiceAccount of a PodSpecable's Pod template.
+func ServiceAccount(name string) ObjectOption {
+	return func(object interface{}) {
+		switch o := object.(type) {
+		case *appsv1.Deployment:
+			o.Spec.Template.Spec.ServiceAccountName = name
+		case *servingv1.Service:
+			o.Spec.Template.Spec.ServiceAccountName = name
+		}
+	}
+}
+
+// Volume adds a volume to a PodSpecable's Pod template.
+func Volume(name string, src corev1.VolumeSource) ObjectOption {
+	return func(object interface{}) {
+		var volumes *[]corev1.Volume
+
+		switch o := object.(type) {
+		case *appsv1.Deployment:
+			volumes = &o.Spec.Template.Spec.Volumes
+		case *servingv1.Service:
+			volumes = &o.Spec.Template.Spec.Volumes
+		}
+
+		*volumes = append(*volumes, corev1.Volume{
+			Name:         name,
+			VolumeSource: src,
+		})
+	}
+}
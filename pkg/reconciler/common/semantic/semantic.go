@@ -18,6 +18,7 @@ package semantic
 
 import (
 	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/conversion"
@@ -29,13 +30,15 @@ import (
 //
 // For a given comparison function
 //
-//   comp(a, b interface{})
+//	comp(a, b interface{})
 //
 // 'a' should always be the desired state, and 'b' the current state for
 // DeepDerivative comparisons to work as expected.
 var Semantic = conversion.EqualitiesOrDie(
 	deploymentEqual,
 	knServiceEqual,
+	configMapEqual,
+	serviceEqual,
 )
 
 // eq is an instance of Equalities for internal deep derivative comparisons
@@ -100,3 +103,43 @@ func knServiceEqual(a, b *servingv1.Service) bool {
 
 	return true
 }
+
+// configMapEqual returns whether two ConfigMaps are semantically equivalent.
+func configMapEqual(a, b *corev1.ConfigMap) bool {
+	if a == b {
+		return true
+	}
+	if a == nil || b == nil {
+		return false
+	}
+
+	if !eq.DeepDerivative(&a.ObjectMeta, &b.ObjectMeta) {
+		return false
+	}
+
+	if !eq.DeepDerivative(a.Data, b.Data) {
+		return false
+	}
+
+	return true
+}
+
+// serviceEqual returns whether two Kubernetes Services are semantically equivalent.
+func serviceEqual(a, b *corev1.Service) bool {
+	if a == b {
+		return true
+	}
+	if a == nil || b == nil {
+		return false
+	}
+
+	if !eq.DeepDerivative(&a.ObjectMeta, &b.ObjectMeta) {
+		return false
+	}
+
+	if !eq.DeepDerivative(&a.Spec, &b.Spec) {
+		return false
+	}
+
+	return true
+}
@@ -24,6 +24,8 @@ import (
 	"github.com/stretchr/testify/assert"
 
 	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 
 	servingv1 "knative.dev/serving/pkg/apis/serving/v1"
@@ -184,6 +186,83 @@ func TestKnServiceEqual(t *testing.T) {
 	}
 }
 
+func TestConfigMapEqual(t *testing.T) {
+	current := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:       "test",
+			Name:            "test",
+			ResourceVersion: "1",
+			Labels: map[string]string{
+				"app.kubernetes.io/name":     "test",
+				"app.kubernetes.io/instance": "test",
+			},
+		},
+		Data: map[string]string{
+			"key1": "value1",
+			"key2": "value2",
+		},
+	}
+
+	assert.True(t, configMapEqual(nil, nil), "Two nil elements should be equal")
+
+	testCases := map[string]struct {
+		prep   func() *corev1.ConfigMap
+		expect bool
+	}{
+		"not equal when one element is nil": {
+			func() *corev1.ConfigMap {
+				return nil
+			},
+			false,
+		},
+		"equal when current has more metadata than desired": {
+			func() *corev1.ConfigMap {
+				desired := current.DeepCopy()
+				desired.ResourceVersion = ""
+				return desired
+			},
+			true,
+		},
+		"not equal when some existing label differs": {
+			func() *corev1.ConfigMap {
+				desired := current.DeepCopy()
+				desired.Labels["app.kubernetes.io/name"] += "test"
+				return desired
+			},
+			false,
+		},
+		"not equal when some data differs": {
+			func() *corev1.ConfigMap {
+				desired := current.DeepCopy()
+				desired.Data["key1"] += "test"
+				return desired
+			},
+			false,
+		},
+		"not equal when desired has more data than current": {
+			func() *corev1.ConfigMap {
+				desired := current.DeepCopy()
+				desired.Data["key3"] = "value3"
+				return desired
+			},
+			false,
+		},
+	}
+
+	for name, tc := range testCases {
+		//nolint:scopelint
+		t.Run(name, func(t *testing.T) {
+			desired := tc.prep()
+			switch tc.expect {
+			case true:
+				assert.True(t, configMapEqual(desired, current))
+			case false:
+				assert.False(t, configMapEqual(desired, current))
+			}
+		})
+	}
+}
+
 func loadFixture(t *testing.T, file string, obj runtime.Object) {
 	t.Helper()
 
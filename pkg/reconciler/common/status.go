@@ -16,7 +16,14 @@ limitations under the License.
 
 package common
 
-import duckv1 "knative.dev/pkg/apis/duck/v1"
+import (
+	corev1 "k8s.io/api/core/v1"
+	duckv1 "knative.dev/pkg/apis/duck/v1"
+	"knative.dev/pkg/controller"
+	"knative.dev/pkg/reconciler"
+
+	"github.com/triggermesh/knative-sources/pkg/apis/sources/v1alpha1"
+)
 
 // CreateCloudEventAttributes returns CloudEvent attributes for the event types
 // supported by the source.
@@ -32,3 +39,12 @@ func CreateCloudEventAttributes(source string, eventTypes []string) []duckv1.Clo
 
 	return ceAttributes
 }
+
+// InvalidSpec marks the given source as not deployed because of an invalid
+// spec, and returns a permanent error which is reported as an InvalidSpec
+// event with the given message.
+func InvalidSpec(src v1alpha1.EventSource, msgFmt string, args ...interface{}) error {
+	src.GetStatusManager().MarkInvalidSpec(msgFmt, args...)
+	return controller.NewPermanentError(reconciler.NewEvent(corev1.EventTypeWarning,
+		ReasonInvalidSpec, msgFmt, args...))
+}
@@ -19,6 +19,7 @@ package httpsource
 import (
 	"strconv"
 
+	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 
 	"knative.dev/eventing/pkg/reconciler/source"
@@ -28,6 +29,7 @@ import (
 
 	"github.com/triggermesh/knative-sources/pkg/apis/sources/v1alpha1"
 	"github.com/triggermesh/knative-sources/pkg/reconciler/common"
+	"github.com/triggermesh/knative-sources/pkg/reconciler/common/buffer"
 	"github.com/triggermesh/knative-sources/pkg/reconciler/common/resource"
 )
 
@@ -90,6 +92,49 @@ func adapterServiceBuilder(src *v1alpha1.HTTPSource, cfg *adapterConfig) common.
 	}
 }
 
+// adapterDeploymentBuilder returns an AdapterDeploymentBuilderFunc for the
+// given source object and adapter config. It is used when events are
+// buffered on a persistent volume, which Knative Services can't mount.
+func adapterDeploymentBuilder(src *v1alpha1.HTTPSource, cfg *adapterConfig) common.AdapterDeploymentBuilderFunc {
+	adapterName := common.AdapterName(src)
+
+	return func(sinkURI *apis.URL) *appsv1.Deployment {
+		name := kmeta.ChildName(adapterName+"-", src.Name)
+
+		var sinkURIStr string
+		if sinkURI != nil {
+			sinkURIStr = sinkURI.String()
+		}
+
+		return resource.NewDeployment(src.Namespace, name,
+			resource.Controller(src),
+
+			resource.Label(common.AppNameLabel, adapterName),
+			resource.Label(common.AppInstanceLabel, src.Name),
+			resource.Label(common.AppComponentLabel, common.AdapterComponent),
+			resource.Label(common.AppPartOfLabel, common.PartOf),
+			resource.Label(common.AppManagedByLabel, common.ManagedBy),
+
+			resource.Selector(common.AppNameLabel, adapterName),
+			resource.Selector(common.AppInstanceLabel, src.Name),
+			resource.PodLabel(common.AppComponentLabel, common.AdapterComponent),
+			resource.PodLabel(common.AppPartOfLabel, common.PartOf),
+			resource.PodLabel(common.AppManagedByLabel, common.ManagedBy),
+
+			resource.Image(cfg.Image),
+
+			resource.EnvVar(common.EnvName, src.Name),
+			resource.EnvVar(common.EnvNamespace, src.Namespace),
+			resource.EnvVar(common.EnvSink, sinkURIStr),
+			resource.EnvVars(makeHTTPEnvs(src)...),
+			resource.EnvVar(common.EnvMetricsPrometheusPort, strconv.Itoa(int(metricsPrometheusPort))),
+			resource.EnvVars(cfg.configs.ToEnvVars()...),
+
+			buffer.AdapterOption(src.Spec.Buffer),
+		)
+	}
+}
+
 func makeHTTPEnvs(src *v1alpha1.HTTPSource) []corev1.EnvVar {
 	envs := []corev1.EnvVar{{
 		Name:  envHTTPEventType,
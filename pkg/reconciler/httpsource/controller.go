@@ -42,7 +42,7 @@ func NewController(
 	app := common.AdapterName(typ)
 
 	adapterCfg := &adapterConfig{
-		configs: source.WatchConfigurations(ctx, app, cmw, source.WithLogging, source.WithMetrics),
+		configs: source.WatchConfigurations(ctx, app, cmw, source.WithLogging, source.WithMetrics, source.WithTracing),
 	}
 	envconfig.MustProcess(app, adapterCfg)
 
@@ -58,6 +58,13 @@ func NewController(
 		impl.EnqueueControllerOf,
 	)
 
+	r.deploymentBase = common.NewGenericAddressableDeploymentReconciler(
+		ctx,
+		typ.GetGroupVersionKind(),
+		impl.EnqueueKey,
+		impl.EnqueueControllerOf,
+	)
+
 	informerv1alpha1.Get(ctx).Informer().AddEventHandler(controller.HandleAll(impl.Enqueue))
 
 	return impl
@@ -28,8 +28,9 @@ import (
 
 // Reconciler implements controller.Reconciler for the event source type.
 type Reconciler struct {
-	base       common.GenericServiceReconciler
-	adapterCfg *adapterConfig
+	base           common.GenericServiceReconciler
+	deploymentBase common.GenericAddressableDeploymentReconciler
+	adapterCfg     *adapterConfig
 }
 
 // Check that our Reconciler implements Interface
@@ -40,5 +41,19 @@ func (r *Reconciler) ReconcileKind(ctx context.Context, src *v1alpha1.HTTPSource
 	// inject source into context for usage in reconciliation logic
 	ctx = v1alpha1.WithSource(ctx, src)
 
+	// the adapter is deployed as a Knative Service, unless it buffers
+	// events on a persistent volume. Switching between modes requires
+	// removing the adapter of the other kind.
+	if buf := src.Spec.Buffer; buf != nil {
+		if err := r.base.DeleteAdapter(ctx, src); err != nil {
+			return err
+		}
+		return r.deploymentBase.ReconcileAddressableSource(ctx, adapterDeploymentBuilder(src, r.adapterCfg),
+			buf.PublicURL)
+	}
+
+	if err := r.deploymentBase.DeleteAdapter(ctx, src); err != nil {
+		return err
+	}
 	return r.base.ReconcileSource(ctx, adapterServiceBuilder(src, r.adapterCfg))
 }
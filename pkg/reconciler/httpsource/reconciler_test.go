@@ -23,6 +23,7 @@ import (
 	"k8s.io/apimachinery/pkg/types"
 
 	"knative.dev/eventing/pkg/reconciler/source"
+	fakek8sinjectionclient "knative.dev/pkg/client/injection/kube/client/fake"
 	"knative.dev/pkg/controller"
 	"knative.dev/pkg/logging"
 	"knative.dev/pkg/resolver"
@@ -50,6 +51,25 @@ func TestReconcileSource(t *testing.T) {
 	TestReconcile(t, ctor, src, adapterFn)
 }
 
+func TestReconcileSourceBuffer(t *testing.T) {
+	adapterCfg := &adapterConfig{
+		Image:   "registry/image:tag",
+		configs: &source.EmptyVarsGenerator{},
+	}
+
+	src := newEventSource()
+	src.Spec.Buffer = &v1alpha1.EventBuffer{
+		PersistentVolumeClaimName: "buffer",
+	}
+
+	var (
+		ctor      = reconcilerCtor(adapterCfg)
+		adapterFn = adapterDeploymentBuilder(src, adapterCfg)
+	)
+
+	TestReconcileAddressableDeployment(t, ctor, src, adapterFn, nil)
+}
+
 // reconcilerCtor returns a Ctor for a Source Reconciler.
 func reconcilerCtor(cfg *adapterConfig) Ctor {
 	return func(t *testing.T, ctx context.Context, ls *Listers) controller.Reconciler {
@@ -59,9 +79,21 @@ func reconcilerCtor(cfg *adapterConfig) Ctor {
 			Client:       fakeservinginjectionclient.Get(ctx).ServingV1().Services,
 		}
 
+		deploymentBase := common.GenericAddressableDeploymentReconciler{
+			GenericDeploymentReconciler: common.GenericDeploymentReconciler{
+				SinkResolver: resolver.NewURIResolver(ctx, func(types.NamespacedName) {}),
+				Lister:       ls.GetDeploymentLister().Deployments,
+				Client:       fakek8sinjectionclient.Get(ctx).AppsV1().Deployments,
+				PodClient:    fakek8sinjectionclient.Get(ctx).CoreV1().Pods,
+			},
+			ServiceLister: ls.GetK8sServiceLister().Services,
+			ServiceClient: fakek8sinjectionclient.Get(ctx).CoreV1().Services,
+		}
+
 		r := &Reconciler{
-			base:       base,
-			adapterCfg: cfg,
+			base:           base,
+			deploymentBase: deploymentBase,
+			adapterCfg:     cfg,
 		}
 
 		return reconcilerv1alpha1.NewReconciler(ctx, logging.FromContext(ctx),
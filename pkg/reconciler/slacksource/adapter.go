@@ -17,8 +17,10 @@ limitations under the License.
 package slacksource
 
 import (
+	"encoding/json"
 	"strconv"
 
+	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 
 	"knative.dev/eventing/pkg/reconciler/source"
@@ -28,12 +30,30 @@ import (
 
 	"github.com/triggermesh/knative-sources/pkg/apis/sources/v1alpha1"
 	"github.com/triggermesh/knative-sources/pkg/reconciler/common"
+	"github.com/triggermesh/knative-sources/pkg/reconciler/common/buffer"
 	"github.com/triggermesh/knative-sources/pkg/reconciler/common/resource"
 )
 
 const (
 	envSlackAppID         = "SLACK_APP_ID"
 	envSlackSigningSecret = "SLACK_SIGNING_SECRET"
+	envSlackApps          = "SLACK_APPS"
+	envSlackEventFilter   = "SLACK_EVENT_FILTER"
+	envSlackBotToken      = "SLACK_BOT_TOKEN"
+	envSlackAppToken      = "SLACK_APP_TOKEN"
+
+	envSlackDeadLetterSink = "SLACK_DEAD_LETTER_SINK"
+
+	envSlackAppStatusConfigMap = "SLACK_APP_STATUS_CONFIGMAP"
+
+	envSlackReplies = "SLACK_REPLIES_ENABLED"
+
+	envSlackEnrichment         = "SLACK_ENRICHMENT_ENABLED"
+	envSlackEnrichmentCacheTTL = "SLACK_ENRICHMENT_CACHE_TTL"
+
+	// the signing secret of each Slack app is exposed to the adapter
+	// via an env var named after the app's ID
+	envSlackAppSigningSecretPrefix = envSlackSigningSecret + "_"
 )
 
 const metricsPrometheusPort uint16 = 9092
@@ -49,8 +69,8 @@ type adapterConfig struct {
 }
 
 // adapterServiceBuilder returns an AdapterServiceBuilderFunc for the
-// given source object and adapter config.
-func adapterServiceBuilder(src *v1alpha1.SlackSource, cfg *adapterConfig) common.AdapterServiceBuilderFunc {
+// given source object, adapter config and resolved auxiliary sinks.
+func adapterServiceBuilder(src *v1alpha1.SlackSource, cfg *adapterConfig, sinks auxSinks) common.AdapterServiceBuilderFunc {
 	adapterName := common.AdapterName(src)
 
 	return func(sinkURI *apis.URL) *servingv1.Service {
@@ -77,18 +97,65 @@ func adapterServiceBuilder(src *v1alpha1.SlackSource, cfg *adapterConfig) common
 			resource.PodLabel(common.AppManagedByLabel, common.ManagedBy),
 
 			resource.Image(cfg.Image),
+			resource.ServiceAccount(common.AdapterServiceAccountName(src)),
 
 			resource.EnvVar(common.EnvName, src.Name),
 			resource.EnvVar(common.EnvNamespace, src.Namespace),
 			resource.EnvVar(common.EnvSink, sinkURIStr),
-			resource.EnvVars(makeSlackEnvs(src)...),
+			resource.EnvVars(makeSlackEnvs(src, sinks)...),
 			resource.EnvVar(common.EnvMetricsPrometheusPort, strconv.Itoa(int(metricsPrometheusPort))),
 			resource.EnvVars(cfg.configs.ToEnvVars()...),
 		)
 	}
 }
 
-func makeSlackEnvs(src *v1alpha1.SlackSource) []corev1.EnvVar {
+// adapterDeploymentBuilder returns an AdapterDeploymentBuilderFunc for the
+// given source object, adapter config and resolved auxiliary sinks.
+// It is used in Socket Mode, where the adapter doesn't need to be reachable
+// by Slack, and when events are buffered on a persistent volume, which
+// Knative Services can't mount.
+func adapterDeploymentBuilder(src *v1alpha1.SlackSource, cfg *adapterConfig, sinks auxSinks) common.AdapterDeploymentBuilderFunc {
+	adapterName := common.AdapterName(src)
+
+	return func(sinkURI *apis.URL) *appsv1.Deployment {
+		name := kmeta.ChildName(adapterName+"-", src.Name)
+
+		var sinkURIStr string
+		if sinkURI != nil {
+			sinkURIStr = sinkURI.String()
+		}
+
+		return resource.NewDeployment(src.Namespace, name,
+			resource.Controller(src),
+
+			resource.Label(common.AppNameLabel, adapterName),
+			resource.Label(common.AppInstanceLabel, src.Name),
+			resource.Label(common.AppComponentLabel, common.AdapterComponent),
+			resource.Label(common.AppPartOfLabel, common.PartOf),
+			resource.Label(common.AppManagedByLabel, common.ManagedBy),
+
+			resource.Selector(common.AppNameLabel, adapterName),
+			resource.Selector(common.AppInstanceLabel, src.Name),
+			resource.PodLabel(common.AppComponentLabel, common.AdapterComponent),
+			resource.PodLabel(common.AppPartOfLabel, common.PartOf),
+			resource.PodLabel(common.AppManagedByLabel, common.ManagedBy),
+
+			resource.Image(cfg.Image),
+			resource.ServiceAccount(common.AdapterServiceAccountName(src)),
+
+			resource.EnvVar(common.EnvName, src.Name),
+			resource.EnvVar(common.EnvNamespace, src.Namespace),
+			resource.EnvVar(common.EnvSink, sinkURIStr),
+			resource.EnvVars(makeSlackEnvs(src, sinks)...),
+			resource.EnvVar(common.EnvMetricsPrometheusPort, strconv.Itoa(int(metricsPrometheusPort))),
+			resource.EnvVars(cfg.configs.ToEnvVars()...),
+
+			buffer.AdapterOption(src.Spec.Buffer),
+		)
+	}
+}
+
+func makeSlackEnvs(src *v1alpha1.SlackSource, sinks auxSinks) []corev1.EnvVar {
 	var slackEnvs []corev1.EnvVar
 
 	if appID := src.Spec.AppID; appID != nil {
@@ -107,5 +174,114 @@ func makeSlackEnvs(src *v1alpha1.SlackSource) []corev1.EnvVar {
 		})
 	}
 
+	if len(src.Spec.Apps) > 0 {
+		slackEnvs = append(slackEnvs, makeSlackAppsEnvs(src.Spec.Apps, sinks.apps)...)
+	}
+
+	if filter := src.Spec.Filter; filter != nil {
+		// marshaling a struct containing only strings and bools can not fail
+		filterJSON, _ := json.Marshal(filter)
+
+		slackEnvs = append(slackEnvs, corev1.EnvVar{
+			Name:  envSlackEventFilter,
+			Value: string(filterJSON),
+		})
+	}
+
+	if botToken := src.Spec.BotToken; botToken != nil {
+		slackEnvs = append(slackEnvs, corev1.EnvVar{
+			Name: envSlackBotToken,
+			ValueFrom: &corev1.EnvVarSource{
+				SecretKeyRef: botToken.SecretKeyRef,
+			},
+		})
+	}
+
+	if src.Spec.Replies {
+		slackEnvs = append(slackEnvs, corev1.EnvVar{
+			Name:  envSlackReplies,
+			Value: strconv.FormatBool(true),
+		})
+	}
+
+	if socketMode := src.Spec.SocketMode; socketMode != nil {
+		slackEnvs = append(slackEnvs, corev1.EnvVar{
+			Name: envSlackAppToken,
+			ValueFrom: &corev1.EnvVarSource{
+				SecretKeyRef: socketMode.AppToken.SecretKeyRef,
+			},
+		})
+	}
+
+	if sinks.deadLetter != nil {
+		slackEnvs = append(slackEnvs, corev1.EnvVar{
+			Name:  envSlackDeadLetterSink,
+			Value: sinks.deadLetter.String(),
+		})
+	}
+
+	if enrichment := src.Spec.Enrichment; enrichment != nil {
+		slackEnvs = append(slackEnvs, corev1.EnvVar{
+			Name:  envSlackEnrichment,
+			Value: strconv.FormatBool(true),
+		})
+
+		if ttl := enrichment.CacheTTL; ttl != nil {
+			slackEnvs = append(slackEnvs, corev1.EnvVar{
+				Name:  envSlackEnrichmentCacheTTL,
+				Value: ttl.Duration.String(),
+			})
+		}
+	}
+
+	// the adapter reports notifications about the Slack app through this
+	// ConfigMap, see reconcileAppStatus
+	slackEnvs = append(slackEnvs, corev1.EnvVar{
+		Name:  envSlackAppStatusConfigMap,
+		Value: appStatusConfigMapName(src),
+	})
+
 	return slackEnvs
 }
+
+// slackAppConfig is the representation of a Slack app passed to the
+// adapter, serialized to JSON.
+type slackAppConfig struct {
+	AppID      string   `json:"appID"`
+	Sink       string   `json:"sink,omitempty"`
+	EventTypes []string `json:"eventTypes,omitempty"`
+}
+
+func makeSlackAppsEnvs(apps []v1alpha1.SlackApp, appSinks map[string]*apis.URL) []corev1.EnvVar {
+	var appsEnvs []corev1.EnvVar
+
+	appsCfg := make([]slackAppConfig, len(apps))
+
+	for i, app := range apps {
+		appsCfg[i] = slackAppConfig{
+			AppID:      app.AppID,
+			EventTypes: app.EventTypes,
+		}
+
+		if sinkURI := appSinks[app.AppID]; sinkURI != nil {
+			appsCfg[i].Sink = sinkURI.String()
+		}
+
+		if signSecret := app.SigningSecret; signSecret != nil {
+			appsEnvs = append(appsEnvs, corev1.EnvVar{
+				Name: envSlackAppSigningSecretPrefix + app.AppID,
+				ValueFrom: &corev1.EnvVarSource{
+					SecretKeyRef: signSecret.SecretKeyRef,
+				},
+			})
+		}
+	}
+
+	// marshaling a slice of structs containing only strings can not fail
+	appsJSON, _ := json.Marshal(appsCfg)
+
+	return append(appsEnvs, corev1.EnvVar{
+		Name:  envSlackApps,
+		Value: string(appsJSON),
+	})
+}
@@ -0,0 +1,80 @@
+/*
+Copyright (c) 2020 TriggerMesh Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package slacksource
+
+import (
+	"context"
+
+	"knative.dev/pkg/kmeta"
+
+	"github.com/triggermesh/knative-sources/pkg/apis/sources/v1alpha1"
+	"github.com/triggermesh/knative-sources/pkg/reconciler/common/skip"
+)
+
+// The adapter reports the notifications it receives from Slack about the
+// app (rate limiting, uninstallation, ...) by patching a ConfigMap owned by
+// the source. The adapter runs with a dedicated ServiceAccount which is only
+// allowed to patch that ConfigMap.
+
+// appStatusConfigMapName returns the name of the ConfigMap through which the
+// adapter of the given source reports the status of the Slack app.
+func appStatusConfigMapName(src *v1alpha1.SlackSource) string {
+	return kmeta.ChildName(src.Name, "-slack-app-status")
+}
+
+// reconcileAppStatus ensures the adapter of the source is able to report the
+// status of the Slack app, and propagates the notifications reported by the
+// adapter to the SlackAppHealthy condition of the source.
+func (r *Reconciler) reconcileAppStatus(ctx context.Context, src *v1alpha1.SlackSource) error {
+	if skip.Skip(ctx) {
+		return nil
+	}
+
+	cm, err := r.configMapBase.ReconcileAdapterConfigMap(ctx, src, appStatusConfigMapName(src), "patch")
+	if err != nil {
+		return err
+	}
+
+	propagateAppStatus(&src.Status, cm.Data)
+
+	return nil
+}
+
+// propagateAppStatus sets the SlackAppHealthy condition of the given status
+// using the notifications reported by the adapter. When several kinds of
+// notifications were reported, the most severe one prevails.
+func propagateAppStatus(st *v1alpha1.SlackSourceStatus, data map[string]string) {
+	switch {
+	case data[v1alpha1.SlackAppStatusUninstalledKey] != "":
+		st.MarkAppUnhealthy(v1alpha1.SlackReasonAppUninstalled,
+			"Slack notified that the app was uninstalled at %s",
+			data[v1alpha1.SlackAppStatusUninstalledKey])
+
+	case data[v1alpha1.SlackAppStatusTokensRevokedKey] != "":
+		st.MarkAppUnhealthy(v1alpha1.SlackReasonTokensRevoked,
+			"Slack notified that API tokens of the app were revoked at %s",
+			data[v1alpha1.SlackAppStatusTokensRevokedKey])
+
+	case data[v1alpha1.SlackAppStatusRateLimitedKey] != "":
+		st.MarkAppUnhealthy(v1alpha1.SlackReasonAppRateLimited,
+			"Slack notified that the app exceeded its rate limit at %s",
+			data[v1alpha1.SlackAppStatusRateLimitedKey])
+
+	default:
+		st.MarkAppHealthy()
+	}
+}
@@ -0,0 +1,131 @@
+/*
+Copyright (c) 2020 TriggerMesh Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package slacksource
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
+
+	"knative.dev/pkg/controller"
+
+	"github.com/triggermesh/knative-sources/pkg/apis/sources/v1alpha1"
+	"github.com/triggermesh/knative-sources/pkg/reconciler/common"
+	. "github.com/triggermesh/knative-sources/pkg/reconciler/testing"
+)
+
+func TestReconcileAppStatus(t *testing.T) {
+	tc := map[string]struct {
+		statusData map[string]string
+
+		expectStatus corev1.ConditionStatus
+		expectReason string
+	}{
+		"no app status ConfigMap": {
+			expectStatus: corev1.ConditionTrue,
+		},
+		"no notification": {
+			statusData:   map[string]string{},
+			expectStatus: corev1.ConditionTrue,
+		},
+		"rate limited": {
+			statusData: map[string]string{
+				v1alpha1.SlackAppStatusRateLimitedKey: "2020-11-10T10:00:00Z",
+			},
+			expectStatus: corev1.ConditionFalse,
+			expectReason: v1alpha1.SlackReasonAppRateLimited,
+		},
+		"uninstalled prevails over rate limited": {
+			statusData: map[string]string{
+				v1alpha1.SlackAppStatusRateLimitedKey: "2020-11-10T10:00:00Z",
+				v1alpha1.SlackAppStatusUninstalledKey: "2020-11-10T10:05:00Z",
+			},
+			expectStatus: corev1.ConditionFalse,
+			expectReason: v1alpha1.SlackReasonAppUninstalled,
+		},
+		"tokens revoked": {
+			statusData: map[string]string{
+				v1alpha1.SlackAppStatusTokensRevokedKey: "2020-11-10T10:05:00Z",
+			},
+			expectStatus: corev1.ConditionFalse,
+			expectReason: v1alpha1.SlackReasonTokensRevoked,
+		},
+	}
+
+	for name, c := range tc {
+		t.Run(name, func(t *testing.T) {
+			src := &v1alpha1.SlackSource{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: "test",
+					Name:      "my-source",
+				},
+			}
+
+			condSet := src.GetConditionSet().Manage(&src.Status)
+			condSet.MarkTrue(v1alpha1.ConditionSinkProvided)
+			condSet.MarkTrue(v1alpha1.ConditionDeployed)
+
+			var objects []runtime.Object
+			if c.statusData != nil {
+				cm := common.NewAdapterConfigMap(src, appStatusConfigMapName(src))
+				cm.Data = c.statusData
+				objects = append(objects, cm)
+			}
+
+			kubeClient := fake.NewSimpleClientset(objects...)
+			ls := NewListers(NewScheme(), objects)
+
+			r := &Reconciler{configMapBase: NewConfigMapReconciler(kubeClient, &ls)}
+
+			ctx := controller.WithEventRecorder(context.Background(), record.NewFakeRecorder(10))
+			ctx = v1alpha1.WithSource(ctx, src)
+
+			err := r.reconcileAppStatus(ctx, src)
+			assert.NoError(t, err)
+
+			_, err = kubeClient.CoreV1().ConfigMaps("test").Get(ctx, "my-source-slack-app-status", metav1.GetOptions{})
+			assert.NoError(t, err, "app status ConfigMap was not created")
+
+			_, err = kubeClient.CoreV1().ServiceAccounts("test").Get(ctx, "slacksource-my-source", metav1.GetOptions{})
+			assert.NoError(t, err, "adapter ServiceAccount was not created")
+
+			role, err := kubeClient.RbacV1().Roles("test").Get(ctx, "slacksource-my-source", metav1.GetOptions{})
+			if assert.NoError(t, err, "app status Role was not created") {
+				assert.Equal(t, []string{"my-source-slack-app-status"}, role.Rules[0].ResourceNames)
+			}
+
+			_, err = kubeClient.RbacV1().RoleBindings("test").Get(ctx, "slacksource-my-source", metav1.GetOptions{})
+			assert.NoError(t, err, "app status RoleBinding was not created")
+
+			cond := src.Status.GetCondition(v1alpha1.SlackConditionAppHealthy)
+			if assert.NotNil(t, cond, "SlackAppHealthy condition is not set") {
+				assert.Equal(t, c.expectStatus, cond.Status)
+				assert.Equal(t, c.expectReason, cond.Reason)
+			}
+
+			// the condition doesn't affect the readiness of the source
+			assert.True(t, src.Status.IsReady(), "source is not ready")
+		})
+	}
+}
@@ -21,10 +21,14 @@ import (
 
 	"github.com/kelseyhightower/envconfig"
 
+	"k8s.io/client-go/tools/cache"
+
 	"knative.dev/eventing/pkg/reconciler/source"
 	"knative.dev/pkg/configmap"
 	"knative.dev/pkg/controller"
 
+	configmapinformer "knative.dev/pkg/client/injection/kube/informers/core/v1/configmap"
+
 	"github.com/triggermesh/knative-sources/pkg/apis/sources/v1alpha1"
 	informerv1alpha1 "github.com/triggermesh/knative-sources/pkg/client/generated/injection/informers/sources/v1alpha1/slacksource"
 	reconcilerv1alpha1 "github.com/triggermesh/knative-sources/pkg/client/generated/injection/reconciler/sources/v1alpha1/slacksource"
@@ -43,7 +47,7 @@ func NewController(
 	// Calling envconfig.Process() with a prefix appends that prefix
 	// (uppercased) to the Go field name, e.g. MYSOURCE_IMAGE.
 	adapterCfg := &adapterConfig{
-		configs: source.WatchConfigurations(ctx, app, cmw, source.WithLogging, source.WithMetrics),
+		configs: source.WatchConfigurations(ctx, app, cmw, source.WithLogging, source.WithMetrics, source.WithTracing),
 	}
 	envconfig.MustProcess(app, adapterCfg)
 
@@ -59,7 +63,23 @@ func NewController(
 		impl.EnqueueControllerOf,
 	)
 
+	r.deploymentBase = common.NewGenericAddressableDeploymentReconciler(
+		ctx,
+		typ.GetGroupVersionKind(),
+		impl.EnqueueKey,
+		impl.EnqueueControllerOf,
+	)
+
+	r.configMapBase = common.NewGenericConfigMapReconciler(ctx)
+
 	informerv1alpha1.Get(ctx).Informer().AddEventHandler(controller.HandleAll(impl.Enqueue))
 
+	// the status of the Slack app is reported by the adapter through a
+	// ConfigMap owned by the source
+	configmapinformer.Get(ctx).Informer().AddEventHandler(cache.FilteringResourceEventHandler{
+		FilterFunc: controller.FilterControllerGVK(typ.GetGroupVersionKind()),
+		Handler:    controller.HandleAll(impl.EnqueueControllerOf),
+	})
+
 	return impl
 }
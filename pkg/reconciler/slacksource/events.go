@@ -0,0 +1,28 @@
+/*
+Copyright (c) 2020 TriggerMesh, Inc
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package slacksource
+
+const (
+	// ReasonManifestCreate indicates that the Slack app manifest ConfigMap was successfully created.
+	ReasonManifestCreate = "CreateAppManifest"
+	// ReasonManifestUpdate indicates that the Slack app manifest ConfigMap was successfully updated.
+	ReasonManifestUpdate = "UpdateAppManifest"
+	// ReasonFailedManifestCreate indicates that the creation of the Slack app manifest ConfigMap failed.
+	ReasonFailedManifestCreate = "FailedAppManifestCreate"
+	// ReasonFailedManifestUpdate indicates that the update of the Slack app manifest ConfigMap failed.
+	ReasonFailedManifestUpdate = "FailedAppManifestUpdate"
+)
@@ -0,0 +1,255 @@
+/*
+Copyright (c) 2020 TriggerMesh Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package slacksource
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+
+	"knative.dev/pkg/kmeta"
+	"knative.dev/pkg/reconciler"
+
+	"github.com/triggermesh/knative-sources/pkg/apis/sources/v1alpha1"
+	"github.com/triggermesh/knative-sources/pkg/reconciler/common"
+	"github.com/triggermesh/knative-sources/pkg/reconciler/common/event"
+	"github.com/triggermesh/knative-sources/pkg/reconciler/common/semantic"
+	"github.com/triggermesh/knative-sources/pkg/reconciler/common/skip"
+)
+
+// Keys of the app manifest ConfigMap.
+const (
+	manifestKeyYAML = "manifest.yaml"
+	manifestKeyJSON = "manifest.json"
+)
+
+// Scopes required by the source's features.
+// See: https://api.slack.com/scopes
+var (
+	enrichmentBotScopes = []string{"users:read", "users:read.email", "channels:read"}
+	replyBotScopes      = []string{"chat:write"}
+)
+
+// slackAppManifest is the representation of a Slack app manifest.
+// See: https://api.slack.com/reference/manifests
+type slackAppManifest struct {
+	DisplayInformation slackManifestDisplayInformation `json:"display_information"`
+	Features           slackManifestFeatures           `json:"features"`
+	OAuthConfig        *slackManifestOAuthConfig       `json:"oauth_config,omitempty"`
+	Settings           slackManifestSettings           `json:"settings"`
+}
+
+type slackManifestDisplayInformation struct {
+	Name string `json:"name"`
+}
+
+type slackManifestFeatures struct {
+	BotUser slackManifestBotUser `json:"bot_user"`
+}
+
+type slackManifestBotUser struct {
+	DisplayName string `json:"display_name"`
+}
+
+type slackManifestOAuthConfig struct {
+	Scopes slackManifestScopes `json:"scopes"`
+}
+
+type slackManifestScopes struct {
+	Bot []string `json:"bot"`
+}
+
+type slackManifestSettings struct {
+	EventSubscriptions *slackManifestEventSubscriptions `json:"event_subscriptions,omitempty"`
+	Interactivity      *slackManifestInteractivity      `json:"interactivity,omitempty"`
+	SocketModeEnabled  bool                             `json:"socket_mode_enabled"`
+}
+
+type slackManifestEventSubscriptions struct {
+	RequestURL string   `json:"request_url,omitempty"`
+	BotEvents  []string `json:"bot_events,omitempty"`
+}
+
+type slackManifestInteractivity struct {
+	IsEnabled  bool   `json:"is_enabled"`
+	RequestURL string `json:"request_url,omitempty"`
+}
+
+// makeAppManifest returns the Slack app manifest matching the given source.
+// The request URLs, and interactivity outside of Socket Mode, are only set
+// when the source is addressable.
+func makeAppManifest(src *v1alpha1.SlackSource) *slackAppManifest {
+	appName := src.Name
+
+	var botEvents, botScopes []string
+
+	if opts := src.Spec.Manifest; opts != nil {
+		if opts.AppName != nil {
+			appName = *opts.AppName
+		}
+		botEvents = opts.BotEvents
+		botScopes = opts.BotScopes
+	}
+
+	if src.Spec.Enrichment != nil {
+		botScopes = append(botScopes, enrichmentBotScopes...)
+	}
+	if src.Spec.Replies {
+		botScopes = append(botScopes, replyBotScopes...)
+	}
+
+	m := &slackAppManifest{
+		DisplayInformation: slackManifestDisplayInformation{Name: appName},
+		Features: slackManifestFeatures{
+			BotUser: slackManifestBotUser{DisplayName: appName},
+		},
+		Settings: slackManifestSettings{
+			EventSubscriptions: &slackManifestEventSubscriptions{
+				BotEvents: botEvents,
+			},
+			SocketModeEnabled: src.Spec.SocketMode != nil,
+		},
+	}
+
+	if botScopes = uniqueSorted(botScopes); len(botScopes) > 0 {
+		m.OAuthConfig = &slackManifestOAuthConfig{
+			Scopes: slackManifestScopes{Bot: botScopes},
+		}
+	}
+
+	// In Socket Mode, Slack doesn't send requests to the source, and
+	// interactions are received over the Socket Mode connection. Otherwise,
+	// Slack rejects manifests which enable interactivity without a request
+	// URL, so it is only enabled once the source is addressable.
+	switch addr := src.Status.Address; {
+	case src.Spec.SocketMode != nil:
+		m.Settings.Interactivity = &slackManifestInteractivity{
+			IsEnabled: true,
+		}
+	case addr != nil && addr.URL != nil:
+		m.Settings.EventSubscriptions.RequestURL = addr.URL.String()
+		m.Settings.Interactivity = &slackManifestInteractivity{
+			IsEnabled:  true,
+			RequestURL: addr.URL.String(),
+		}
+	}
+
+	return m
+}
+
+// uniqueSorted returns a sorted copy of the given strings without
+// duplicates.
+func uniqueSorted(strs []string) []string {
+	if len(strs) == 0 {
+		return nil
+	}
+
+	set := make(map[string]struct{}, len(strs))
+	for _, s := range strs {
+		set[s] = struct{}{}
+	}
+
+	uniq := make([]string, 0, len(set))
+	for s := range set {
+		uniq = append(uniq, s)
+	}
+	sort.Strings(uniq)
+
+	return uniq
+}
+
+// newAppManifestConfigMap returns a ConfigMap containing the Slack app
+// manifest of the given source, serialized to YAML and JSON.
+func newAppManifestConfigMap(src *v1alpha1.SlackSource) (*corev1.ConfigMap, error) {
+	manifestJSON, err := json.MarshalIndent(makeAppManifest(src), "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("serializing app manifest to JSON: %w", err)
+	}
+
+	manifestYAML, err := yaml.JSONToYAML(manifestJSON)
+	if err != nil {
+		return nil, fmt.Errorf("serializing app manifest to YAML: %w", err)
+	}
+
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: src.Namespace,
+			Name:      kmeta.ChildName(src.Name, "-slack-app-manifest"),
+			Labels: map[string]string{
+				common.AppNameLabel:      common.AdapterName(src),
+				common.AppInstanceLabel:  src.Name,
+				common.AppPartOfLabel:    common.PartOf,
+				common.AppManagedByLabel: common.ManagedBy,
+			},
+			OwnerReferences: []metav1.OwnerReference{
+				*kmeta.NewControllerRef(src),
+			},
+		},
+		Data: map[string]string{
+			manifestKeyYAML: string(manifestYAML),
+			manifestKeyJSON: string(manifestJSON),
+		},
+	}, nil
+}
+
+// reconcileAppManifest ensures the ConfigMap containing the Slack app
+// manifest of the source is up-to-date, and references it in the source's
+// status.
+func (r *Reconciler) reconcileAppManifest(ctx context.Context, src *v1alpha1.SlackSource) error {
+	if skip.Skip(ctx) {
+		return nil
+	}
+
+	desired, err := newAppManifestConfigMap(src)
+	if err != nil {
+		return fmt.Errorf("rendering Slack app manifest: %w", err)
+	}
+
+	cmCli := r.configMapBase.ConfigMapClient(src.Namespace)
+
+	current, err := r.configMapBase.ConfigMapLister(src.Namespace).Get(desired.Name)
+	switch {
+	case apierrors.IsNotFound(err):
+		if _, err := cmCli.Create(ctx, desired, metav1.CreateOptions{}); err != nil {
+			return reconciler.NewEvent(corev1.EventTypeWarning, ReasonFailedManifestCreate,
+				"Failed to create Slack app manifest ConfigMap %q: %s", desired.Name, err)
+		}
+		event.Normal(ctx, ReasonManifestCreate, "Created Slack app manifest ConfigMap %q", desired.Name)
+
+	case err != nil:
+		return fmt.Errorf("getting Slack app manifest ConfigMap: %w", err)
+
+	case !semantic.Semantic.DeepEqual(desired, current):
+		desired.ResourceVersion = current.ResourceVersion
+
+		if _, err := cmCli.Update(ctx, desired, metav1.UpdateOptions{}); err != nil {
+			return reconciler.NewEvent(corev1.EventTypeWarning, ReasonFailedManifestUpdate,
+				"Failed to update Slack app manifest ConfigMap %q: %s", desired.Name, err)
+		}
+		event.Normal(ctx, ReasonManifestUpdate, "Updated Slack app manifest ConfigMap %q", desired.Name)
+	}
+
+	src.Status.AppManifestRef = &corev1.LocalObjectReference{Name: desired.Name}
+
+	return nil
+}
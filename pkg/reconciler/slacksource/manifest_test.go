@@ -0,0 +1,130 @@
+/*
+Copyright (c) 2020 TriggerMesh Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package slacksource
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+
+	"knative.dev/pkg/apis"
+	duckv1 "knative.dev/pkg/apis/duck/v1"
+
+	"github.com/triggermesh/knative-sources/pkg/apis/sources/v1alpha1"
+)
+
+func TestAppManifestConfigMap(t *testing.T) {
+	src := &v1alpha1.SlackSource{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "test",
+			Name:      "my-source",
+		},
+		Spec: v1alpha1.SlackSourceSpec{
+			Enrichment: &v1alpha1.SlackEventEnrichment{},
+			Manifest: &v1alpha1.SlackAppManifestOptions{
+				AppName:   strPtr("My App"),
+				BotEvents: []string{"app_mention", "message.channels"},
+				BotScopes: []string{"channels:history", "channels:read"},
+			},
+		},
+	}
+	src.Status.Address = &duckv1.Addressable{
+		URL: apis.HTTPS("my-source.test.example.com"),
+	}
+
+	cm, err := newAppManifestConfigMap(src)
+	assert.NoError(t, err)
+
+	assert.Equal(t, "test", cm.Namespace)
+	assert.Equal(t, "my-source-slack-app-manifest", cm.Name)
+
+	const expectManifest = `display_information:
+  name: My App
+features:
+  bot_user:
+    display_name: My App
+oauth_config:
+  scopes:
+    bot:
+    - channels:history
+    - channels:read
+    - users:read
+    - users:read.email
+settings:
+  event_subscriptions:
+    bot_events:
+    - app_mention
+    - message.channels
+    request_url: https://my-source.test.example.com
+  interactivity:
+    is_enabled: true
+    request_url: https://my-source.test.example.com
+  socket_mode_enabled: false
+`
+	assert.YAMLEq(t, expectManifest, cm.Data[manifestKeyYAML])
+	assert.JSONEq(t, yamlToJSON(t, expectManifest), cm.Data[manifestKeyJSON])
+
+	t.Run("Socket Mode", func(t *testing.T) {
+		src := src.DeepCopy()
+		src.Spec.SocketMode = &v1alpha1.SlackSocketMode{}
+
+		m := makeAppManifest(src)
+		assert.True(t, m.Settings.SocketModeEnabled)
+		assert.Empty(t, m.Settings.EventSubscriptions.RequestURL)
+		assert.True(t, m.Settings.Interactivity.IsEnabled)
+		assert.Empty(t, m.Settings.Interactivity.RequestURL)
+	})
+
+	t.Run("replies", func(t *testing.T) {
+		src := src.DeepCopy()
+		src.Spec.BotToken = &v1alpha1.SecretValueFromSource{}
+
+		m := makeAppManifest(src)
+		assert.NotContains(t, m.OAuthConfig.Scopes.Bot, "chat:write", "a bot token alone should not enable replies")
+
+		src.Spec.Replies = true
+
+		m = makeAppManifest(src)
+		assert.Contains(t, m.OAuthConfig.Scopes.Bot, "chat:write")
+	})
+
+	t.Run("not addressable", func(t *testing.T) {
+		src := src.DeepCopy()
+		src.Status.Address = nil
+
+		m := makeAppManifest(src)
+		assert.Empty(t, m.Settings.EventSubscriptions.RequestURL)
+		assert.Nil(t, m.Settings.Interactivity, "interactivity requires a request URL")
+	})
+}
+
+func yamlToJSON(t *testing.T, y string) string {
+	t.Helper()
+
+	j, err := yaml.YAMLToJSON([]byte(y))
+	if err != nil {
+		t.Fatalf("Failed to convert YAML to JSON: %s", err)
+	}
+	return string(j)
+}
+
+func strPtr(s string) *string {
+	return &s
+}
@@ -18,7 +18,13 @@ package slacksource
 
 import (
 	"context"
+	"fmt"
 
+	corev1 "k8s.io/api/core/v1"
+
+	"knative.dev/pkg/apis"
+	duckv1 "knative.dev/pkg/apis/duck/v1"
+	"knative.dev/pkg/controller"
 	"knative.dev/pkg/reconciler"
 
 	"github.com/triggermesh/knative-sources/pkg/apis/sources/v1alpha1"
@@ -28,8 +34,10 @@ import (
 
 // Reconciler implements controller.Reconciler for the event source type.
 type Reconciler struct {
-	base       common.GenericServiceReconciler
-	adapterCfg *adapterConfig
+	base           common.GenericServiceReconciler
+	deploymentBase common.GenericAddressableDeploymentReconciler
+	configMapBase  common.GenericConfigMapReconciler
+	adapterCfg     *adapterConfig
 }
 
 // Check that our Reconciler implements Interface
@@ -40,5 +48,147 @@ func (r *Reconciler) ReconcileKind(ctx context.Context, src *v1alpha1.SlackSourc
 	// inject source into context for usage in reconciliation logic
 	ctx = v1alpha1.WithSource(ctx, src)
 
-	return r.base.ReconcileSource(ctx, adapterServiceBuilder(src, r.adapterCfg))
+	if src.Spec.Enrichment != nil && src.Spec.BotToken == nil {
+		return common.InvalidSpec(src, "The enrichment of events requires a bot token")
+	}
+
+	if src.Spec.Replies && src.Spec.BotToken == nil {
+		return common.InvalidSpec(src, "Replies require a bot token")
+	}
+
+	if d := src.Spec.Delivery; d != nil && d.Policy != nil &&
+		*d.Policy == v1alpha1.SlackDeliveryPolicyDeadLetter && d.DeadLetterSink == nil {
+
+		return common.InvalidSpec(src, "The %s delivery policy requires a dead-letter sink", *d.Policy)
+	}
+
+	// buffering adapters are only reachable from within the cluster,
+	// unless exposed at a public URL
+	if src.Spec.Buffer != nil && src.Spec.Buffer.PublicURL == nil && src.Spec.SocketMode == nil {
+		return common.InvalidSpec(src, "The buffering of events requires a public URL outside of Socket Mode")
+	}
+
+	// events sent to the sink with replies enabled expect a reply, and
+	// are therefore delivered synchronously
+	if src.Spec.Buffer != nil && src.Spec.Replies {
+		return common.InvalidSpec(src, "The buffering of events is not supported with replies")
+	}
+
+	// buffered events are acknowledged before being delivered, so
+	// rejections by the sink can not be handled by the delivery policy
+	if d := src.Spec.Delivery; src.Spec.Buffer != nil && d != nil && d.Policy != nil &&
+		*d.Policy == v1alpha1.SlackDeliveryPolicyDeadLetter {
+
+		return common.InvalidSpec(src, "The buffering of events is not supported with the %s delivery policy",
+			*d.Policy)
+	}
+
+	sinks, err := r.resolveAuxSinks(ctx, src)
+	if err != nil {
+		src.GetStatusManager().MarkNoSink()
+		return controller.NewPermanentError(reconciler.NewEvent(corev1.EventTypeWarning,
+			common.ReasonBadSinkURI, "Could not resolve sink URI: %s", err))
+	}
+
+	if err := r.reconcileAdapter(ctx, src, sinks); err != nil {
+		return err
+	}
+
+	// Pods of the adapter can not be created until its ServiceAccount
+	// exists, and are retried meanwhile
+	if err := r.reconcileAppStatus(ctx, src); err != nil {
+		return err
+	}
+
+	// the app manifest depends on the URL of the adapter, which is
+	// propagated to the source's status while reconciling the adapter
+	return r.reconcileAppManifest(ctx, src)
+}
+
+// reconcileAdapter reconciles the adapter of the source. The adapter is
+// deployed as a Knative Service when it receives events over HTTP, as a plain
+// Deployment in Socket Mode, and as a Deployment exposed by a Kubernetes
+// Service when it buffers events received over HTTP on a persistent volume.
+// Switching between modes requires removing the adapter of the other kinds.
+func (r *Reconciler) reconcileAdapter(ctx context.Context, src *v1alpha1.SlackSource, sinks auxSinks) reconciler.Event {
+	switch {
+	case src.Spec.SocketMode != nil:
+		if err := r.base.DeleteAdapter(ctx, src); err != nil {
+			return err
+		}
+		if err := r.deploymentBase.DeleteAdapterK8sService(ctx, src); err != nil {
+			return err
+		}
+		return r.deploymentBase.ReconcileSource(ctx, adapterDeploymentBuilder(src, r.adapterCfg, sinks))
+
+	case src.Spec.Buffer != nil:
+		if err := r.base.DeleteAdapter(ctx, src); err != nil {
+			return err
+		}
+		return r.deploymentBase.ReconcileAddressableSource(ctx, adapterDeploymentBuilder(src, r.adapterCfg, sinks),
+			src.Spec.Buffer.PublicURL)
+	}
+
+	if err := r.deploymentBase.DeleteAdapter(ctx, src); err != nil {
+		return err
+	}
+	return r.base.ReconcileSource(ctx, adapterServiceBuilder(src, r.adapterCfg, sinks))
+}
+
+// auxSinks contains the resolved URLs of the destinations of events other
+// than the source's sink.
+type auxSinks struct {
+	// sinks overridden by Slack apps, indexed by app ID
+	apps map[string]*apis.URL
+	// destination of events which could not be delivered
+	deadLetter *apis.URL
+}
+
+// resolveAuxSinks resolves the URLs of the sinks overridden by Slack apps
+// and of the dead-letter sink.
+func (r *Reconciler) resolveAuxSinks(ctx context.Context, src *v1alpha1.SlackSource) (auxSinks, error) {
+	var sinks auxSinks
+
+	for _, app := range src.Spec.Apps {
+		if app.Sink == nil {
+			continue
+		}
+
+		sinkURI, err := r.resolveDestination(ctx, src, *app.Sink)
+		if err != nil {
+			return sinks, fmt.Errorf("sink of Slack app %q: %w", app.AppID, err)
+		}
+
+		if sinks.apps == nil {
+			sinks.apps = make(map[string]*apis.URL, len(src.Spec.Apps))
+		}
+		sinks.apps[app.AppID] = sinkURI
+	}
+
+	if d := src.Spec.Delivery; d != nil && d.Policy != nil &&
+		*d.Policy == v1alpha1.SlackDeliveryPolicyDeadLetter {
+
+		sinkURI, err := r.resolveDestination(ctx, src, *d.DeadLetterSink)
+		if err != nil {
+			return sinks, fmt.Errorf("dead-letter sink: %w", err)
+		}
+		sinks.deadLetter = sinkURI
+	}
+
+	return sinks, nil
+}
+
+// resolveDestination resolves the URL of the given destination. References
+// without a namespace default to the namespace of the source. The
+// destination is passed by value, so that the source's spec isn't altered.
+func (r *Reconciler) resolveDestination(ctx context.Context, src *v1alpha1.SlackSource,
+	dest duckv1.Destination) (*apis.URL, error) {
+
+	if ref := dest.Ref; ref != nil && ref.Namespace == "" {
+		ref = ref.DeepCopy()
+		ref.Namespace = src.Namespace
+		dest.Ref = ref
+	}
+
+	return r.base.SinkResolver.URIFromDestinationV1(ctx, dest, src)
 }
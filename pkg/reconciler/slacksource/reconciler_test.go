@@ -20,9 +20,12 @@ import (
 	"context"
 	"testing"
 
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/types"
 
 	"knative.dev/eventing/pkg/reconciler/source"
+	"knative.dev/pkg/apis"
+	fakek8sinjectionclient "knative.dev/pkg/client/injection/kube/client/fake"
 	"knative.dev/pkg/controller"
 	"knative.dev/pkg/logging"
 	"knative.dev/pkg/resolver"
@@ -44,12 +47,71 @@ func TestReconcileSource(t *testing.T) {
 	var (
 		ctor      = reconcilerCtor(adapterCfg)
 		src       = newEventSource()
-		adapterFn = adapterServiceBuilder(src, adapterCfg)
+		adapterFn = adapterServiceBuilder(src, adapterCfg, auxSinks{})
 	)
 
 	TestReconcile(t, ctor, src, adapterFn)
 }
 
+func TestReconcileSourceSocketMode(t *testing.T) {
+	adapterCfg := &adapterConfig{
+		Image:   "registry/image:tag",
+		configs: &source.EmptyVarsGenerator{},
+	}
+
+	var (
+		ctor      = reconcilerCtor(adapterCfg)
+		src       = newSocketModeEventSource()
+		adapterFn = adapterDeploymentBuilder(src, adapterCfg, auxSinks{})
+	)
+
+	TestReconcile(t, ctor, src, adapterFn)
+}
+
+func TestReconcileSourceSocketModeBuffer(t *testing.T) {
+	adapterCfg := &adapterConfig{
+		Image:   "registry/image:tag",
+		configs: &source.EmptyVarsGenerator{},
+	}
+
+	src := newSocketModeEventSource()
+	src.Spec.Buffer = &v1alpha1.EventBuffer{
+		PersistentVolumeClaimName: "buffer",
+	}
+
+	var (
+		ctor      = reconcilerCtor(adapterCfg)
+		adapterFn = adapterDeploymentBuilder(src, adapterCfg, auxSinks{})
+	)
+
+	TestReconcile(t, ctor, src, adapterFn)
+}
+
+func TestReconcileSourceBuffer(t *testing.T) {
+	adapterCfg := &adapterConfig{
+		Image:   "registry/image:tag",
+		configs: &source.EmptyVarsGenerator{},
+	}
+
+	publicURL := &apis.URL{
+		Scheme: "https",
+		Host:   "slack.example.com",
+	}
+
+	src := newEventSource()
+	src.Spec.Buffer = &v1alpha1.EventBuffer{
+		PersistentVolumeClaimName: "buffer",
+		PublicURL:                 publicURL,
+	}
+
+	var (
+		ctor      = reconcilerCtor(adapterCfg)
+		adapterFn = adapterDeploymentBuilder(src, adapterCfg, auxSinks{})
+	)
+
+	TestReconcileAddressableDeployment(t, ctor, src, adapterFn, publicURL)
+}
+
 // reconcilerCtor returns a Ctor for a source Reconciler.
 func reconcilerCtor(cfg *adapterConfig) Ctor {
 	return func(t *testing.T, ctx context.Context, ls *Listers) controller.Reconciler {
@@ -59,9 +121,22 @@ func reconcilerCtor(cfg *adapterConfig) Ctor {
 			Client:       fakeservinginjectionclient.Get(ctx).ServingV1().Services,
 		}
 
+		deploymentBase := common.GenericAddressableDeploymentReconciler{
+			GenericDeploymentReconciler: common.GenericDeploymentReconciler{
+				SinkResolver: resolver.NewURIResolver(ctx, func(types.NamespacedName) {}),
+				Lister:       ls.GetDeploymentLister().Deployments,
+				Client:       fakek8sinjectionclient.Get(ctx).AppsV1().Deployments,
+				PodClient:    fakek8sinjectionclient.Get(ctx).CoreV1().Pods,
+			},
+			ServiceLister: ls.GetK8sServiceLister().Services,
+			ServiceClient: fakek8sinjectionclient.Get(ctx).CoreV1().Services,
+		}
+
 		r := &Reconciler{
-			base:       base,
-			adapterCfg: cfg,
+			base:           base,
+			deploymentBase: deploymentBase,
+			configMapBase:  NewConfigMapReconciler(fakek8sinjectionclient.Get(ctx), ls),
+			adapterCfg:     cfg,
 		}
 
 		return reconcilerv1alpha1.NewReconciler(ctx, logging.FromContext(ctx),
@@ -80,3 +155,22 @@ func newEventSource() *v1alpha1.SlackSource {
 
 	return src
 }
+
+// newSocketModeEventSource returns a test source object which receives events
+// in Socket Mode.
+func newSocketModeEventSource() *v1alpha1.SlackSource {
+	src := newEventSource()
+
+	src.Spec.SocketMode = &v1alpha1.SlackSocketMode{
+		AppToken: v1alpha1.SecretValueFromSource{
+			SecretKeyRef: &corev1.SecretKeySelector{
+				LocalObjectReference: corev1.LocalObjectReference{
+					Name: "slack",
+				},
+				Key: "appToken",
+			},
+		},
+	}
+
+	return src
+}
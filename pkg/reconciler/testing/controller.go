@@ -26,10 +26,13 @@ import (
 	"knative.dev/pkg/logging"
 	"knative.dev/pkg/metrics"
 	rt "knative.dev/pkg/reconciler/testing"
+	tracingconfig "knative.dev/pkg/tracing/config"
 )
 
-// TestControllerConstructor tests that a controller constructor meets our requirements.
-func TestControllerConstructor(t *testing.T, ctor injection.ControllerConstructor) {
+// TestControllerConstructor tests that a controller constructor meets our
+// requirements. The expected number of injected informers includes the
+// informer of the source type and the informers of its adapter types.
+func TestControllerConstructor(t *testing.T, ctor injection.ControllerConstructor, expectInformers int) {
 	t.Helper()
 
 	defer func() {
@@ -40,9 +43,8 @@ func TestControllerConstructor(t *testing.T, ctor injection.ControllerConstructo
 
 	ctx, informers := rt.SetupFakeContext(t)
 
-	// expected informers: Source, Deployment
-	if expect, got := 2, len(informers); got != expect {
-		t.Errorf("Expected %d injected informers, got %d", expect, got)
+	if got := len(informers); got != expectInformers {
+		t.Errorf("Expected %d injected informers, got %d", expectInformers, got)
 	}
 
 	// updateAdapterMetricsConfig panics when METRICS_DOMAIN is unset
@@ -51,6 +53,7 @@ func TestControllerConstructor(t *testing.T, ctor injection.ControllerConstructo
 	cmw := configmap.NewStaticWatcher(
 		NewConfigMap(metrics.ConfigMapName(), nil),
 		NewConfigMap(logging.ConfigMapName(), nil),
+		NewConfigMap(tracingconfig.ConfigName, nil),
 	)
 
 	ctrler := ctor(ctx, cmw)
@@ -82,6 +85,7 @@ func TestControllerConstructorFailures(t *testing.T, ctor injection.ControllerCo
 				*cmw = configmap.NewStaticWatcher(
 					NewConfigMap(metrics.ConfigMapName(), nil),
 					NewConfigMap(logging.ConfigMapName(), nil),
+					NewConfigMap(tracingconfig.ConfigName, nil),
 				)
 				return nil
 			},
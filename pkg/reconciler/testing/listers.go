@@ -20,9 +20,13 @@ import (
 	"fmt"
 
 	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	fakek8sclient "k8s.io/client-go/kubernetes/fake"
 	k8slistersv1 "k8s.io/client-go/listers/apps/v1"
+	corelistersv1 "k8s.io/client-go/listers/core/v1"
+	rbaclistersv1 "k8s.io/client-go/listers/rbac/v1"
 	"k8s.io/client-go/tools/cache"
 
 	fakeeventingclientset "knative.dev/eventing/pkg/client/clientset/versioned/fake"
@@ -118,3 +122,28 @@ func (l *Listers) GetDeploymentLister() k8slistersv1.DeploymentLister {
 func (l *Listers) GetServiceLister() servinglistersv1.ServiceLister {
 	return servinglistersv1.NewServiceLister(l.IndexerFor(&servingv1.Service{}))
 }
+
+// GetK8sServiceLister returns a lister for Kubernetes Service objects.
+func (l *Listers) GetK8sServiceLister() corelistersv1.ServiceLister {
+	return corelistersv1.NewServiceLister(l.IndexerFor(&corev1.Service{}))
+}
+
+// GetConfigMapLister returns a lister for ConfigMap objects.
+func (l *Listers) GetConfigMapLister() corelistersv1.ConfigMapLister {
+	return corelistersv1.NewConfigMapLister(l.IndexerFor(&corev1.ConfigMap{}))
+}
+
+// GetServiceAccountLister returns a lister for ServiceAccount objects.
+func (l *Listers) GetServiceAccountLister() corelistersv1.ServiceAccountLister {
+	return corelistersv1.NewServiceAccountLister(l.IndexerFor(&corev1.ServiceAccount{}))
+}
+
+// GetRoleLister returns a lister for Role objects.
+func (l *Listers) GetRoleLister() rbaclistersv1.RoleLister {
+	return rbaclistersv1.NewRoleLister(l.IndexerFor(&rbacv1.Role{}))
+}
+
+// GetRoleBindingLister returns a lister for RoleBinding objects.
+func (l *Listers) GetRoleBindingLister() rbaclistersv1.RoleBindingLister {
+	return rbaclistersv1.NewRoleBindingLister(l.IndexerFor(&rbacv1.RoleBinding{}))
+}
@@ -34,6 +34,7 @@ import (
 	eventingv1 "knative.dev/eventing/pkg/apis/eventing/v1"
 	"knative.dev/pkg/apis"
 	duckv1 "knative.dev/pkg/apis/duck/v1"
+	"knative.dev/pkg/network"
 	"knative.dev/pkg/reconciler"
 	rt "knative.dev/pkg/reconciler/testing"
 	servingv1 "knative.dev/serving/pkg/apis/serving/v1"
@@ -231,6 +232,126 @@ func TestReconcile(t *testing.T, ctor Ctor, src v1alpha1.EventSource, adapterFn
 	testCases.Test(t, MakeFactory(ctor))
 }
 
+// Test the Reconcile method of the controller.Reconciler implemented by
+// controllers, for sources whose adapter is a Deployment exposed by a
+// Kubernetes Service. The given public URL, if any, is expected as the
+// address of the source.
+func TestReconcileAddressableDeployment(t *testing.T, ctor Ctor, src v1alpha1.EventSource,
+	adapterFn common.AdapterDeploymentBuilderFunc, publicURL *apis.URL) {
+
+	assertPopulatedSource(t, src)
+
+	newEventSource := eventSourceCtor(src)
+	newAdapter := adapterCtor(adapterFn, src)
+	newAdapterSvc := func(opts ...func(*corev1.Service)) *corev1.Service {
+		svc := common.NewAdapterK8sService(src)
+		for _, opt := range opts {
+			opt(svc)
+		}
+		return svc
+	}
+
+	a := newAdapter()
+	n, k, _ := nameKindAndResource(a)
+	svcName := newAdapterSvc().Name
+
+	addrURL := publicURL
+	if addrURL == nil {
+		addrURL = &apis.URL{
+			Scheme: "http",
+			Host:   network.GetServiceHostname(svcName, tNs),
+		}
+	}
+	withAddress := func(src v1alpha1.EventSource) {
+		src.GetStatusManager().SetAddress(addrURL)
+	}
+
+	bumpPort := func(svc *corev1.Service) {
+		svc.Spec.Ports[0].Port++
+	}
+
+	skipCtx := skip.EnableSkip(context.Background())
+
+	testCases := rt.TableTest{
+		{
+			Name: "Source object creation",
+			Key:  tKey,
+			Ctx:  skipCtx,
+			Objects: []runtime.Object{
+				newAdressable(),
+				newEventSource(noCEAttributes),
+			},
+			WantCreates: []runtime.Object{
+				newAdapter(),
+				newAdapterSvc(),
+			},
+			WantStatusUpdates: []clientgotesting.UpdateActionImpl{{
+				Object: newEventSource(withSink, notDeployed(a), withAddress),
+			}},
+			WantEvents: []string{
+				createAdapterEvent(n, k),
+				createAdapterEvent(svcName, k8sServiceKind),
+			},
+		},
+		{
+			Name: "Adapter becomes Ready",
+			Key:  tKey,
+			Ctx:  skipCtx,
+			Objects: []runtime.Object{
+				newAdressable(),
+				newEventSource(withSink, notDeployed(a), withAddress),
+				newAdapter(ready),
+				newAdapterSvc(),
+			},
+			WantStatusUpdates: []clientgotesting.UpdateActionImpl{{
+				Object: newEventSource(withSink, deployed(a), withAddress),
+			}},
+		},
+		{
+			Name: "Adapter Service is outdated",
+			Key:  tKey,
+			Ctx:  skipCtx,
+			Objects: []runtime.Object{
+				newAdressable(),
+				newEventSource(withSink, deployed(a), withAddress),
+				newAdapter(ready),
+				newAdapterSvc(bumpPort),
+			},
+			WantUpdates: []clientgotesting.UpdateActionImpl{{
+				Object: newAdapterSvc(),
+			}},
+			WantEvents: []string{
+				updateAdapterEvent(svcName, k8sServiceKind),
+			},
+		},
+		{
+			Name: "Fail to create adapter Service",
+			Key:  tKey,
+			Ctx:  skipCtx,
+			WithReactors: []clientgotesting.ReactionFunc{
+				rt.InduceFailure("create", "services"),
+			},
+			Objects: []runtime.Object{
+				newAdressable(),
+				newEventSource(withSink, deployed(a)),
+				newAdapter(ready),
+			},
+			WantCreates: []runtime.Object{
+				newAdapterSvc(),
+			},
+			WantEvents: []string{
+				failCreateAdapterEvent(svcName, k8sServiceKind, "services"),
+			},
+			WantErr: true,
+		},
+	}
+
+	testCases.Test(t, MakeFactory(ctor))
+}
+
+// k8sServiceKind is the kind of Kubernetes Services in events about adapters.
+const k8sServiceKind = "Kubernetes Service"
+
 // assertPopulatedSource asserts that all source attributes required in
 // reconciliation tests are populated and valid.
 func assertPopulatedSource(t *testing.T, src v1alpha1.EventSource) {
@@ -23,8 +23,11 @@ import (
 
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
 
 	"knative.dev/pkg/controller"
+
+	"github.com/triggermesh/knative-sources/pkg/reconciler/common"
 )
 
 // EnsureNoNilField fails the test if the provided Impl's reconciler contains
@@ -76,3 +79,18 @@ func NewConfigMap(name string, data map[string]string) *corev1.ConfigMap {
 	}
 	return cmap
 }
+
+// NewConfigMapReconciler returns a GenericConfigMapReconciler which uses the
+// given client and listers.
+func NewConfigMapReconciler(kubeClient kubernetes.Interface, ls *Listers) common.GenericConfigMapReconciler {
+	return common.GenericConfigMapReconciler{
+		ConfigMapClient:      kubeClient.CoreV1().ConfigMaps,
+		ServiceAccountClient: kubeClient.CoreV1().ServiceAccounts,
+		RoleClient:           kubeClient.RbacV1().Roles,
+		RoleBindingClient:    kubeClient.RbacV1().RoleBindings,
+		ConfigMapLister:      ls.GetConfigMapLister().ConfigMaps,
+		ServiceAccountLister: ls.GetServiceAccountLister().ServiceAccounts,
+		RoleLister:           ls.GetRoleLister().Roles,
+		RoleBindingLister:    ls.GetRoleBindingLister().RoleBindings,
+	}
+}
@@ -18,6 +18,10 @@ package zendesksource
 
 import (
 	"strconv"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
 
 	"knative.dev/eventing/pkg/reconciler/source"
 	"knative.dev/pkg/apis"
@@ -26,13 +30,22 @@ import (
 
 	"github.com/triggermesh/knative-sources/pkg/apis/sources/v1alpha1"
 	"github.com/triggermesh/knative-sources/pkg/reconciler/common"
+	"github.com/triggermesh/knative-sources/pkg/reconciler/common/buffer"
 	"github.com/triggermesh/knative-sources/pkg/reconciler/common/resource"
 )
 
 const (
-	envZdSubdomain   = "ZENDESK_SUBDOMAIN"
-	envZdWebhookUser = "ZENDESK_WEBHOOK_USERNAME"
-	envZdWebhookPwd  = "ZENDESK_WEBHOOK_PASSWORD"
+	envZdSubdomain         = "ZENDESK_SUBDOMAIN"
+	envZdWebhookUser       = "ZENDESK_WEBHOOK_USERNAME"
+	envZdWebhookPwd        = "ZENDESK_WEBHOOK_PASSWORD"
+	envZdEmail             = "ZENDESK_EMAIL"
+	envZdAPIToken          = "ZENDESK_API_TOKEN"
+	envZdWebhookName       = "ZENDESK_WEBHOOK_NAME"
+	envZdEventsWebhookName = "ZENDESK_EVENTS_WEBHOOK_NAME"
+
+	envZdEvents          = "ZENDESK_EVENTS"
+	envZdPollingInterval = "ZENDESK_POLLING_INTERVAL"
+	envZdCursorConfigMap = "ZENDESK_CURSOR_CONFIGMAP"
 )
 
 const metricsPrometheusPort uint16 = 9092
@@ -81,12 +94,147 @@ func adapterServiceBuilder(src *v1alpha1.ZendeskSource, cfg *adapterConfig) comm
 			resource.EnvVar(common.EnvNamespace, src.Namespace),
 			resource.EnvVar(common.EnvSink, sinkURIStr),
 			resource.EnvVar(envZdSubdomain, src.Spec.Subdomain),
-			resource.EnvVar(envZdWebhookUser, src.Spec.WebhookUsername),
-			resource.EnvVarFromSecret(envZdWebhookPwd,
-				src.Spec.WebhookPassword.SecretKeyRef.Name,
-				src.Spec.WebhookPassword.SecretKeyRef.Key),
+			resource.EnvVars(makeZendeskEnvs(src)...),
 			resource.EnvVar(common.EnvMetricsPrometheusPort, strconv.Itoa(int(metricsPrometheusPort))),
 			resource.EnvVars(cfg.configs.ToEnvVars()...),
 		)
 	}
 }
+
+// adapterDeploymentBuilder returns an AdapterDeploymentBuilderFunc for the
+// given source object and adapter config.
+// It is used in polling mode, where the adapter doesn't need to be reachable
+// by Zendesk, and when events are buffered on a persistent volume, which
+// Knative Services can't mount.
+func adapterDeploymentBuilder(src *v1alpha1.ZendeskSource, cfg *adapterConfig) common.AdapterDeploymentBuilderFunc {
+	adapterName := common.AdapterName(src)
+
+	return func(sinkURI *apis.URL) *appsv1.Deployment {
+		name := kmeta.ChildName(adapterName+"-", src.Name)
+
+		var sinkURIStr string
+		if sinkURI != nil {
+			sinkURIStr = sinkURI.String()
+		}
+
+		// only the adapter in polling mode accesses the Kubernetes API,
+		// to persist its cursor
+		var serviceAccount string
+		zdEnvs := makeZendeskEnvs(src)
+		if src.Spec.Polling != nil {
+			serviceAccount = common.AdapterServiceAccountName(src)
+			zdEnvs = makePollingEnvs(src)
+		}
+
+		return resource.NewDeployment(src.Namespace, name,
+			resource.Controller(src),
+
+			resource.Label(common.AppNameLabel, adapterName),
+			resource.Label(common.AppInstanceLabel, src.Name),
+			resource.Label(common.AppComponentLabel, common.AdapterComponent),
+			resource.Label(common.AppPartOfLabel, common.PartOf),
+			resource.Label(common.AppManagedByLabel, common.ManagedBy),
+
+			resource.Selector(common.AppNameLabel, adapterName),
+			resource.Selector(common.AppInstanceLabel, src.Name),
+			resource.PodLabel(common.AppComponentLabel, common.AdapterComponent),
+			resource.PodLabel(common.AppPartOfLabel, common.PartOf),
+			resource.PodLabel(common.AppManagedByLabel, common.ManagedBy),
+
+			resource.Image(cfg.Image),
+			resource.ServiceAccount(serviceAccount),
+
+			resource.EnvVar(common.EnvName, src.Name),
+			resource.EnvVar(common.EnvNamespace, src.Namespace),
+			resource.EnvVar(common.EnvSink, sinkURIStr),
+			resource.EnvVar(envZdSubdomain, src.Spec.Subdomain),
+			resource.EnvVars(zdEnvs...),
+			resource.EnvVar(common.EnvMetricsPrometheusPort, strconv.Itoa(int(metricsPrometheusPort))),
+			resource.EnvVars(cfg.configs.ToEnvVars()...),
+
+			buffer.AdapterOption(src.Spec.Buffer),
+		)
+	}
+}
+
+// makePollingEnvs returns the environment variables which configure the
+// polling of the Zendesk API by the adapter.
+func makePollingEnvs(src *v1alpha1.ZendeskSource) []corev1.EnvVar {
+	events := make([]string, 0, len(src.GetEvents()))
+	for _, ev := range src.GetEvents() {
+		events = append(events, string(ev))
+	}
+
+	return []corev1.EnvVar{
+		{
+			Name:  envZdEmail,
+			Value: src.Spec.Email,
+		}, {
+			Name: envZdAPIToken,
+			ValueFrom: &corev1.EnvVarSource{
+				SecretKeyRef: src.Spec.Token.SecretKeyRef,
+			},
+		}, {
+			Name:  envZdEvents,
+			Value: strings.Join(events, ","),
+		}, {
+			Name:  envZdPollingInterval,
+			Value: src.Spec.Polling.GetPollingInterval().String(),
+		}, {
+			Name:  envZdCursorConfigMap,
+			Value: cursorConfigMapName(src),
+		},
+	}
+}
+
+// makeZendeskEnvs returns the environment variables which allow the adapter
+// to authenticate the requests sent by Zendesk.
+// With the Webhooks notification API, the adapter retrieves the signing
+// secret of the source's webhook from the Zendesk API.
+func makeZendeskEnvs(src *v1alpha1.ZendeskSource) []corev1.EnvVar {
+	var zdEnvs []corev1.EnvVar
+
+	if src.GetNotificationAPI() == v1alpha1.ZendeskNotificationAPIWebhooks {
+		zdEnvs = append(zdEnvs,
+			corev1.EnvVar{
+				Name:  envZdEmail,
+				Value: src.Spec.Email,
+			},
+			corev1.EnvVar{
+				Name: envZdAPIToken,
+				ValueFrom: &corev1.EnvVarSource{
+					SecretKeyRef: src.Spec.Token.SecretKeyRef,
+				},
+			},
+			corev1.EnvVar{
+				Name:  envZdWebhookName,
+				Value: targetTitle(src),
+			},
+		)
+
+		if len(src.GetWebhookEventTypes()) > 0 {
+			zdEnvs = append(zdEnvs, corev1.EnvVar{
+				Name:  envZdEventsWebhookName,
+				Value: eventsWebhookName(src),
+			})
+		}
+
+		return zdEnvs
+	}
+
+	zdEnvs = append(zdEnvs, corev1.EnvVar{
+		Name:  envZdWebhookUser,
+		Value: src.Spec.WebhookUsername,
+	})
+
+	if pwd := src.Spec.WebhookPassword.SecretKeyRef; pwd != nil {
+		zdEnvs = append(zdEnvs, corev1.EnvVar{
+			Name: envZdWebhookPwd,
+			ValueFrom: &corev1.EnvVarSource{
+				SecretKeyRef: pwd,
+			},
+		})
+	}
+
+	return zdEnvs
+}
@@ -0,0 +1,355 @@
+/*
+Copyright (c) 2020 TriggerMesh Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package zendesksource
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/nukosuke/go-zendesk/zendesk"
+
+	"github.com/triggermesh/knative-sources/pkg/zendesk/rest"
+	"github.com/triggermesh/knative-sources/pkg/zendesk/webhooks"
+)
+
+// listPageSize is the number of objects requested per page when listing
+// objects from the Zendesk API.
+const listPageSize = 100
+
+// apiClient is a client of the Zendesk Targets and Triggers APIs which lists
+// the objects of all pages, instead of only the first one.
+type apiClient struct {
+	*zendesk.Client
+
+	// lists Targets, which the Zendesk client can't paginate
+	rest *rest.Client
+}
+
+var _ zendeskAPI = (*apiClient)(nil)
+
+// GetTargets implements zendesk.TargetAPI.
+func (c *apiClient) GetTargets(ctx context.Context) ([]zendesk.Target, zendesk.Page, error) {
+	var targets []zendesk.Target
+
+	for page := 1; ; page++ {
+		var resp struct {
+			Targets []zendesk.Target `json:"targets"`
+			zendesk.Page
+		}
+
+		query := url.Values{
+			"page":     {strconv.Itoa(page)},
+			"per_page": {strconv.Itoa(listPageSize)},
+		}
+		if err := c.rest.Do(ctx, http.MethodGet, "/targets.json?"+query.Encode(), nil, http.StatusOK, &resp); err != nil {
+			return nil, zendesk.Page{}, err
+		}
+
+		targets = append(targets, resp.Targets...)
+
+		if !resp.HasNext() || len(resp.Targets) == 0 {
+			return targets, zendesk.Page{Count: resp.Count}, nil
+		}
+	}
+}
+
+// GetTriggers implements zendesk.TriggerAPI.
+func (c *apiClient) GetTriggers(ctx context.Context,
+	opts *zendesk.TriggerListOptions) ([]zendesk.Trigger, zendesk.Page, error) {
+
+	var pageOpts zendesk.TriggerListOptions
+	if opts != nil {
+		pageOpts = *opts
+	}
+	pageOpts.PerPage = listPageSize
+
+	var triggers []zendesk.Trigger
+
+	for pageOpts.Page = 1; ; pageOpts.Page++ {
+		pageTriggers, page, err := c.Client.GetTriggers(ctx, &pageOpts)
+		if err != nil {
+			return nil, zendesk.Page{}, err
+		}
+
+		triggers = append(triggers, pageTriggers...)
+
+		if !page.HasNext() || len(pageTriggers) == 0 {
+			return triggers, zendesk.Page{Count: page.Count}, nil
+		}
+	}
+}
+
+// zendeskClients are the clients of the Zendesk APIs used by the reconciler
+// on behalf of a set of credentials.
+type zendeskClients struct {
+	api      *apiClient
+	webhooks *webhooks.Client
+
+	// rate limit shared by the clients
+	rateLimit *rateLimitTransport
+
+	// identifies the subdomain and credentials of the clients
+	fingerprint string
+}
+
+// newZendeskClients returns clients for the Zendesk APIs of the given
+// subdomain, authenticated with the given credentials. The clients share the
+// rate limit of the credentials.
+func newZendeskClients(creds *apiCredentials, subdomain string) (*zendeskClients, error) {
+	next := http.DefaultTransport
+	if creds.oauthClient != nil {
+		next = creds.oauthClient.Transport
+	}
+	rateLimit := &rateLimitTransport{next: next, now: time.Now}
+	httpClient := &http.Client{Transport: rateLimit}
+
+	client, err := zendesk.NewClient(httpClient)
+	if err != nil {
+		return nil, fmt.Errorf("creating Zendesk client: %w", err)
+	}
+	if err := client.SetSubdomain(subdomain); err != nil {
+		return nil, fmt.Errorf("setting Zendesk subdomain: %w", err)
+	}
+
+	var restClient *rest.Client
+	var whClient *webhooks.Client
+
+	if creds.oauthClient != nil {
+		// The Zendesk client sets Basic Authentication credentials
+		// on every request, which the OAuth transport overrides
+		// with the access token.
+		client.SetCredential(zendesk.NewBasicAuthCredential("", ""))
+
+		restClient = rest.NewClientWithHTTPClient(subdomain, httpClient)
+		whClient = webhooks.NewClientWithHTTPClient(subdomain, httpClient)
+
+	} else {
+		client.SetCredential(zendesk.NewAPITokenCredential(creds.email, creds.apiToken))
+
+		restClient = rest.NewClient(subdomain, creds.email, creds.apiToken)
+		restClient.SetHTTPClient(httpClient)
+		whClient = webhooks.NewClient(subdomain, creds.email, creds.apiToken)
+		whClient.SetHTTPClient(httpClient)
+	}
+
+	return &zendeskClients{
+		api: &apiClient{
+			Client: client,
+			rest:   restClient,
+		},
+		webhooks:  whClient,
+		rateLimit: rateLimit,
+	}, nil
+}
+
+// setEndpointURL overrides the base URL of the Zendesk APIs.
+func (c *zendeskClients) setEndpointURL(u string) error {
+	if err := c.api.SetEndpointURL(u); err != nil {
+		return fmt.Errorf("setting Zendesk endpoint URL: %w", err)
+	}
+	c.api.rest.SetEndpointURL(u)
+	c.webhooks.SetEndpointURL(u)
+	return nil
+}
+
+// clientCache caches the clients of the Zendesk API per set of credentials.
+// Rate limits of the Zendesk API apply to an account, so sources which share
+// credentials also share the knowledge of these limits.
+type clientCache struct {
+	mu sync.Mutex
+	// clients by fingerprint of their subdomain and credentials
+	clients map[string]*zendeskClients
+	// fingerprint of the clients used by each source object
+	users map[types.NamespacedName]string
+
+	// overrides the base URL of the Zendesk API (tests)
+	endpointURLOverride string
+}
+
+// clientsFor returns the clients of the Zendesk API of the given subdomain
+// which authenticate with the given credentials on behalf of the given source
+// object.
+func (c *clientCache) clientsFor(src types.NamespacedName, subdomain string,
+	creds *apiCredentials) (*zendeskClients, error) {
+
+	fp := fingerprint(subdomain, creds.fingerprint)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if prev, ok := c.users[src]; ok && prev != fp {
+		delete(c.users, src)
+		c.release(prev)
+	}
+
+	clients, ok := c.clients[fp]
+	if !ok {
+		var err error
+		if clients, err = newZendeskClients(creds, subdomain); err != nil {
+			return nil, err
+		}
+		if c.endpointURLOverride != "" {
+			if err := clients.setEndpointURL(c.endpointURLOverride); err != nil {
+				return nil, err
+			}
+		}
+
+		if c.clients == nil {
+			c.clients = make(map[string]*zendeskClients)
+			c.users = make(map[types.NamespacedName]string)
+		}
+		clients.fingerprint = fp
+		c.clients[fp] = clients
+	}
+	c.users[src] = fp
+
+	return clients, nil
+}
+
+// list returns the cached clients.
+func (c *clientCache) list() []*zendeskClients {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	clients := make([]*zendeskClients, 0, len(c.clients))
+	for _, cl := range c.clients {
+		clients = append(clients, cl)
+	}
+	return clients
+}
+
+// forget stops tracking the clients used by the given source object, and
+// removes them from the cache unless other sources use them. The removed
+// clients are returned, if any.
+func (c *clientCache) forget(src types.NamespacedName) *zendeskClients {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	fp, ok := c.users[src]
+	if !ok {
+		return nil
+	}
+	delete(c.users, src)
+
+	clients := c.clients[fp]
+	if !c.release(fp) {
+		return nil
+	}
+	return clients
+}
+
+// release removes the clients with the given fingerprint from the cache if no
+// source uses them, and returns whether they were removed. It must be called
+// with the lock held.
+func (c *clientCache) release(fp string) bool {
+	for _, used := range c.users {
+		if used == fp {
+			return false
+		}
+	}
+	delete(c.clients, fp)
+	return true
+}
+
+// defaultRetryAfter is the delay during which requests are withheld after the
+// Zendesk API rate limited a request without indicating a delay. Rate limits
+// of the Zendesk API are expressed per minute.
+// See: https://developer.zendesk.com/api-reference/ticketing/account-configuration/usage_limits/
+const defaultRetryAfter = time.Minute
+
+// rateLimitedError indicates that the Zendesk API rate limits requests, and
+// that no request should be sent before the given delay elapses.
+type rateLimitedError struct {
+	retryAfter time.Duration
+}
+
+// Error implements the error interface.
+// The message doesn't contain the delay, so that an error recorded in the
+// status of a source doesn't change between two rate limited attempts.
+func (*rateLimitedError) Error() string {
+	return "rate limited by the Zendesk API"
+}
+
+// Status returns the HTTP status code of rate limited responses.
+func (*rateLimitedError) Status() int {
+	return http.StatusTooManyRequests
+}
+
+// rateLimitTransport is a http.RoundTripper which turns rate limited responses
+// of the Zendesk API into rateLimitedErrors, and fails subsequent requests
+// without sending them until the delay requested by the API elapses.
+type rateLimitTransport struct {
+	next http.RoundTripper
+
+	mu      sync.Mutex
+	retryAt time.Time
+
+	now func() time.Time
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *rateLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	now := t.now()
+
+	t.mu.Lock()
+	wait := t.retryAt.Sub(now)
+	t.mu.Unlock()
+
+	if wait > 0 {
+		return nil, &rateLimitedError{retryAfter: wait}
+	}
+
+	resp, err := t.next.RoundTrip(req)
+	if err != nil || resp.StatusCode != http.StatusTooManyRequests {
+		return resp, err
+	}
+
+	_, _ = io.Copy(ioutil.Discard, resp.Body)
+	_ = resp.Body.Close()
+
+	retryAfter := parseRetryAfter(resp.Header.Get("Retry-After"), now)
+
+	t.mu.Lock()
+	if retryAt := now.Add(retryAfter); retryAt.After(t.retryAt) {
+		t.retryAt = retryAt
+	}
+	t.mu.Unlock()
+
+	return nil, &rateLimitedError{retryAfter: retryAfter}
+}
+
+// parseRetryAfter returns the delay expressed by the given value of a
+// Retry-After header, either as a number of seconds or as a HTTP date
+// relative to the given time.
+func parseRetryAfter(v string, now time.Time) time.Duration {
+	if secs, err := strconv.Atoi(v); err == nil && secs > 0 {
+		return time.Duration(secs) * time.Second
+	}
+	if t, err := http.ParseTime(v); err == nil && t.After(now) {
+		return t.Sub(now).Round(time.Second)
+	}
+	return defaultRetryAfter
+}
@@ -0,0 +1,236 @@
+/*
+Copyright (c) 2020 TriggerMesh Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package zendesksource
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+
+	"knative.dev/pkg/controller"
+
+	"github.com/nukosuke/go-zendesk/zendesk"
+
+	"github.com/triggermesh/knative-sources/pkg/apis/sources/v1alpha1"
+	"github.com/triggermesh/knative-sources/pkg/zendesk/webhooks"
+)
+
+func TestAPIClientListsAllPages(t *testing.T) {
+	const numTargets, numTriggers, numWebhooks = 150, 250, 120
+
+	api := newFakeZendeskAPI()
+	for i := 0; i < numTargets; i++ {
+		api.targets = append(api.targets, zendesk.Target{ID: int64(i + 1), Title: fmt.Sprint("target-", i)})
+	}
+	for i := 0; i < numTriggers; i++ {
+		api.triggers = append(api.triggers, zendesk.Trigger{ID: int64(i + 1), Title: fmt.Sprint("trigger-", i)})
+	}
+	// the name of the last webhook is contained in the names of all others
+	for i := 0; i < numWebhooks; i++ {
+		api.webhooks = append(api.webhooks, webhooks.Webhook{ID: fmt.Sprint("01WEBHOOK", i), Name: fmt.Sprint("webhook-", i)})
+	}
+	api.webhooks[numWebhooks-1].Name = "webhook"
+
+	srv := httptest.NewServer(api)
+	defer srv.Close()
+
+	client, whClient := newFakeZendeskClients(t, srv.URL, "api-token")
+
+	ctx := context.Background()
+
+	targets, page, err := client.GetTargets(ctx)
+	require.NoError(t, err)
+	assert.Len(t, targets, numTargets)
+	assert.EqualValues(t, numTargets, page.Count)
+	assert.False(t, page.HasNext(), "Page of all Targets has a next page")
+
+	triggers, page, err := client.GetTriggers(ctx, &zendesk.TriggerListOptions{})
+	require.NoError(t, err)
+	assert.Len(t, triggers, numTriggers)
+	assert.EqualValues(t, numTriggers, page.Count)
+	assert.False(t, page.HasNext(), "Page of all Triggers has a next page")
+
+	wh, err := whClient.FindWebhook(ctx, "webhook")
+	require.NoError(t, err)
+	if assert.NotNil(t, wh, "Webhook of the last page was not found") {
+		assert.Equal(t, fmt.Sprint("01WEBHOOK", numWebhooks-1), wh.ID)
+	}
+
+	assert.Equal(t, []string{
+		"GET /targets.json", "GET /targets.json",
+		"GET /triggers.json", "GET /triggers.json", "GET /triggers.json",
+		"GET /webhooks", "GET /webhooks",
+	}, api.listRequests())
+}
+
+func TestRateLimitTransport(t *testing.T) {
+	var numRequests int32
+	var rateLimited atomic.Value
+	rateLimited.Store(true)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&numRequests, 1)
+		if rateLimited.Load().(bool) {
+			w.Header().Set("Retry-After", "30")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{"triggers": []interface{}{}})
+	}))
+	defer srv.Close()
+
+	clients, err := newZendeskClients(&apiCredentials{email: "me@example.com", apiToken: "api-token"}, "example")
+	require.NoError(t, err)
+	require.NoError(t, clients.setEndpointURL(srv.URL))
+
+	now := time.Date(2020, 11, 1, 0, 0, 0, 0, time.UTC)
+	clients.rateLimit.now = func() time.Time { return now }
+
+	listTriggers := func() error {
+		_, _, err := clients.api.GetTriggers(context.Background(), &zendesk.TriggerListOptions{})
+		return err
+	}
+
+	err = listTriggers()
+	var rlErr *rateLimitedError
+	if assert.True(t, errors.As(err, &rlErr), "Expected a rate limited error, got %v", err) {
+		assert.Equal(t, 30*time.Second, rlErr.retryAfter)
+	}
+	assert.Equal(t, http.StatusTooManyRequests, apiStatusCode(err))
+	assert.EqualValues(t, 1, atomic.LoadInt32(&numRequests))
+
+	// requests are withheld until the delay elapses
+	rateLimited.Store(false)
+	now = now.Add(20 * time.Second)
+
+	err = listTriggers()
+	if assert.True(t, errors.As(err, &rlErr), "Expected a rate limited error, got %v", err) {
+		assert.Equal(t, 10*time.Second, rlErr.retryAfter)
+	}
+	assert.EqualValues(t, 1, atomic.LoadInt32(&numRequests), "Request was sent while rate limited")
+
+	now = now.Add(10 * time.Second)
+
+	assert.NoError(t, listTriggers())
+	assert.EqualValues(t, 2, atomic.LoadInt32(&numRequests))
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2020, 11, 1, 0, 0, 0, 0, time.UTC)
+
+	tc := map[string]struct {
+		value  string
+		expect time.Duration
+	}{
+		"seconds":     {value: "42", expect: 42 * time.Second},
+		"HTTP date":   {value: now.Add(90 * time.Second).Format(http.TimeFormat), expect: 90 * time.Second},
+		"past date":   {value: now.Add(-time.Hour).Format(http.TimeFormat), expect: defaultRetryAfter},
+		"zero":        {value: "0", expect: defaultRetryAfter},
+		"missing":     {value: "", expect: defaultRetryAfter},
+		"unparseable": {value: "soon", expect: defaultRetryAfter},
+	}
+
+	for name, c := range tc {
+		//nolint:scopelint
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, c.expect, parseRetryAfter(c.value, now))
+		})
+	}
+}
+
+func TestClientCache(t *testing.T) {
+	srcA := types.NamespacedName{Namespace: "test", Name: "source-a"}
+	srcB := types.NamespacedName{Namespace: "test", Name: "source-b"}
+
+	creds := func(apiToken string) *apiCredentials {
+		return &apiCredentials{
+			email:       "me@example.com",
+			apiToken:    apiToken,
+			fingerprint: fingerprint("token", "me@example.com", apiToken),
+		}
+	}
+
+	var c clientCache
+
+	clientsA, err := c.clientsFor(srcA, "example", creds("token-1"))
+	require.NoError(t, err)
+	clientsB, err := c.clientsFor(srcB, "example", creds("token-1"))
+	require.NoError(t, err)
+	assert.Same(t, clientsA, clientsB, "Sources with the same credentials should share clients")
+
+	clientsA2, err := c.clientsFor(srcA, "example", creds("token-1"))
+	require.NoError(t, err)
+	assert.Same(t, clientsA, clientsA2, "Clients should be reused across calls")
+
+	clientsOtherSubdomain, err := c.clientsFor(srcA, "other", creds("token-1"))
+	require.NoError(t, err)
+	assert.NotSame(t, clientsA, clientsOtherSubdomain, "Clients of different subdomains should differ")
+	assert.Len(t, c.clients, 2, "Clients still used by another source were removed")
+
+	// rotation of the credentials of source B
+	clientsB2, err := c.clientsFor(srcB, "example", creds("token-2"))
+	require.NoError(t, err)
+	assert.NotSame(t, clientsB, clientsB2, "Clients should be replaced when credentials change")
+	assert.Len(t, c.clients, 2, "Clients no longer used by any source were not removed")
+
+	c.forget(srcA)
+	c.forget(srcB)
+	assert.Empty(t, c.clients)
+	assert.Empty(t, c.users)
+}
+
+func TestRequeueIfRateLimited(t *testing.T) {
+	src := &v1alpha1.ZendeskSource{}
+	src.Namespace = "test"
+	src.Name = "my-source"
+
+	var enqueuedKey types.NamespacedName
+	var enqueuedDelay time.Duration
+
+	r := &Reconciler{
+		enqueueKeyAfter: func(key types.NamespacedName, delay time.Duration) {
+			enqueuedKey, enqueuedDelay = key, delay
+		},
+	}
+
+	rec := record.NewFakeRecorder(10)
+	ctx := controller.WithEventRecorder(context.Background(), rec)
+
+	otherErr := errors.New("some error")
+	assert.Equal(t, otherErr, r.requeueIfRateLimited(ctx, src, otherErr))
+	assert.Nil(t, r.requeueIfRateLimited(ctx, src, nil))
+	assert.Empty(t, enqueuedKey.Name, "Source was requeued")
+
+	rlErr := fmt.Errorf("retrieving Zendesk Target: %w", &rateLimitedError{retryAfter: 42 * time.Second})
+	assert.NoError(t, r.requeueIfRateLimited(ctx, src, rlErr))
+	assert.Equal(t, types.NamespacedName{Namespace: "test", Name: "my-source"}, enqueuedKey)
+	assert.Equal(t, 42*time.Second, enqueuedDelay)
+	assert.Equal(t, []string{
+		"Warning APIRateLimited Requests to the Zendesk API are rate limited. Retrying in 42s",
+	}, recordedEvents(rec))
+}
@@ -22,6 +22,8 @@ import (
 
 	"github.com/kelseyhightower/envconfig"
 
+	"k8s.io/client-go/tools/cache"
+
 	"knative.dev/eventing/pkg/reconciler/source"
 	"knative.dev/pkg/configmap"
 	"knative.dev/pkg/controller"
@@ -49,15 +51,26 @@ func NewController(
 	// Calling envconfig.Process() with a prefix appends that prefix
 	// (uppercased) to the Go field name, e.g. MYSOURCE_IMAGE.
 	adapterCfg := &adapterConfig{
-		configs: source.WatchConfigurations(ctx, app, cmw, source.WithLogging, source.WithMetrics),
+		configs: source.WatchConfigurations(ctx, app, cmw, source.WithLogging, source.WithMetrics, source.WithTracing),
 	}
 	envconfig.MustProcess(app, adapterCfg)
 
+	gcCfg := gcConfig{}
+	envconfig.MustProcess(app, &gcCfg)
+
+	informer := informerv1alpha1.Get(ctx)
+
 	r := &Reconciler{
 		adapterCfg: adapterCfg,
 		kubeClient: kubeclient.Get(ctx),
 	}
+	r.orphans = orphanCollector{
+		cfg:     gcCfg,
+		lister:  informer.Lister(),
+		clients: &r.zendeskClients,
+	}
 	impl := reconcilerv1alpha1.NewImpl(ctx, r)
+	r.enqueueKeyAfter = impl.EnqueueKeyAfter
 
 	r.base = common.NewGenericServiceReconciler(
 		ctx,
@@ -66,7 +79,24 @@ func NewController(
 		impl.EnqueueControllerOf,
 	)
 
-	informerv1alpha1.Get(ctx).Informer().AddEventHandlerWithResyncPeriod(controller.HandleAll(impl.Enqueue), informerResyncPeriod)
+	r.deploymentBase = common.NewGenericAddressableDeploymentReconciler(
+		ctx,
+		typ.GetGroupVersionKind(),
+		impl.EnqueueKey,
+		impl.EnqueueControllerOf,
+	)
+
+	r.configMapBase = common.NewGenericConfigMapReconciler(ctx)
+
+	informer.Informer().AddEventHandlerWithResyncPeriod(controller.HandleAll(impl.Enqueue), informerResyncPeriod)
+
+	// sources deleted without being finalized leave orphaned Zendesk
+	// objects behind
+	informer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		DeleteFunc: r.handleDelete(),
+	})
+
+	go r.orphans.run(ctx)
 
 	return impl
 }
@@ -0,0 +1,211 @@
+/*
+Copyright (c) 2020 TriggerMesh, Inc
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package zendesksource
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/clientcredentials"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"knative.dev/pkg/controller"
+	"knative.dev/pkg/reconciler"
+
+	"github.com/triggermesh/knative-sources/pkg/apis/sources/v1alpha1"
+	"github.com/triggermesh/knative-sources/pkg/reconciler/common"
+)
+
+// apiCredentials authenticate the requests sent to the Zendesk API by the
+// controller, either with the API token of a user or with an OAuth access
+// token.
+type apiCredentials struct {
+	// API token authentication
+	email    string
+	apiToken string
+
+	// OAuth authentication
+	oauthClient *http.Client
+
+	// secret value used as a key for digests of other credentials
+	secret string
+
+	// identifies the credentials, e.g. to cache API clients
+	fingerprint string
+}
+
+// apiCredentialsFor returns the credentials used by the controller to
+// authenticate against the Zendesk API on behalf of the given source.
+func (r *Reconciler) apiCredentialsFor(ctx context.Context, src *v1alpha1.ZendeskSource) (*apiCredentials, error) {
+	oauth := src.Spec.OAuth
+
+	switch {
+	case oauth == nil:
+		if src.Spec.Token.SecretKeyRef == nil {
+			return nil, controller.NewPermanentError(reconciler.NewEvent(corev1.EventTypeWarning,
+				common.ReasonInvalidSpec, "Either an API token or OAuth credentials are required"))
+		}
+
+		apiToken, err := r.secretFrom(ctx, src.Namespace, src.Spec.Token.SecretKeyRef)
+		if err != nil {
+			return nil, err
+		}
+
+		return &apiCredentials{
+			email:       src.Spec.Email,
+			apiToken:    apiToken,
+			secret:      apiToken,
+			fingerprint: fingerprint("token", src.Spec.Email, apiToken),
+		}, nil
+
+	case oauth.AccessToken != nil && oauth.AccessToken.SecretKeyRef != nil:
+		accessToken, err := r.secretFrom(ctx, src.Namespace, oauth.AccessToken.SecretKeyRef)
+		if err != nil {
+			return nil, err
+		}
+
+		ts := oauth2.StaticTokenSource(&oauth2.Token{AccessToken: accessToken})
+
+		return &apiCredentials{
+			oauthClient: oauth2.NewClient(context.Background(), ts),
+			secret:      accessToken,
+			fingerprint: fingerprint("accessToken", accessToken),
+		}, nil
+
+	case oauth.ClientCredentials != nil && oauth.ClientCredentials.ClientSecret.SecretKeyRef != nil:
+		cc := oauth.ClientCredentials
+
+		clientSecret, err := r.secretFrom(ctx, src.Namespace, cc.ClientSecret.SecretKeyRef)
+		if err != nil {
+			return nil, err
+		}
+
+		cfg := &clientcredentials.Config{
+			ClientID:     cc.ClientID,
+			ClientSecret: clientSecret,
+			TokenURL:     r.oauthTokens.tokenURL(src.Spec.Subdomain),
+			Scopes:       cc.GetScopes(),
+			// Zendesk expects the client credentials in the body
+			AuthStyle: oauth2.AuthStyleInParams,
+		}
+		ts := r.oauthTokens.tokenSource(sourceKey(src), cfg)
+
+		return &apiCredentials{
+			oauthClient: oauth2.NewClient(context.Background(), ts),
+			secret:      clientSecret,
+			fingerprint: fingerprint("clientCredentials", clientCredentialsFingerprint(cfg)),
+		}, nil
+
+	default:
+		return nil, controller.NewPermanentError(reconciler.NewEvent(corev1.EventTypeWarning,
+			common.ReasonInvalidSpec, "OAuth credentials require either an access token or client credentials"))
+	}
+}
+
+// tokenSourceCache caches the OAuth token sources of sources which
+// authenticate with client credentials, so that access tokens are reused
+// across reconciliations until they expire.
+type tokenSourceCache struct {
+	mu      sync.Mutex
+	entries map[types.NamespacedName]tokenSourceEntry
+
+	// overrides the URL of the OAuth token endpoint of Zendesk (tests)
+	tokenURLOverride string
+}
+
+// tokenSourceEntry is a token source, and the fingerprint of the client
+// credentials it obtains access tokens with.
+type tokenSourceEntry struct {
+	fingerprint string
+	ts          oauth2.TokenSource
+}
+
+// tokenURL returns the URL of the OAuth token endpoint of the given Zendesk
+// subdomain.
+func (c *tokenSourceCache) tokenURL(subdomain string) string {
+	if c.tokenURLOverride != "" {
+		return c.tokenURLOverride
+	}
+	return "https://" + subdomain + ".zendesk.com/oauth/tokens"
+}
+
+// tokenSource returns the token source of the given source object, which is
+// created from the given client credentials if they changed since the last
+// call.
+func (c *tokenSourceCache) tokenSource(src types.NamespacedName, cfg *clientcredentials.Config) oauth2.TokenSource {
+	fp := clientCredentialsFingerprint(cfg)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if e, ok := c.entries[src]; ok && e.fingerprint == fp {
+		return e.ts
+	}
+
+	if c.entries == nil {
+		c.entries = make(map[types.NamespacedName]tokenSourceEntry)
+	}
+
+	// the token source outlives the current reconciliation
+	ts := cfg.TokenSource(context.Background())
+	c.entries[src] = tokenSourceEntry{fingerprint: fp, ts: ts}
+
+	return ts
+}
+
+// forget removes the token source of the given source object from the cache.
+func (c *tokenSourceCache) forget(src types.NamespacedName) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.entries, src)
+}
+
+// clientCredentialsFingerprint returns a fingerprint of the given client
+// credentials.
+func clientCredentialsFingerprint(cfg *clientcredentials.Config) string {
+	return fingerprint(cfg.TokenURL, cfg.ClientID, cfg.ClientSecret, strings.Join(cfg.Scopes, " "))
+}
+
+// fingerprint returns a fingerprint of the given values.
+func fingerprint(values ...string) string {
+	h := sha256.New()
+	for _, v := range values {
+		_, _ = h.Write([]byte(v))
+		_, _ = h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// isOAuthDenied returns whether the given error indicates that the OAuth
+// token endpoint rejected the client credentials.
+func isOAuthDenied(err error) bool {
+	var retrieveErr *oauth2.RetrieveError
+	if errors.As(err, &retrieveErr) && retrieveErr.Response != nil {
+		s := retrieveErr.Response.StatusCode
+		return s == http.StatusBadRequest || s == http.StatusUnauthorized || s == http.StatusForbidden
+	}
+	return false
+}
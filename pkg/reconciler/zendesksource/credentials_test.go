@@ -0,0 +1,205 @@
+/*
+Copyright (c) 2020 TriggerMesh, Inc
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package zendesksource
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"knative.dev/pkg/controller"
+
+	"github.com/nukosuke/go-zendesk/zendesk"
+
+	"github.com/triggermesh/knative-sources/pkg/apis/sources/v1alpha1"
+)
+
+func TestAPICredentials(t *testing.T) {
+	const secretName = "zendesk"
+
+	kubeClient := fake.NewSimpleClientset(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "test",
+			Name:      secretName,
+		},
+		Data: map[string][]byte{
+			"apiToken":     []byte("api-token"),
+			"accessToken":  []byte("access-token"),
+			"clientSecret": []byte("client-secret"),
+		},
+	})
+
+	secretRef := func(key string) *v1alpha1.SecretValueFromSource {
+		return &v1alpha1.SecretValueFromSource{
+			SecretKeyRef: &corev1.SecretKeySelector{
+				LocalObjectReference: corev1.LocalObjectReference{Name: secretName},
+				Key:                  key,
+			},
+		}
+	}
+
+	// fake Zendesk API, which records the Authorization header of
+	// requests, and issues access tokens
+	var mu sync.Mutex
+	var authHeaders []string
+	var tokenRequests []*http.Request
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/triggers.json", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		authHeaders = append(authHeaders, r.Header.Get("Authorization"))
+		mu.Unlock()
+		_, _ = w.Write([]byte(`{"triggers":[]}`))
+	})
+	mux.HandleFunc("/oauth/tokens", func(w http.ResponseWriter, r *http.Request) {
+		_ = r.ParseForm()
+		mu.Lock()
+		tokenRequests = append(tokenRequests, r)
+		mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"access_token":"exchanged-token","token_type":"bearer","expires_in":3600}`))
+	})
+
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	reset := func() {
+		mu.Lock()
+		defer mu.Unlock()
+		authHeaders = nil
+		tokenRequests = nil
+	}
+
+	// sendRequest sends a request to the fake Zendesk API using the given
+	// credentials.
+	sendRequest := func(t *testing.T, creds *apiCredentials) {
+		t.Helper()
+
+		clients, err := newZendeskClients(creds, "example")
+		require.NoError(t, err)
+		require.NoError(t, clients.setEndpointURL(srv.URL))
+
+		_, _, err = clients.api.GetTriggers(context.Background(), &zendesk.TriggerListOptions{})
+		require.NoError(t, err)
+	}
+
+	newSource := func(token *v1alpha1.SecretValueFromSource, oauth *v1alpha1.ZendeskOAuth) *v1alpha1.ZendeskSource {
+		src := &v1alpha1.ZendeskSource{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: "test",
+				Name:      "my-source",
+			},
+			Spec: v1alpha1.ZendeskSourceSpec{
+				Subdomain: "example",
+				Email:     "me@example.com",
+				OAuth:     oauth,
+			},
+		}
+		if token != nil {
+			src.Spec.Token = *token
+		}
+		return src
+	}
+
+	t.Run("API token", func(t *testing.T) {
+		reset()
+		r := &Reconciler{kubeClient: kubeClient}
+
+		creds, err := r.apiCredentialsFor(context.Background(), newSource(secretRef("apiToken"), nil))
+		require.NoError(t, err)
+
+		sendRequest(t, creds)
+
+		req := &http.Request{Header: http.Header{"Authorization": authHeaders}}
+		user, pass, ok := req.BasicAuth()
+		assert.True(t, ok, "Request should use Basic Authentication")
+		assert.Equal(t, "me@example.com/token", user)
+		assert.Equal(t, "api-token", pass)
+	})
+
+	t.Run("OAuth access token", func(t *testing.T) {
+		reset()
+		r := &Reconciler{kubeClient: kubeClient}
+
+		creds, err := r.apiCredentialsFor(context.Background(), newSource(nil, &v1alpha1.ZendeskOAuth{
+			AccessToken: secretRef("accessToken"),
+		}))
+		require.NoError(t, err)
+
+		sendRequest(t, creds)
+
+		assert.Equal(t, []string{"Bearer access-token"}, authHeaders)
+	})
+
+	t.Run("OAuth client credentials", func(t *testing.T) {
+		reset()
+		r := &Reconciler{kubeClient: kubeClient}
+		r.oauthTokens.tokenURLOverride = srv.URL + "/oauth/tokens"
+
+		src := newSource(nil, &v1alpha1.ZendeskOAuth{
+			ClientCredentials: &v1alpha1.ZendeskOAuthClientCredentials{
+				ClientID:     "my-client",
+				ClientSecret: *secretRef("clientSecret"),
+			},
+		})
+
+		// access tokens are reused across reconciliations
+		for i := 0; i < 2; i++ {
+			creds, err := r.apiCredentialsFor(context.Background(), src)
+			require.NoError(t, err)
+			sendRequest(t, creds)
+		}
+
+		assert.Equal(t, []string{"Bearer exchanged-token", "Bearer exchanged-token"}, authHeaders)
+
+		require.Len(t, tokenRequests, 1, "Access token should be requested once")
+		form := tokenRequests[0].PostForm
+		assert.Equal(t, "client_credentials", form.Get("grant_type"))
+		assert.Equal(t, "my-client", form.Get("client_id"))
+		assert.Equal(t, "client-secret", form.Get("client_secret"))
+		assert.Equal(t, "triggers:read triggers:write targets:read targets:write webhooks:read webhooks:write",
+			form.Get("scope"))
+
+		// a change of client credentials invalidates the access token
+		src.Spec.OAuth.ClientCredentials.Scopes = []string{"triggers:write"}
+
+		creds, err := r.apiCredentialsFor(context.Background(), src)
+		require.NoError(t, err)
+		sendRequest(t, creds)
+
+		assert.Len(t, tokenRequests, 2, "Access token should be requested again")
+	})
+
+	t.Run("no credentials", func(t *testing.T) {
+		r := &Reconciler{kubeClient: kubeClient}
+
+		_, err := r.apiCredentialsFor(context.Background(), newSource(nil, nil))
+		assert.True(t, controller.IsPermanentError(err), "Missing credentials should not be retried")
+
+		_, err = r.apiCredentialsFor(context.Background(), newSource(nil, &v1alpha1.ZendeskOAuth{}))
+		assert.True(t, controller.IsPermanentError(err), "Missing OAuth credentials should not be retried")
+	})
+}
@@ -0,0 +1,49 @@
+/*
+Copyright (c) 2020 TriggerMesh, Inc
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package zendesksource
+
+import (
+	"context"
+
+	"knative.dev/pkg/kmeta"
+
+	"github.com/triggermesh/knative-sources/pkg/apis/sources/v1alpha1"
+	"github.com/triggermesh/knative-sources/pkg/reconciler/common/skip"
+)
+
+// In polling mode, the adapter persists the position it reached in the stream
+// of Zendesk ticket events (cursor) in a ConfigMap owned by the source, so
+// that restarts neither replay nor skip events. The adapter runs with a
+// dedicated ServiceAccount which is only allowed to read and patch that
+// ConfigMap.
+
+// cursorConfigMapName returns the name of the ConfigMap in which the adapter
+// of the given source persists its polling cursor.
+func cursorConfigMapName(src *v1alpha1.ZendeskSource) string {
+	return kmeta.ChildName(src.Name, "-zendesk-cursor")
+}
+
+// reconcileCursor ensures the adapter of the source is able to persist its
+// polling cursor.
+func (r *Reconciler) reconcileCursor(ctx context.Context, src *v1alpha1.ZendeskSource) error {
+	if skip.Skip(ctx) {
+		return nil
+	}
+
+	_, err := r.configMapBase.ReconcileAdapterConfigMap(ctx, src, cursorConfigMapName(src), "get", "patch")
+	return err
+}
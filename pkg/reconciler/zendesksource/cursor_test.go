@@ -0,0 +1,95 @@
+/*
+Copyright (c) 2020 TriggerMesh, Inc
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package zendesksource
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
+
+	"knative.dev/pkg/controller"
+
+	"github.com/triggermesh/knative-sources/pkg/apis/sources/v1alpha1"
+	"github.com/triggermesh/knative-sources/pkg/reconciler/common"
+	. "github.com/triggermesh/knative-sources/pkg/reconciler/testing"
+)
+
+func TestReconcileCursor(t *testing.T) {
+	tc := map[string]struct {
+		cursorData map[string]string
+	}{
+		"no cursor ConfigMap": {},
+		"cursor written by the adapter": {
+			cursorData: map[string]string{
+				"startTime": "1605002400",
+			},
+		},
+	}
+
+	for name, c := range tc {
+		//nolint:scopelint
+		t.Run(name, func(t *testing.T) {
+			src := &v1alpha1.ZendeskSource{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: "test",
+					Name:      "my-source",
+				},
+			}
+
+			var objects []runtime.Object
+			if c.cursorData != nil {
+				cm := common.NewAdapterConfigMap(src, cursorConfigMapName(src))
+				cm.Data = c.cursorData
+				objects = append(objects, cm)
+			}
+
+			kubeClient := fake.NewSimpleClientset(objects...)
+			ls := NewListers(NewScheme(), objects)
+
+			r := &Reconciler{configMapBase: NewConfigMapReconciler(kubeClient, &ls)}
+
+			ctx := controller.WithEventRecorder(context.Background(), record.NewFakeRecorder(10))
+			ctx = v1alpha1.WithSource(ctx, src)
+
+			err := r.reconcileCursor(ctx, src)
+			assert.NoError(t, err)
+
+			cm, err := kubeClient.CoreV1().ConfigMaps("test").Get(ctx, "my-source-zendesk-cursor", metav1.GetOptions{})
+			if assert.NoError(t, err, "cursor ConfigMap was not created") {
+				// the cursor is owned by the adapter
+				assert.Equal(t, c.cursorData, cm.Data)
+			}
+
+			_, err = kubeClient.CoreV1().ServiceAccounts("test").Get(ctx, "zendesksource-my-source", metav1.GetOptions{})
+			assert.NoError(t, err, "adapter ServiceAccount was not created")
+
+			role, err := kubeClient.RbacV1().Roles("test").Get(ctx, "zendesksource-my-source", metav1.GetOptions{})
+			if assert.NoError(t, err, "cursor Role was not created") {
+				assert.Equal(t, []string{"my-source-zendesk-cursor"}, role.Rules[0].ResourceNames)
+			}
+
+			_, err = kubeClient.RbacV1().RoleBindings("test").Get(ctx, "zendesksource-my-source", metav1.GetOptions{})
+			assert.NoError(t, err, "cursor RoleBinding was not created")
+		})
+	}
+}
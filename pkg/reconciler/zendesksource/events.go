@@ -17,8 +17,12 @@ limitations under the License.
 package zendesksource
 
 const (
+	// ReasonTargetUpdated indicates the successful update of a Zendesk Target/Trigger which drifted from its desired state.
+	ReasonTargetUpdated = "TargetUpdated"
 	// ReasonTargetDeleted indicates the successful deletion of a Zendesk Target/Trigger.
 	ReasonTargetDeleted = "TargetDeleted"
 	// ReasonFailedTargetDelete indicates a failure during the deletion of a Zendesk Target/Trigger.
 	ReasonFailedTargetDelete = "FailedTargetDelete"
+	// ReasonAPIRateLimited indicates that the synchronization of Zendesk Targets/Triggers was postponed due to API rate limits.
+	ReasonAPIRateLimited = "APIRateLimited"
 )
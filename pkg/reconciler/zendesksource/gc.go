@@ -0,0 +1,278 @@
+/*
+Copyright (c) 2020 TriggerMesh Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package zendesksource
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/cache"
+	"knative.dev/pkg/logging"
+
+	"github.com/nukosuke/go-zendesk/zendesk"
+
+	"github.com/triggermesh/knative-sources/pkg/apis/sources/v1alpha1"
+	listersv1alpha1 "github.com/triggermesh/knative-sources/pkg/client/generated/listers/sources/v1alpha1"
+)
+
+// gcConfig contains the configuration of the garbage collection of Zendesk
+// objects left behind by deleted sources.
+type gcConfig struct {
+	// Interval between two sweeps of orphaned Zendesk objects. Zero
+	// disables the garbage collection.
+	Interval time.Duration `envconfig:"GC_INTERVAL" default:"1h"`
+	// Report orphaned Zendesk objects without deleting them. Titles of
+	// Zendesk objects don't identify the cluster of their source, so
+	// orphans are only deleted when opted in, by controllers which are the
+	// only ones to manage sources in their Zendesk accounts.
+	DryRun bool `envconfig:"GC_DRY_RUN" default:"true"`
+}
+
+// orphanCollector collects the Zendesk Targets, webhooks and Triggers left
+// behind by sources which no longer exist ("orphans"), e.g. because their
+// finalizer was removed before it could run.
+//
+// Orphans are swept at every interval, in the Zendesk account of each set of
+// credentials used by existing sources, and of each set of credentials last
+// used by a source deleted without being finalized. Only the leader
+// reconciles sources and caches their credentials, so other replicas of the
+// controller have nothing to sweep. Orphans are reported in the logs of the
+// controller, since the sources they belong to no longer exist.
+type orphanCollector struct {
+	cfg    gcConfig
+	lister listersv1alpha1.ZendeskSourceLister
+	// clients of the Zendesk API used by existing sources
+	clients *clientCache
+
+	mu sync.Mutex
+	// clients last used by deleted sources, by fingerprint
+	pending map[string]*zendeskClients
+}
+
+// run sweeps orphans at every interval until the given context is cancelled.
+func (c *orphanCollector) run(ctx context.Context) {
+	if c.cfg.Interval <= 0 {
+		return
+	}
+
+	t := time.NewTicker(c.cfg.Interval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+			c.sweep(ctx)
+		}
+	}
+}
+
+// sweep collects the orphans which can be accessed with the clients of
+// existing sources and with the clients of deleted sources.
+func (c *orphanCollector) sweep(ctx context.Context) {
+	for _, clients := range c.sweptClients() {
+		if err := c.collect(ctx, clients.api, clients.webhooks); err != nil {
+			logging.FromContext(ctx).Errorw("Failed to collect orphaned Zendesk objects", zap.Error(err))
+		}
+	}
+}
+
+// sweptClients returns the clients to sweep orphans with, once per set of
+// credentials, and forgets the clients of deleted sources.
+func (c *orphanCollector) sweptClients() []*zendeskClients {
+	c.mu.Lock()
+	byFingerprint := c.pending
+	c.pending = nil
+	c.mu.Unlock()
+
+	if byFingerprint == nil {
+		byFingerprint = make(map[string]*zendeskClients)
+	}
+	if c.clients != nil {
+		for _, clients := range c.clients.list() {
+			byFingerprint[clients.fingerprint] = clients
+		}
+	}
+
+	swept := make([]*zendeskClients, 0, len(byFingerprint))
+	for _, clients := range byFingerprint {
+		swept = append(swept, clients)
+	}
+	return swept
+}
+
+// enqueue schedules a sweep with the given clients, which are no longer used
+// by any source, at the next interval.
+func (c *orphanCollector) enqueue(clients *zendeskClients) {
+	if c.cfg.Interval <= 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.pending == nil {
+		c.pending = make(map[string]*zendeskClients)
+	}
+	c.pending[clients.fingerprint] = clients
+}
+
+// collect deletes the orphaned Zendesk objects which can be accessed with the
+// given clients, or only reports them in dry-run mode. Triggers are deleted
+// first, since a Target or webhook can't be deleted while a Trigger notifies
+// it.
+func (c *orphanCollector) collect(ctx context.Context, client zendeskAPI, whClient webhookAPI) error {
+	triggers, _, err := client.GetTriggers(ctx, &zendesk.TriggerListOptions{})
+	if err != nil {
+		return fmt.Errorf("retrieving Zendesk Triggers: %w", err)
+	}
+	for _, t := range triggers {
+		id := t.ID
+		err := c.handleOrphan(ctx, "Trigger", t.Title, func() error {
+			return client.DeleteTrigger(ctx, id)
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	targets, _, err := client.GetTargets(ctx)
+	if err != nil {
+		return fmt.Errorf("retrieving Zendesk Targets: %w", err)
+	}
+	for _, t := range targets {
+		id := t.ID
+		err := c.handleOrphan(ctx, "Target", t.Title, func() error {
+			return client.DeleteTarget(ctx, id)
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	whs, err := whClient.ListWebhooks(ctx, titlePrefix)
+	if err != nil {
+		return fmt.Errorf("retrieving Zendesk webhooks: %w", err)
+	}
+	for _, wh := range whs {
+		id := wh.ID
+		err := c.handleOrphan(ctx, "webhook", wh.Name, func() error {
+			return whClient.DeleteWebhook(ctx, id)
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// handleOrphan deletes the Zendesk object of the given kind and title using
+// the given function if it is an orphan, or only reports it in dry-run mode.
+func (c *orphanCollector) handleOrphan(ctx context.Context, kind, title string, deleteFn func() error) error {
+	owner, isOrphan := c.orphanOwner(title)
+	if !isOrphan {
+		return nil
+	}
+
+	logger := logging.FromContext(ctx).With(
+		zap.String("kind", kind),
+		zap.String("title", title),
+		zap.String("source", owner.String()),
+	)
+
+	if c.cfg.DryRun {
+		logger.Warn("Found orphaned Zendesk object of a deleted source (dry run)")
+		return nil
+	}
+
+	if err := deleteFn(); err != nil && !isNotFound(err) {
+		return fmt.Errorf("deleting Zendesk %s %q: %w", kind, title, err)
+	}
+	logger.Info("Deleted orphaned Zendesk object of a deleted source")
+
+	return nil
+}
+
+// orphanOwner returns the source which the Zendesk object with the given title
+// was created for, and whether this source no longer exists.
+func (c *orphanCollector) orphanOwner(title string) (types.NamespacedName, bool) {
+	owner, ok := sourceOfTitle(title)
+	if !ok {
+		return owner, false
+	}
+
+	_, err := c.lister.ZendeskSources(owner.Namespace).Get(owner.Name)
+	return owner, apierrors.IsNotFound(err)
+}
+
+// sourceOfTitle returns the namespace and name of the source which the Zendesk
+// object with the given title was created for, and whether the title matches
+// the titles of the objects created for sources.
+// Names of Kubernetes namespaces can't contain '.', and names of sources
+// can't contain ':'.
+func sourceOfTitle(title string) (types.NamespacedName, bool) {
+	nsName := strings.TrimPrefix(title, titlePrefix)
+	if nsName == title {
+		return types.NamespacedName{}, false
+	}
+
+	// Triggers of events other than TicketCreated have a suffix
+	if i := strings.IndexByte(nsName, ':'); i >= 0 {
+		nsName = nsName[:i]
+	}
+
+	parts := strings.SplitN(nsName, ".", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return types.NamespacedName{}, false
+	}
+
+	return types.NamespacedName{Namespace: parts[0], Name: parts[1]}, true
+}
+
+// forgetDeleted removes the credentials and clients of the given source,
+// which was deleted without being finalized, from the caches. Its orphans are
+// collected at the next sweep, with the clients of other sources which use
+// the same credentials, if any.
+func (r *Reconciler) forgetDeleted(src *v1alpha1.ZendeskSource) {
+	r.oauthTokens.forget(sourceKey(src))
+
+	if clients := r.zendeskClients.forget(sourceKey(src)); clients != nil {
+		r.orphans.enqueue(clients)
+	}
+}
+
+// handleDelete returns a function which forgets the sources deleted from the
+// informer's cache.
+func (r *Reconciler) handleDelete() func(interface{}) {
+	return func(obj interface{}) {
+		if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+			obj = tombstone.Obj
+		}
+
+		if src, ok := obj.(*v1alpha1.ZendeskSource); ok {
+			r.forgetDeleted(src)
+		}
+	}
+}
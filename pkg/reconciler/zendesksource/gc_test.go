@@ -0,0 +1,312 @@
+/*
+Copyright (c) 2020 TriggerMesh Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package zendesksource
+
+import (
+	"context"
+	"fmt"
+	"net/http/httptest"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	zapt "go.uber.org/zap/zaptest"
+	"go.uber.org/zap/zaptest/observer"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/cache"
+
+	"knative.dev/pkg/logging"
+
+	"github.com/nukosuke/go-zendesk/zendesk"
+
+	"github.com/triggermesh/knative-sources/pkg/apis/sources/v1alpha1"
+	listersv1alpha1 "github.com/triggermesh/knative-sources/pkg/client/generated/listers/sources/v1alpha1"
+	"github.com/triggermesh/knative-sources/pkg/zendesk/webhooks"
+)
+
+func TestSourceOfTitle(t *testing.T) {
+	tc := map[string]struct {
+		title       string
+		expectOwner types.NamespacedName
+		expectOK    bool
+	}{
+		"target": {
+			title:       "io.triggermesh.zendesksource.test.my-source",
+			expectOwner: types.NamespacedName{Namespace: "test", Name: "my-source"},
+			expectOK:    true,
+		},
+		"trigger of an event": {
+			title:       "io.triggermesh.zendesksource.test.my-source:TicketSolved",
+			expectOwner: types.NamespacedName{Namespace: "test", Name: "my-source"},
+			expectOK:    true,
+		},
+		"name with dots": {
+			title:       "io.triggermesh.zendesksource.test.my.source",
+			expectOwner: types.NamespacedName{Namespace: "test", Name: "my.source"},
+			expectOK:    true,
+		},
+		"missing name": {
+			title: "io.triggermesh.zendesksource.test",
+		},
+		"other prefix": {
+			title: "Notify requester of received request",
+		},
+	}
+
+	for name, c := range tc {
+		//nolint:scopelint
+		t.Run(name, func(t *testing.T) {
+			owner, ok := sourceOfTitle(c.title)
+			assert.Equal(t, c.expectOK, ok)
+			assert.Equal(t, c.expectOwner, owner)
+		})
+	}
+}
+
+func TestOrphanCollector(t *testing.T) {
+	alive := &v1alpha1.ZendeskSource{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "test",
+			Name:      "alive",
+		},
+	}
+	gone := &v1alpha1.ZendeskSource{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "test",
+			Name:      "gone",
+		},
+	}
+
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	require.NoError(t, indexer.Add(alive))
+	lister := listersv1alpha1.NewZendeskSourceLister(indexer)
+
+	populateAPI := func(api *fakeZendeskAPI) {
+		api.targets = []zendesk.Target{
+			{ID: 1, Title: targetTitle(alive)},
+			{ID: 2, Title: targetTitle(gone)},
+		}
+		api.triggers = []zendesk.Trigger{
+			{ID: 3, Title: triggerTitle(alive, v1alpha1.ZendeskTicketCreated)},
+			{ID: 4, Title: triggerTitle(gone, v1alpha1.ZendeskTicketCreated)},
+			{ID: 5, Title: triggerTitle(gone, v1alpha1.ZendeskTicketSolved)},
+			{ID: 6, Title: "Notify requester of received request"},
+		}
+		api.webhooks = []webhooks.Webhook{
+			{ID: "01WEBHOOK1", Name: targetTitle(alive)},
+			{ID: "01WEBHOOK2", Name: targetTitle(gone)},
+		}
+	}
+
+	t.Run("delete orphans", func(t *testing.T) {
+		api := newFakeZendeskAPI()
+		populateAPI(api)
+
+		srv := httptest.NewServer(api)
+		defer srv.Close()
+
+		client, whClient := newFakeZendeskClients(t, srv.URL, "api-token")
+
+		core, logs := observer.New(zapcore.InfoLevel)
+		ctx := logging.WithLogger(context.Background(), zap.New(core).Sugar())
+
+		c := &orphanCollector{lister: lister}
+		require.NoError(t, c.collect(ctx, client, whClient))
+
+		assert.Equal(t, []string{
+			"DELETE /triggers/4.json",
+			"DELETE /triggers/5.json",
+			"DELETE /targets/2.json",
+			"DELETE /webhooks/01WEBHOOK2",
+		}, api.updateRequests())
+
+		assert.Equal(t, []string{
+			"Trigger io.triggermesh.zendesksource.test.gone",
+			"Trigger io.triggermesh.zendesksource.test.gone:TicketSolved",
+			"Target io.triggermesh.zendesksource.test.gone",
+			"webhook io.triggermesh.zendesksource.test.gone",
+		}, loggedOrphans(logs, "Deleted orphaned Zendesk object of a deleted source"))
+
+		assert.Equal(t, []int64{1}, targetIDs(api.targets))
+		assert.Equal(t, []int64{3, 6}, triggerIDs(api.triggers))
+		if assert.Len(t, api.webhooks, 1) {
+			assert.Equal(t, "01WEBHOOK1", api.webhooks[0].ID)
+		}
+	})
+
+	t.Run("dry run", func(t *testing.T) {
+		api := newFakeZendeskAPI()
+		populateAPI(api)
+
+		srv := httptest.NewServer(api)
+		defer srv.Close()
+
+		client, whClient := newFakeZendeskClients(t, srv.URL, "api-token")
+
+		core, logs := observer.New(zapcore.InfoLevel)
+		ctx := logging.WithLogger(context.Background(), zap.New(core).Sugar())
+
+		c := &orphanCollector{cfg: gcConfig{DryRun: true}, lister: lister}
+		require.NoError(t, c.collect(ctx, client, whClient))
+
+		assert.Empty(t, api.updateRequests())
+		assert.Len(t, loggedOrphans(logs, "Found orphaned Zendesk object of a deleted source (dry run)"), 4)
+	})
+}
+
+func TestSweepOrphansOfDeleted(t *testing.T) {
+	alive := &v1alpha1.ZendeskSource{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "test",
+			Name:      "alive",
+		},
+	}
+	gone := &v1alpha1.ZendeskSource{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "test",
+			Name:      "gone",
+		},
+	}
+
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	require.NoError(t, indexer.Add(alive))
+	lister := listersv1alpha1.NewZendeskSourceLister(indexer)
+
+	creds := func(apiToken string) *apiCredentials {
+		return &apiCredentials{
+			email:       "me@example.com",
+			apiToken:    apiToken,
+			fingerprint: fingerprint("token", "me@example.com", apiToken),
+		}
+	}
+
+	populateAPI := func(api *fakeZendeskAPI) {
+		api.targets = []zendesk.Target{
+			{ID: 1, Title: targetTitle(gone)},
+		}
+		api.triggers = []zendesk.Trigger{
+			{ID: 2, Title: triggerTitle(gone, v1alpha1.ZendeskTicketCreated)},
+		}
+	}
+
+	newReconciler := func(srvURL string) *Reconciler {
+		r := &Reconciler{
+			zendeskClients: clientCache{endpointURLOverride: srvURL},
+		}
+		r.orphans = orphanCollector{
+			cfg:     gcConfig{Interval: time.Hour},
+			lister:  lister,
+			clients: &r.zendeskClients,
+		}
+		return r
+	}
+
+	ctx := logging.WithLogger(context.Background(), zapt.NewLogger(t).Sugar())
+
+	t.Run("last user of the credentials", func(t *testing.T) {
+		api := newFakeZendeskAPI()
+		populateAPI(api)
+
+		srv := httptest.NewServer(api)
+		defer srv.Close()
+
+		r := newReconciler(srv.URL)
+
+		_, err := r.zendeskClients.clientsFor(sourceKey(gone), "example", creds("token-1"))
+		require.NoError(t, err)
+
+		// the source is deleted without being finalized
+		r.handleDelete()(cache.DeletedFinalStateUnknown{Obj: gone})
+
+		assert.Empty(t, r.zendeskClients.clients, "Clients of deleted sources should be removed from the cache")
+		assert.Empty(t, api.requests, "Orphans should only be collected by the next sweep")
+
+		r.orphans.sweep(ctx)
+
+		assert.Equal(t, []string{
+			"DELETE /triggers/2.json",
+			"DELETE /targets/1.json",
+		}, api.updateRequests())
+
+		api.requests = nil
+		r.orphans.sweep(ctx)
+		assert.Empty(t, api.requests, "Clients of deleted sources should only be used by one sweep")
+	})
+
+	t.Run("credentials used by another source", func(t *testing.T) {
+		api := newFakeZendeskAPI()
+		populateAPI(api)
+
+		srv := httptest.NewServer(api)
+		defer srv.Close()
+
+		r := newReconciler(srv.URL)
+
+		_, err := r.zendeskClients.clientsFor(sourceKey(gone), "example", creds("token-1"))
+		require.NoError(t, err)
+		_, err = r.zendeskClients.clientsFor(sourceKey(alive), "example", creds("token-1"))
+		require.NoError(t, err)
+
+		r.handleDelete()(gone)
+
+		assert.Len(t, r.zendeskClients.clients, 1, "Clients used by another source should be kept")
+
+		r.orphans.sweep(ctx)
+
+		assert.Equal(t, []string{
+			"DELETE /triggers/2.json",
+			"DELETE /targets/1.json",
+		}, api.updateRequests(), "Orphans should be collected once with the shared credentials")
+	})
+}
+
+// loggedOrphans returns the kind and title of the orphans which were logged
+// with the given message.
+func loggedOrphans(logs *observer.ObservedLogs, msg string) []string {
+	var orphans []string
+	for _, e := range logs.FilterMessage(msg).All() {
+		ctx := e.ContextMap()
+		orphans = append(orphans, fmt.Sprintf("%s %s", ctx["kind"], ctx["title"]))
+	}
+	return orphans
+}
+
+// targetIDs returns the sorted IDs of the given Targets.
+func targetIDs(targets []zendesk.Target) []int64 {
+	ids := make([]int64, 0, len(targets))
+	for _, t := range targets {
+		ids = append(ids, t.ID)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+// triggerIDs returns the sorted IDs of the given Triggers.
+func triggerIDs(triggers []zendesk.Trigger) []int64 {
+	ids := make([]int64, 0, len(triggers))
+	for _, t := range triggers {
+		ids = append(ids, t.ID)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
@@ -19,20 +19,36 @@ package zendesksource
 import (
 	"context"
 	"fmt"
+	"time"
 
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes"
 	"knative.dev/pkg/reconciler"
 
 	"github.com/triggermesh/knative-sources/pkg/apis/sources/v1alpha1"
 	reconcilerv1alpha1 "github.com/triggermesh/knative-sources/pkg/client/generated/injection/reconciler/sources/v1alpha1/zendesksource"
 	"github.com/triggermesh/knative-sources/pkg/reconciler/common"
+	"github.com/triggermesh/knative-sources/pkg/reconciler/common/skip"
 )
 
 // Reconciler implements controller.Reconciler for the event source type.
 type Reconciler struct {
-	base       common.GenericServiceReconciler
-	kubeClient kubernetes.Interface
-	adapterCfg *adapterConfig
+	base           common.GenericServiceReconciler
+	deploymentBase common.GenericAddressableDeploymentReconciler
+	configMapBase  common.GenericConfigMapReconciler
+	kubeClient     kubernetes.Interface
+	adapterCfg     *adapterConfig
+
+	// token sources of sources which authenticate with OAuth client
+	// credentials
+	oauthTokens tokenSourceCache
+	// clients of the Zendesk API, by credentials
+	zendeskClients clientCache
+	// garbage collection of Zendesk objects of deleted sources
+	orphans orphanCollector
+
+	// schedules a new reconciliation of a source
+	enqueueKeyAfter func(types.NamespacedName, time.Duration)
 }
 
 // Check that our Reconciler implements Interface
@@ -46,13 +62,101 @@ func (r *Reconciler) ReconcileKind(ctx context.Context, src *v1alpha1.ZendeskSou
 	// inject source into context for usage in reconciliation logic
 	ctx = v1alpha1.WithSource(ctx, src)
 
-	if err := r.base.ReconcileSource(ctx, adapterServiceBuilder(src, r.adapterCfg)); err != nil {
-		return fmt.Errorf("failed to reconcile source: %w", err)
+	// the adapter authenticates against the Zendesk API with the API
+	// token, regardless of the credentials of the controller
+	if src.Spec.Token.SecretKeyRef == nil &&
+		(src.Spec.Polling != nil || src.GetNotificationAPI() == v1alpha1.ZendeskNotificationAPIWebhooks) {
+
+		return common.InvalidSpec(src, "An API token is required by the adapter in polling mode "+
+			"and with the Webhooks notification API")
+	}
+
+	// user and organization events can only be notified by a webhook
+	// subscribed to them
+	if len(src.GetWebhookEventTypes()) > 0 &&
+		(src.Spec.Polling != nil || src.GetNotificationAPI() != v1alpha1.ZendeskNotificationAPIWebhooks) {
+
+		return common.InvalidSpec(src, "User and organization events require the Webhooks notification API "+
+			"and are not supported in polling mode")
+	}
+
+	// buffering adapters are only reachable from within the cluster,
+	// unless exposed at a public URL
+	if src.Spec.Buffer != nil && src.Spec.Buffer.PublicURL == nil && src.Spec.Polling == nil {
+		return common.InvalidSpec(src, "The buffering of events requires a public URL outside of polling mode")
+	}
+
+	if src.Spec.Polling != nil {
+		if err := r.base.DeleteAdapter(ctx, src); err != nil {
+			return err
+		}
+		if err := r.deploymentBase.DeleteAdapterK8sService(ctx, src); err != nil {
+			return err
+		}
+		if err := r.deploymentBase.ReconcileSource(ctx, adapterDeploymentBuilder(src, r.adapterCfg)); err != nil {
+			return fmt.Errorf("failed to reconcile source: %w", err)
+		}
+
+		// Pods of the adapter can not be created until its
+		// ServiceAccount exists, and are retried meanwhile
+		if err := r.reconcileCursor(ctx, src); err != nil {
+			return err
+		}
+
+		return r.ensureNoZendeskNotifications(ctx, src)
+	}
+
+	// the adapter is deployed as a Knative Service, unless it buffers
+	// events on a persistent volume
+	if buf := src.Spec.Buffer; buf != nil {
+		if err := r.base.DeleteAdapter(ctx, src); err != nil {
+			return err
+		}
+		err := r.deploymentBase.ReconcileAddressableSource(ctx, adapterDeploymentBuilder(src, r.adapterCfg),
+			buf.PublicURL)
+		if err != nil {
+			return fmt.Errorf("failed to reconcile source: %w", err)
+		}
+	} else {
+		if err := r.deploymentBase.DeleteAdapter(ctx, src); err != nil {
+			return err
+		}
+		if err := r.base.ReconcileSource(ctx, adapterServiceBuilder(src, r.adapterCfg)); err != nil {
+			return fmt.Errorf("failed to reconcile source: %w", err)
+		}
 	}
 
 	return r.ensureZendeskTargetAndTrigger(ctx)
 }
 
+// ensureNoZendeskNotifications deletes the Zendesk Target, webhook and
+// Triggers which notified the adapter of the given source before it switched
+// to polling mode. The deletion is attempted until it succeeds once.
+func (r *Reconciler) ensureNoZendeskNotifications(ctx context.Context, src *v1alpha1.ZendeskSource) error {
+	if skip.Skip(ctx) {
+		return nil
+	}
+
+	if cond := src.Status.GetCondition(v1alpha1.ZendeskConditionTargetSynced); cond.IsTrue() &&
+		cond.Reason == v1alpha1.ZendeskReasonPolling {
+
+		return nil
+	}
+
+	if err := r.ensureNoZendeskTargetAndTrigger(ctx); err != nil {
+		return err
+	}
+
+	src.Status.MarkTargetNotRequired()
+	src.Status.TargetCredentialsDigest = ""
+	src.Status.TargetID = ""
+	src.Status.EventsWebhookID = ""
+	src.Status.Triggers = nil
+	src.Status.LastSyncError = nil
+
+	return nil
+}
+
 // FinalizeKind is called when the resource is deleted.
 func (r *Reconciler) FinalizeKind(ctx context.Context, src *v1alpha1.ZendeskSource) reconciler.Event {
 	// inject source into context for usage in finalization logic
@@ -61,5 +165,17 @@ func (r *Reconciler) FinalizeKind(ctx context.Context, src *v1alpha1.ZendeskSour
 	// The finalizer blocks the deletion of the source object until
 	// ensureNoZendeskTargetAndTrigger succeeds to ensure that we don't
 	// leave any dangling Zendesk Target/Trigger behind us.
-	return r.ensureNoZendeskTargetAndTrigger(ctx)
+	if err := r.ensureNoZendeskTargetAndTrigger(ctx); err != nil {
+		return err
+	}
+
+	r.oauthTokens.forget(sourceKey(src))
+	r.zendeskClients.forget(sourceKey(src))
+
+	return nil
+}
+
+// sourceKey returns the key of the given source object in the work queue.
+func sourceKey(src *v1alpha1.ZendeskSource) types.NamespacedName {
+	return types.NamespacedName{Namespace: src.Namespace, Name: src.Name}
 }
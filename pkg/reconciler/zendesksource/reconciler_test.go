@@ -25,6 +25,7 @@ import (
 	fakek8sinjectionclient "knative.dev/pkg/client/injection/kube/client/fake"
 
 	"knative.dev/eventing/pkg/reconciler/source"
+	"knative.dev/pkg/apis"
 	"knative.dev/pkg/controller"
 	"knative.dev/pkg/logging"
 	"knative.dev/pkg/resolver"
@@ -53,6 +54,65 @@ func TestReconcileSource(t *testing.T) {
 	TestReconcile(t, ctor, src, adapterFn)
 }
 
+func TestReconcileSourcePolling(t *testing.T) {
+	adapterCfg := &adapterConfig{
+		Image:   "registry/image:tag",
+		configs: &source.EmptyVarsGenerator{},
+	}
+
+	var (
+		ctor      = reconcilerCtor(adapterCfg)
+		src       = newPollingEventSource()
+		adapterFn = adapterDeploymentBuilder(src, adapterCfg)
+	)
+
+	TestReconcile(t, ctor, src, adapterFn)
+}
+
+func TestReconcileSourcePollingBuffer(t *testing.T) {
+	adapterCfg := &adapterConfig{
+		Image:   "registry/image:tag",
+		configs: &source.EmptyVarsGenerator{},
+	}
+
+	src := newPollingEventSource()
+	src.Spec.Buffer = &v1alpha1.EventBuffer{
+		PersistentVolumeClaimName: "buffer",
+	}
+
+	var (
+		ctor      = reconcilerCtor(adapterCfg)
+		adapterFn = adapterDeploymentBuilder(src, adapterCfg)
+	)
+
+	TestReconcile(t, ctor, src, adapterFn)
+}
+
+func TestReconcileSourceBuffer(t *testing.T) {
+	adapterCfg := &adapterConfig{
+		Image:   "registry/image:tag",
+		configs: &source.EmptyVarsGenerator{},
+	}
+
+	publicURL := &apis.URL{
+		Scheme: "https",
+		Host:   "zendesk.example.com",
+	}
+
+	src := newEventSource()
+	src.Spec.Buffer = &v1alpha1.EventBuffer{
+		PersistentVolumeClaimName: "buffer",
+		PublicURL:                 publicURL,
+	}
+
+	var (
+		ctor      = reconcilerCtor(adapterCfg)
+		adapterFn = adapterDeploymentBuilder(src, adapterCfg)
+	)
+
+	TestReconcileAddressableDeployment(t, ctor, src, adapterFn, publicURL)
+}
+
 // reconcilerCtor returns a Ctor for a source Reconciler.
 func reconcilerCtor(cfg *adapterConfig) Ctor {
 	return func(t *testing.T, ctx context.Context, ls *Listers) controller.Reconciler {
@@ -62,10 +122,23 @@ func reconcilerCtor(cfg *adapterConfig) Ctor {
 			Client:       fakeservinginjectionclient.Get(ctx).ServingV1().Services,
 		}
 
+		deploymentBase := common.GenericAddressableDeploymentReconciler{
+			GenericDeploymentReconciler: common.GenericDeploymentReconciler{
+				SinkResolver: resolver.NewURIResolver(ctx, func(types.NamespacedName) {}),
+				Lister:       ls.GetDeploymentLister().Deployments,
+				Client:       fakek8sinjectionclient.Get(ctx).AppsV1().Deployments,
+				PodClient:    fakek8sinjectionclient.Get(ctx).CoreV1().Pods,
+			},
+			ServiceLister: ls.GetK8sServiceLister().Services,
+			ServiceClient: fakek8sinjectionclient.Get(ctx).CoreV1().Services,
+		}
+
 		r := &Reconciler{
-			base:       base,
-			kubeClient: fakek8sinjectionclient.Get(ctx),
-			adapterCfg: cfg,
+			base:           base,
+			deploymentBase: deploymentBase,
+			configMapBase:  NewConfigMapReconciler(fakek8sinjectionclient.Get(ctx), ls),
+			kubeClient:     fakek8sinjectionclient.Get(ctx),
+			adapterCfg:     cfg,
 		}
 
 		return reconcilerv1alpha1.NewReconciler(ctx, logging.FromContext(ctx),
@@ -108,3 +181,10 @@ func newEventSource() *v1alpha1.ZendeskSource {
 
 	return src
 }
+
+// newPollingEventSource returns a test source object in polling mode.
+func newPollingEventSource() *v1alpha1.ZendeskSource {
+	src := newEventSource()
+	src.Spec.Polling = &v1alpha1.ZendeskPolling{}
+	return src
+}
@@ -0,0 +1,373 @@
+/*
+Copyright (c) 2020 TriggerMesh, Inc
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package zendesksource
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"knative.dev/pkg/controller"
+	"knative.dev/pkg/reconciler"
+
+	"github.com/nukosuke/go-zendesk/zendesk"
+
+	"github.com/triggermesh/knative-sources/pkg/apis/sources/v1alpha1"
+	"github.com/triggermesh/knative-sources/pkg/reconciler/common"
+)
+
+// triggerTemplate contains the settings shared by all the Zendesk Triggers of
+// a source.
+type triggerTemplate struct {
+	// JSON payload containing Zendesk placeholders, without the
+	// "event_type" attribute
+	payload string
+	// conditions added to the conditions of each event
+	conditions conditions
+}
+
+// defaultTriggerTemplate returns the triggerTemplate of sources which don't
+// customize their Triggers.
+func defaultTriggerTemplate() *triggerTemplate {
+	return &triggerTemplate{
+		payload: defaultPayloadTemplate,
+	}
+}
+
+// triggerTemplateOf returns the triggerTemplate of the given source, or an
+// error if its Trigger settings are invalid.
+func (r *Reconciler) triggerTemplateOf(ctx context.Context, src *v1alpha1.ZendeskSource) (*triggerTemplate, error) {
+	spec := src.Spec.Trigger
+	if spec == nil {
+		return defaultTriggerTemplate(), nil
+	}
+
+	tt := defaultTriggerTemplate()
+
+	if tmpl := spec.PayloadTemplate; tmpl != nil {
+		switch {
+		case tmpl.ValueFromConfigMap != nil:
+			payload, err := r.configMapValueFrom(src.Namespace, tmpl.ValueFromConfigMap)
+			if err != nil {
+				src.Status.MarkTargetNotSynced(v1alpha1.ZendeskReasonInvalidTrigger,
+					"Cannot obtain Trigger payload template")
+				return nil, fmt.Errorf("reading Trigger payload template: %w", err)
+			}
+			tt.payload = payload
+
+		case tmpl.Value != "":
+			tt.payload = tmpl.Value
+		}
+
+		if err := validatePayloadTemplate(tt.payload); err != nil {
+			src.Status.MarkTargetNotSynced(v1alpha1.ZendeskReasonInvalidTrigger,
+				"Invalid Trigger payload template")
+			return nil, controller.NewPermanentError(reconciler.NewEvent(corev1.EventTypeWarning,
+				common.ReasonInvalidSpec, "Invalid Trigger payload template: %s", err))
+		}
+	}
+
+	if conds := spec.Conditions; conds != nil {
+		var err error
+		if tt.conditions.all, err = triggerConditionsFrom(conds.All); err == nil {
+			tt.conditions.any, err = triggerConditionsFrom(conds.Any)
+		}
+		if err != nil {
+			src.Status.MarkTargetNotSynced(v1alpha1.ZendeskReasonInvalidTrigger,
+				"Invalid Trigger conditions")
+			return nil, controller.NewPermanentError(reconciler.NewEvent(corev1.EventTypeWarning,
+				common.ReasonInvalidSpec, "Invalid Trigger conditions: %s", err))
+		}
+	}
+
+	return tt, nil
+}
+
+// configMapValueFrom retrieves a value from a ConfigMap.
+func (r *Reconciler) configMapValueFrom(namespace string,
+	cmKeySelector *corev1.ConfigMapKeySelector) (string, error) {
+
+	cm, err := r.configMapBase.ConfigMapLister(namespace).Get(cmKeySelector.Name)
+	if err != nil {
+		return "", err
+	}
+
+	val, ok := cm.Data[cmKeySelector.Key]
+	if !ok {
+		return "", fmt.Errorf("key %q not found in ConfigMap %q", cmKeySelector.Key, cmKeySelector.Name)
+	}
+	return val, nil
+}
+
+// eventTypeAttribute is the attribute of a Trigger's payload from which the
+// adapter determines the type of the CloudEvent it generates.
+const eventTypeAttribute = "event_type"
+
+// triggerPayload returns the JSON payload sent to the adapter by the Trigger
+// of the given CloudEvent type, by adding the "event_type" attribute to the
+// given payload template. The template is expected to be a valid JSON object.
+func triggerPayload(tmpl, eventType string) string {
+	i := strings.IndexByte(tmpl, '{') + 1
+
+	sep := ","
+	if strings.HasPrefix(strings.TrimSpace(tmpl[i:]), "}") {
+		sep = ""
+	}
+
+	return tmpl[:i] + "\n  \"" + eventTypeAttribute + "\": \"" + eventType + "\"" + sep + tmpl[i:]
+}
+
+// validatePayloadTemplate returns an error if the given payload template
+// doesn't render as a JSON object once its placeholders are substituted by
+// Zendesk.
+func validatePayloadTemplate(tmpl string) error {
+	rendered, err := renderPlaceholders(tmpl)
+	if err != nil {
+		return err
+	}
+
+	var obj map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(rendered), &obj); err != nil {
+		return fmt.Errorf("template is not a valid JSON object: %w", err)
+	}
+	if _, ok := obj[eventTypeAttribute]; ok {
+		return fmt.Errorf("the %q attribute is reserved", eventTypeAttribute)
+	}
+
+	return nil
+}
+
+// numericPlaceholders are the Zendesk placeholders which render as numbers,
+// and therefore don't need to be quoted in a JSON payload.
+var numericPlaceholders = map[string]struct{}{
+	"ticket.id":              {},
+	"ticket.assignee.id":     {},
+	"ticket.requester.id":    {},
+	"ticket.organization.id": {},
+	"ticket.group.id":        {},
+	"ticket.brand.id":        {},
+	"ticket.ticket_form_id":  {},
+	"current_user.id":        {},
+}
+
+// renderPlaceholders substitutes the Zendesk placeholders contained in the
+// given template with a sample value, and returns an error if a placeholder is
+// malformed or would render as invalid JSON.
+func renderPlaceholders(tmpl string) (string, error) {
+	var b strings.Builder
+	var inString, escaped bool
+
+	for i := 0; i < len(tmpl); i++ {
+		c := tmpl[i]
+
+		switch {
+		case strings.HasPrefix(tmpl[i:], "{{"):
+			end := strings.Index(tmpl[i:], "}}")
+			if end == -1 {
+				return "", errors.New("unterminated placeholder")
+			}
+
+			placeholder := tmpl[i : i+end+2]
+
+			// placeholders may be followed by Liquid filters,
+			// e.g. {{ticket.title | upcase}}
+			name := strings.TrimSpace(strings.SplitN(placeholder[2:len(placeholder)-2], "|", 2)[0])
+			if name == "" || strings.ContainsAny(name, "{} \t\n") {
+				return "", fmt.Errorf("malformed placeholder %s", placeholder)
+			}
+
+			if _, numeric := numericPlaceholders[name]; !inString && !numeric {
+				return "", fmt.Errorf("placeholder %s must be quoted", placeholder)
+			}
+
+			b.WriteByte('0')
+			i += end + 1
+			continue
+
+		case escaped:
+			escaped = false
+		case inString && c == '\\':
+			escaped = true
+		case c == '"':
+			inString = !inString
+		}
+
+		b.WriteByte(c)
+	}
+
+	return b.String(), nil
+}
+
+// Zendesk fields and operators of the supported Trigger conditions.
+// See: https://developer.zendesk.com/rest_api/docs/support/triggers#conditions-reference
+var (
+	conditionFields = map[v1alpha1.ZendeskConditionField]string{
+		v1alpha1.ZendeskConditionFieldGroup:    "group_id",
+		v1alpha1.ZendeskConditionFieldBrand:    "brand_id",
+		v1alpha1.ZendeskConditionFieldTags:     "current_tags",
+		v1alpha1.ZendeskConditionFieldPriority: "priority",
+		v1alpha1.ZendeskConditionFieldForm:     "ticket_form_id",
+	}
+
+	conditionOperators = map[v1alpha1.ZendeskConditionOperator]string{
+		v1alpha1.ZendeskConditionOperatorIs:          "is",
+		v1alpha1.ZendeskConditionOperatorIsNot:       "is_not",
+		v1alpha1.ZendeskConditionOperatorIncludes:    "includes",
+		v1alpha1.ZendeskConditionOperatorNotIncludes: "not_includes",
+		v1alpha1.ZendeskConditionOperatorLessThan:    "less_than",
+		v1alpha1.ZendeskConditionOperatorGreaterThan: "greater_than",
+	}
+)
+
+// triggerConditionsFrom returns the Zendesk Trigger conditions matching the
+// given conditions, or an error if a condition is invalid.
+func triggerConditionsFrom(conds []v1alpha1.ZendeskTriggerCondition) ([]zendesk.TriggerCondition, error) {
+	if len(conds) == 0 {
+		return nil, nil
+	}
+
+	trgConds := make([]zendesk.TriggerCondition, 0, len(conds))
+
+	for _, c := range conds {
+		field, ok := conditionFields[c.Field]
+		if !ok {
+			return nil, fmt.Errorf("unsupported field %q", c.Field)
+		}
+
+		op := c.Operator
+		if op == "" {
+			op = v1alpha1.ZendeskConditionOperatorIs
+			if c.Field == v1alpha1.ZendeskConditionFieldTags {
+				op = v1alpha1.ZendeskConditionOperatorIncludes
+			}
+		}
+		if !operatorSupported(c.Field, op) {
+			return nil, fmt.Errorf("operator %q is not supported by field %q", op, c.Field)
+		}
+
+		if c.Value == "" {
+			return nil, fmt.Errorf("condition on field %q has no value", c.Field)
+		}
+
+		trgConds = append(trgConds, zendesk.TriggerCondition{
+			Field:    field,
+			Operator: conditionOperators[op],
+			Value:    c.Value,
+		})
+	}
+
+	return trgConds, nil
+}
+
+// operatorSupported returns whether the given operator can be used in a
+// condition on the given field.
+func operatorSupported(field v1alpha1.ZendeskConditionField, op v1alpha1.ZendeskConditionOperator) bool {
+	switch op {
+	case v1alpha1.ZendeskConditionOperatorIncludes, v1alpha1.ZendeskConditionOperatorNotIncludes:
+		return field == v1alpha1.ZendeskConditionFieldTags
+	case v1alpha1.ZendeskConditionOperatorLessThan, v1alpha1.ZendeskConditionOperatorGreaterThan:
+		return field == v1alpha1.ZendeskConditionFieldPriority
+	case v1alpha1.ZendeskConditionOperatorIs, v1alpha1.ZendeskConditionOperatorIsNot:
+		return field != v1alpha1.ZendeskConditionFieldTags
+	default:
+		return false
+	}
+}
+
+// defaultPayloadTemplate is the template of a Trigger's JSON payload, which
+// contains placeholders substituted by Zendesk.
+// See: https://support.zendesk.com/hc/en-us/articles/203662156
+const defaultPayloadTemplate = `{
+  "ticket": {
+    "id": {{ticket.id}},
+    "external_id": "{{ticket.external_id}}",
+    "title": "{{ticket.title}}",
+    "url": "{{ticket.url}}",
+    "description": "{{ticket.description}}",
+    "via": "{{ticket.via}}",
+    "status": "{{ticket.status}}",
+    "priority": "{{ticket.priority}}",
+    "ticket_type": "{{ticket.ticket_type}}",
+    "group_name": "{{ticket.group.name}}",
+    "brand_name": "{{ticket.brand.name}}",
+    "due_date": "{{ticket.due_date}}",
+    "created_at": "{{ticket.created_at_with_timestamp}}",
+    "updated_at": "{{ticket.updated_at_with_timestamp}}",
+    "account": "{{ticket.account}}",
+    "assignee": {
+      "id": "{{ticket.assignee.id}}",
+      "email": "{{ticket.assignee.email}}",
+      "name": "{{ticket.assignee.name}}",
+      "first_name": "{{ticket.assignee.first_name}}",
+      "last_name": "{{ticket.assignee.last_name}}"
+    },
+    "requester": {
+      "id": "{{ticket.requester.id}}",
+      "name": "{{ticket.requester.name}}",
+      "first_name": "{{ticket.requester.first_name}}",
+      "last_name": "{{ticket.requester.last_name}}",
+      "email": "{{ticket.requester.email}}",
+      "language": "{{ticket.requester.language}}",
+      "phone": "{{ticket.requester.phone}}",
+      "external_id": "{{ticket.requester.external_id}}",
+      "field": "{{ticket.requester_field}}",
+      "details": "{{ticket.requester.details}}"
+    },
+    "organization": {
+      "id": "{{ticket.organization.id}}",
+      "name": "{{ticket.organization.name}}",
+      "external_id": "{{ticket.organization.external_id}}",
+      "details": "{{ticket.organization.details}}",
+      "notes": "{{ticket.organization.notes}}"
+    },
+    "ccs": "{{ticket.ccs}}",
+    "cc_names": "{{ticket.cc_names}}",
+    "tags": "{{ticket.tags}}",
+    "current_holiday_name": "{{ticket.current_holiday_name}}",
+    "ticket_field_id": "{{ticket.ticket_field_ID}}",
+    "ticket_field_option_title_id": "{{ticket.ticket_field_option_title_ID}}",
+    "latest_comment": {
+      "value": "{{ticket.latest_comment}}",
+      "author_name": "{{ticket.latest_comment.author.name}}",
+      "is_public": "{{ticket.latest_comment.is_public}}"
+    }
+  },
+  "current_user": {
+    "id": "{{current_user.id}}",
+    "name": "{{current_user.name}}",
+    "first_name": "{{current_user.first_name}}",
+    "email": "{{current_user.email}}",
+    "organization": {
+      "name": "{{current_user.organization.name}}",
+      "notes": "{{current_user.organization.notes}}",
+      "details": "{{current_user.organization.details}}"
+    },
+    "external_id": "{{current_user.external_id}}",
+    "phone": "{{current_user.phone}}",
+    "details": "{{current_user.details}}",
+    "notes": "{{current_user.notes}}",
+    "language": "{{current_user.language}}"
+  },
+  "satisfaction": {
+    "current_rating": "{{satisfaction.current_rating}}",
+    "current_comment": "{{satisfaction.current_comment}}"
+  }
+}`
@@ -0,0 +1,383 @@
+/*
+Copyright (c) 2020 TriggerMesh, Inc
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package zendesksource
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"knative.dev/pkg/controller"
+
+	"github.com/nukosuke/go-zendesk/zendesk"
+
+	"github.com/triggermesh/knative-sources/pkg/apis/sources/v1alpha1"
+	. "github.com/triggermesh/knative-sources/pkg/reconciler/testing"
+)
+
+func TestTriggerPayload(t *testing.T) {
+	tc := map[string]struct {
+		template string
+		expect   map[string]interface{}
+	}{
+		"empty object": {
+			template: `{}`,
+			expect: map[string]interface{}{
+				"event_type": "com.zendesk.ticket.created",
+			},
+		},
+		"attributes": {
+			template: `{ "id": {{ticket.id}}, "title": "{{ticket.title}}" }`,
+			expect: map[string]interface{}{
+				"event_type": "com.zendesk.ticket.created",
+				"id":         float64(0),
+				"title":      "0",
+			},
+		},
+	}
+
+	for name, c := range tc {
+		//nolint:scopelint
+		t.Run(name, func(t *testing.T) {
+			payload := triggerPayload(c.template, v1alpha1.ZendeskTicketCreatedEventType)
+
+			rendered, err := renderPlaceholders(payload)
+			require.NoError(t, err)
+
+			var obj map[string]interface{}
+			require.NoError(t, json.Unmarshal([]byte(rendered), &obj), "Trigger payload is not valid JSON")
+			assert.Equal(t, c.expect, obj)
+		})
+	}
+}
+
+func TestValidatePayloadTemplate(t *testing.T) {
+	tc := map[string]struct {
+		template  string
+		expectErr bool
+	}{
+		"default template": {
+			template: defaultPayloadTemplate,
+		},
+		"quoted placeholders": {
+			template: `{"title": "{{ticket.title}}", "url": "https://{{ticket.url}}"}`,
+		},
+		"numeric placeholder": {
+			template: `{"ticket": {"id": {{ticket.id}}, "brand": {{ticket.brand.id}}}}`,
+		},
+		"placeholder with filter": {
+			template: `{"title": "{{ ticket.title | upcase }}"}`,
+		},
+		"escaped quote": {
+			template: `{"title": "\"{{ticket.title}}\""}`,
+		},
+		"unquoted text placeholder": {
+			template:  `{"title": {{ticket.title}}}`,
+			expectErr: true,
+		},
+		"unterminated placeholder": {
+			template:  `{"title": "{{ticket.title"}`,
+			expectErr: true,
+		},
+		"empty placeholder": {
+			template:  `{"title": "{{}}"}`,
+			expectErr: true,
+		},
+		"not an object": {
+			template:  `["{{ticket.title}}"]`,
+			expectErr: true,
+		},
+		"invalid JSON": {
+			template:  `{"title": "{{ticket.title}}",}`,
+			expectErr: true,
+		},
+		"reserved attribute": {
+			template:  `{"event_type": "custom"}`,
+			expectErr: true,
+		},
+	}
+
+	for name, c := range tc {
+		//nolint:scopelint
+		t.Run(name, func(t *testing.T) {
+			err := validatePayloadTemplate(c.template)
+			if c.expectErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestTriggerConditionsFrom(t *testing.T) {
+	tc := map[string]struct {
+		conds     []v1alpha1.ZendeskTriggerCondition
+		expect    []zendesk.TriggerCondition
+		expectErr bool
+	}{
+		"no condition": {},
+		"default operators": {
+			conds: []v1alpha1.ZendeskTriggerCondition{
+				{Field: v1alpha1.ZendeskConditionFieldBrand, Value: "360000000001"},
+				{Field: v1alpha1.ZendeskConditionFieldTags, Value: "vip enterprise"},
+			},
+			expect: []zendesk.TriggerCondition{
+				{Field: "brand_id", Operator: "is", Value: "360000000001"},
+				{Field: "current_tags", Operator: "includes", Value: "vip enterprise"},
+			},
+		},
+		"explicit operators": {
+			conds: []v1alpha1.ZendeskTriggerCondition{
+				{Field: v1alpha1.ZendeskConditionFieldPriority, Operator: v1alpha1.ZendeskConditionOperatorGreaterThan, Value: "normal"},
+				{Field: v1alpha1.ZendeskConditionFieldGroup, Operator: v1alpha1.ZendeskConditionOperatorIsNot, Value: "42"},
+				{Field: v1alpha1.ZendeskConditionFieldForm, Operator: v1alpha1.ZendeskConditionOperatorIs, Value: "43"},
+			},
+			expect: []zendesk.TriggerCondition{
+				{Field: "priority", Operator: "greater_than", Value: "normal"},
+				{Field: "group_id", Operator: "is_not", Value: "42"},
+				{Field: "ticket_form_id", Operator: "is", Value: "43"},
+			},
+		},
+		"unsupported field": {
+			conds: []v1alpha1.ZendeskTriggerCondition{
+				{Field: "Status", Value: "open"},
+			},
+			expectErr: true,
+		},
+		"unsupported operator": {
+			conds: []v1alpha1.ZendeskTriggerCondition{
+				{Field: v1alpha1.ZendeskConditionFieldTags, Operator: v1alpha1.ZendeskConditionOperatorIs, Value: "vip"},
+			},
+			expectErr: true,
+		},
+		"missing value": {
+			conds: []v1alpha1.ZendeskTriggerCondition{
+				{Field: v1alpha1.ZendeskConditionFieldGroup},
+			},
+			expectErr: true,
+		},
+	}
+
+	for name, c := range tc {
+		//nolint:scopelint
+		t.Run(name, func(t *testing.T) {
+			conds, err := triggerConditionsFrom(c.conds)
+			if c.expectErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, c.expect, conds)
+		})
+	}
+}
+
+func TestStatusTriggerConditions(t *testing.T) {
+	tc := map[string]struct {
+		event       v1alpha1.ZendeskEvent
+		from, to    string
+		expectMatch bool
+	}{
+		"open to solved": {
+			event:       v1alpha1.ZendeskTicketSolved,
+			from:        "open",
+			to:          "solved",
+			expectMatch: true,
+		},
+		"solved to open": {
+			event:       v1alpha1.ZendeskTicketReopened,
+			from:        "solved",
+			to:          "open",
+			expectMatch: true,
+		},
+		"solved to pending": {
+			event:       v1alpha1.ZendeskTicketReopened,
+			from:        "solved",
+			to:          "pending",
+			expectMatch: true,
+		},
+		"solved to closed": {
+			event:       v1alpha1.ZendeskTicketReopened,
+			from:        "solved",
+			to:          "closed",
+			expectMatch: false,
+		},
+		"open to pending": {
+			event:       v1alpha1.ZendeskTicketReopened,
+			from:        "open",
+			to:          "pending",
+			expectMatch: false,
+		},
+	}
+
+	for name, c := range tc {
+		//nolint:scopelint
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, c.expectMatch, matchStatusChange(t, triggerConditions[c.event].all, c.from, c.to))
+		})
+	}
+}
+
+// matchStatusChange evaluates the given "status" Trigger conditions against
+// a change of the status of a ticket, the way Zendesk does. Conditions on
+// other fields are ignored.
+func matchStatusChange(t *testing.T, conds []zendesk.TriggerCondition, from, to string) bool {
+	t.Helper()
+
+	for _, cond := range conds {
+		if cond.Field != "status" {
+			continue
+		}
+
+		var match bool
+		switch cond.Operator {
+		case "value":
+			match = to == cond.Value
+		case "value_previous":
+			match = from == cond.Value
+		case "not_value":
+			match = to != cond.Value
+		default:
+			t.Fatalf("Unsupported operator %q", cond.Operator)
+		}
+
+		if !match {
+			return false
+		}
+	}
+
+	return true
+}
+
+func TestTriggerTemplateOf(t *testing.T) {
+	const tmplCMName, tmplCMKey = "my-template", "payload.json"
+	const customPayload = `{"id": {{ticket.id}}}`
+
+	objects := []runtime.Object{
+		&corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: "test",
+				Name:      tmplCMName,
+			},
+			Data: map[string]string{
+				tmplCMKey:  customPayload,
+				"bad.json": `{"id": {{ticket.id}`,
+			},
+		},
+	}
+	ls := NewListers(NewScheme(), objects)
+
+	r := &Reconciler{configMapBase: NewConfigMapReconciler(fake.NewSimpleClientset(objects...), &ls)}
+
+	newSource := func(trg *v1alpha1.ZendeskTriggerSpec) *v1alpha1.ZendeskSource {
+		return &v1alpha1.ZendeskSource{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: "test",
+				Name:      "my-source",
+			},
+			Spec: v1alpha1.ZendeskSourceSpec{
+				Trigger: trg,
+			},
+		}
+	}
+
+	t.Run("defaults", func(t *testing.T) {
+		tt, err := r.triggerTemplateOf(context.Background(), newSource(nil))
+		require.NoError(t, err)
+		assert.Equal(t, defaultTriggerTemplate(), tt)
+	})
+
+	t.Run("inline template and conditions", func(t *testing.T) {
+		tt, err := r.triggerTemplateOf(context.Background(), newSource(&v1alpha1.ZendeskTriggerSpec{
+			PayloadTemplate: &v1alpha1.ZendeskPayloadTemplate{
+				Value: customPayload,
+			},
+			Conditions: &v1alpha1.ZendeskTriggerConditions{
+				All: []v1alpha1.ZendeskTriggerCondition{
+					{Field: v1alpha1.ZendeskConditionFieldBrand, Value: "1"},
+				},
+				Any: []v1alpha1.ZendeskTriggerCondition{
+					{Field: v1alpha1.ZendeskConditionFieldPriority, Value: "high"},
+					{Field: v1alpha1.ZendeskConditionFieldPriority, Value: "urgent"},
+				},
+			},
+		}))
+		require.NoError(t, err)
+		assert.Equal(t, customPayload, tt.payload)
+		assert.Len(t, tt.conditions.all, 1)
+		assert.Len(t, tt.conditions.any, 2)
+
+		trg, _ := newTrigger(newSource(nil), v1alpha1.ZendeskTicketSolved, tt, notificationTargetAction, "42")
+		assert.Len(t, trg.Conditions.All, 3, "Trigger should have the event's and the user-defined conditions")
+		assert.Len(t, trg.Conditions.Any, 2)
+	})
+
+	t.Run("template from ConfigMap", func(t *testing.T) {
+		tt, err := r.triggerTemplateOf(context.Background(), newSource(&v1alpha1.ZendeskTriggerSpec{
+			PayloadTemplate: &v1alpha1.ZendeskPayloadTemplate{
+				ValueFromConfigMap: &corev1.ConfigMapKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: tmplCMName},
+					Key:                  tmplCMKey,
+				},
+			},
+		}))
+		require.NoError(t, err)
+		assert.Equal(t, customPayload, tt.payload)
+	})
+
+	t.Run("missing ConfigMap key", func(t *testing.T) {
+		src := newSource(&v1alpha1.ZendeskTriggerSpec{
+			PayloadTemplate: &v1alpha1.ZendeskPayloadTemplate{
+				ValueFromConfigMap: &corev1.ConfigMapKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: tmplCMName},
+					Key:                  "missing.json",
+				},
+			},
+		})
+
+		_, err := r.triggerTemplateOf(context.Background(), src)
+		assert.Error(t, err)
+		assert.False(t, controller.IsPermanentError(err), "a missing template should be retried")
+		assert.Equal(t, v1alpha1.ZendeskReasonInvalidTrigger,
+			src.Status.GetCondition(v1alpha1.ZendeskConditionTargetSynced).Reason)
+	})
+
+	t.Run("invalid template", func(t *testing.T) {
+		src := newSource(&v1alpha1.ZendeskTriggerSpec{
+			PayloadTemplate: &v1alpha1.ZendeskPayloadTemplate{
+				ValueFromConfigMap: &corev1.ConfigMapKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: tmplCMName},
+					Key:                  "bad.json",
+				},
+			},
+		})
+
+		_, err := r.triggerTemplateOf(context.Background(), src)
+		assert.True(t, controller.IsPermanentError(err), "an invalid template should not be retried")
+		assert.Equal(t, v1alpha1.ZendeskReasonInvalidTrigger,
+			src.Status.GetCondition(v1alpha1.ZendeskConditionTargetSynced).Reason)
+	})
+}
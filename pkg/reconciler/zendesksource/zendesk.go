@@ -18,9 +18,16 @@ package zendesksource
 
 import (
 	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"sort"
 	"strconv"
+	"strings"
 
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
@@ -31,10 +38,12 @@ import (
 	"knative.dev/pkg/reconciler"
 
 	"github.com/nukosuke/go-zendesk/zendesk"
+	"golang.org/x/oauth2"
 
 	"github.com/triggermesh/knative-sources/pkg/apis/sources/v1alpha1"
 	"github.com/triggermesh/knative-sources/pkg/reconciler/common/event"
 	"github.com/triggermesh/knative-sources/pkg/reconciler/common/skip"
+	"github.com/triggermesh/knative-sources/pkg/zendesk/webhooks"
 )
 
 func (r *Reconciler) ensureZendeskTargetAndTrigger(ctx context.Context) error {
@@ -45,30 +54,52 @@ func (r *Reconciler) ensureZendeskTargetAndTrigger(ctx context.Context) error {
 	src := v1alpha1.SourceFromContext(ctx)
 	status := &src.(*v1alpha1.ZendeskSource).Status
 
-	adapter, err := r.base.FindAdapter(src)
-	switch {
-	case apierrors.IsNotFound(err):
-		return nil
-	case err != nil:
-		return fmt.Errorf("finding receive adapter: %w", err)
+	// the adapter is either a Knative Service or a Deployment exposed at
+	// a public URL, both report their address in the source's status
+	var url *pkgapis.URL
+	if status.Address != nil {
+		url = status.Address.URL
 	}
 
-	url := adapter.Status.URL
-
 	// skip this cycle if the adapter URL wasn't yet determined
-	if !adapter.IsReady() || url == nil {
+	if !status.GetCondition(v1alpha1.ConditionDeployed).IsTrue() || url == nil {
 		status.MarkTargetNotSynced(v1alpha1.ZendeskReasonNoURL,
 			"The receive adapter did not report its public URL yet")
 		return nil
 	}
 
 	spec := src.(pkgapis.HasSpec).GetUntypedSpec().(v1alpha1.ZendeskSourceSpec)
+	typedSrc := src.(*v1alpha1.ZendeskSource)
+
+	creds, err := r.apiCredentialsFor(ctx, typedSrc)
+	if err != nil {
+		status.MarkTargetNotSynced(v1alpha1.ZendeskReasonNoSecret, "Cannot obtain Zendesk API credentials")
+		return err
+	}
 
-	apiToken, err := r.secretFrom(ctx, src.GetNamespace(), spec.Token.SecretKeyRef)
+	clients, err := r.zendeskClients.clientsFor(sourceKey(typedSrc), spec.Subdomain, creds)
 	if err != nil {
-		status.MarkTargetNotSynced(v1alpha1.ZendeskReasonNoSecret, "Cannot obtain Zendesk API token")
 		return err
 	}
+	client, whClient := clients.api, clients.webhooks
+
+	tt, err := r.triggerTemplateOf(ctx, typedSrc)
+	if err != nil {
+		return err
+	}
+
+	if typedSrc.GetNotificationAPI() == v1alpha1.ZendeskNotificationAPIWebhooks {
+		desiredWebhook := newWebhook(src, url.String())
+		desiredEventsWebhook := newEventsWebhook(typedSrc, url.String())
+		err := syncWebhookAndTriggers(ctx, client, whClient, typedSrc, tt, desiredWebhook, desiredEventsWebhook)
+		return r.requeueIfRateLimited(ctx, typedSrc, err)
+	}
+
+	if spec.WebhookPassword.SecretKeyRef == nil {
+		status.MarkTargetNotSynced(v1alpha1.ZendeskReasonNoSecret,
+			"A webhook password is required by the Targets notification API")
+		return nil
+	}
 
 	webhookPassword, err := r.secretFrom(ctx, src.GetNamespace(), spec.WebhookPassword.SecretKeyRef)
 	if err != nil {
@@ -76,100 +107,495 @@ func (r *Reconciler) ensureZendeskTargetAndTrigger(ctx context.Context) error {
 		return err
 	}
 
-	cred := zendesk.NewAPITokenCredential(spec.Email, apiToken)
-	client, err := zendesk.NewClient(nil)
+	desiredTarget := newTarget(src, url.String(), spec.WebhookUsername, webhookPassword)
+	credsDigest := credentialsDigest(creds.secret, spec.WebhookUsername, webhookPassword)
+
+	err = syncTargetAndTriggers(ctx, client, whClient, typedSrc, tt, desiredTarget, credsDigest)
+	return r.requeueIfRateLimited(ctx, typedSrc, err)
+}
+
+// requeueIfRateLimited schedules a new reconciliation of the given source once
+// the delay requested by the Zendesk API elapses if the given error indicates
+// that requests are rate limited. The error is swallowed in that case, so
+// that the work queue doesn't retry earlier.
+func (r *Reconciler) requeueIfRateLimited(ctx context.Context, src *v1alpha1.ZendeskSource, err error) error {
+	var rlErr *rateLimitedError
+	if !errors.As(err, &rlErr) {
+		return err
+	}
+
+	event.Warn(ctx, ReasonAPIRateLimited, "Requests to the Zendesk API are rate limited. Retrying in %s",
+		rlErr.retryAfter)
+	r.enqueueKeyAfter(sourceKey(src), rlErr.retryAfter)
+
+	return nil
+}
+
+// zendeskAPI is the subset of the Zendesk API used by the reconciler.
+type zendeskAPI interface {
+	zendesk.TargetAPI
+	zendesk.TriggerAPI
+}
+
+// webhookAPI is the subset of the Zendesk Webhooks API used by the reconciler.
+type webhookAPI interface {
+	GetWebhook(ctx context.Context, id string) (*webhooks.Webhook, error)
+	FindWebhook(ctx context.Context, name string) (*webhooks.Webhook, error)
+	ListWebhooks(ctx context.Context, nameContains string) ([]webhooks.Webhook, error)
+	CreateWebhook(ctx context.Context, wh webhooks.Webhook) (*webhooks.Webhook, error)
+	UpdateWebhook(ctx context.Context, id string, wh webhooks.Webhook) error
+	DeleteWebhook(ctx context.Context, id string) error
+}
+
+// Fields of the Trigger actions which notify the adapter.
+const (
+	notificationTargetAction  = "notification_target"
+	notificationWebhookAction = "notification_webhook"
+)
+
+// syncTargetAndTriggers ensures the Zendesk Target and Triggers of the given
+// source exist and match their desired state, and that the source has no
+// webhooks left from the Webhooks notification API.
+// Because the password of a Target can not be read back from the Zendesk API,
+// the Target is also updated whenever the given digest of its credentials
+// differs from the one recorded in the source's status.
+func syncTargetAndTriggers(ctx context.Context, client zendeskAPI, whClient webhookAPI,
+	src *v1alpha1.ZendeskSource, tt *triggerTemplate, desiredTarget *zendesk.Target, credsDigest string) error {
+
+	status := &src.Status
+
+	prevTargetID := status.TargetID
+
+	target, targetModified, err := syncTarget(ctx, client, src, desiredTarget, credsDigest)
+	if err != nil {
+		return err
+	}
+	status.TargetID = strconv.FormatInt(target.ID, 10)
+	status.TargetCredentialsDigest = credsDigest
+
+	triggersModified, err := syncTriggers(ctx, client, src, tt, notificationTargetAction, status.TargetID)
 	if err != nil {
-		return fmt.Errorf("creating Zendesk client: %w", err)
+		return err
 	}
-	if err := client.SetSubdomain(spec.Subdomain); err != nil {
-		return fmt.Errorf("setting Zendesk subdomain: %w", err)
+
+	// Triggers must stop notifying a webhook before it can be deleted.
+	// A source which was already notified via a Target has no webhook left.
+	if !isTargetID(prevTargetID) {
+		if err := deleteWebhook(ctx, whClient, targetTitle(src)); err != nil {
+			markSyncFailed(status, "Unable to delete webhook", err)
+			return err
+		}
 	}
-	client.SetCredential(cred)
 
-	title := targetTitle(src)
+	if status.EventsWebhookID != "" {
+		if err := deleteWebhook(ctx, whClient, eventsWebhookName(src)); err != nil {
+			markSyncFailed(status, "Unable to delete webhook", err)
+			return err
+		}
+		status.EventsWebhookID = ""
+	}
 
-	targets, _, err := client.GetTargets(ctx)
+	status.MarkTargetSyncedAt(metav1.Now(), targetModified || triggersModified)
+
+	return nil
+}
+
+// syncWebhookAndTriggers ensures the Zendesk webhooks and Triggers of the
+// given source exist and match their desired state, and that the source has
+// no Target left from the Targets notification API.
+// The desired webhook is notified by Triggers about ticket events, while the
+// desired events webhook, which is nil when the source doesn't subscribe to
+// any user or organization event, is subscribed to these events directly.
+func syncWebhookAndTriggers(ctx context.Context, client zendeskAPI, whClient webhookAPI,
+	src *v1alpha1.ZendeskSource, tt *triggerTemplate, desiredWebhook, desiredEventsWebhook *webhooks.Webhook) error {
+
+	status := &src.Status
+
+	prevTargetID := status.TargetID
+
+	wh, whModified, err := syncWebhook(ctx, whClient, src, status.TargetID, desiredWebhook)
+	if err != nil {
+		return err
+	}
+	status.TargetID = wh.ID
+
+	triggersModified, err := syncTriggers(ctx, client, src, tt, notificationWebhookAction, wh.ID)
+	if err != nil {
+		return err
+	}
+
+	eventsWhModified, err := syncEventsWebhook(ctx, whClient, src, desiredEventsWebhook)
+	if err != nil {
+		return err
+	}
+
+	// Triggers must stop notifying a Target before it can be deleted.
+	// A source which was already notified via a webhook has no Target left.
+	if prevTargetID == "" || isTargetID(prevTargetID) {
+		if err := deleteTarget(ctx, client, targetTitle(src)); err != nil {
+			markSyncFailed(status, "Unable to delete Target", err)
+			return err
+		}
+	}
+	status.TargetCredentialsDigest = ""
+
+	status.MarkTargetSyncedAt(metav1.Now(), whModified || triggersModified || eventsWhModified)
+
+	return nil
+}
+
+// syncEventsWebhook ensures the Zendesk webhook which is subscribed to the
+// user and organization events of the given source exists and matches its
+// desired state, or is deleted if the desired webhook is nil. It returns
+// whether the webhook was modified.
+func syncEventsWebhook(ctx context.Context, whClient webhookAPI, src *v1alpha1.ZendeskSource,
+	desiredWebhook *webhooks.Webhook) (bool, error) {
+
+	status := &src.Status
+
+	if desiredWebhook == nil {
+		if status.EventsWebhookID == "" {
+			return false, nil
+		}
+
+		if err := deleteWebhook(ctx, whClient, eventsWebhookName(src)); err != nil {
+			markSyncFailed(status, "Unable to delete webhook", err)
+			return false, err
+		}
+		status.EventsWebhookID = ""
+
+		return true, nil
+	}
+
+	wh, modified, err := syncWebhook(ctx, whClient, src, status.EventsWebhookID, desiredWebhook)
+	if err != nil {
+		return false, err
+	}
+	status.EventsWebhookID = wh.ID
+
+	return modified, nil
+}
+
+// syncTarget ensures the Zendesk Target of the given source exists and
+// matches its desired state, and returns it along with whether it was
+// modified.
+func syncTarget(ctx context.Context, client zendesk.TargetAPI, src *v1alpha1.ZendeskSource,
+	desiredTarget *zendesk.Target, credsDigest string) (*zendesk.Target, bool, error) {
+
+	status := &src.Status
+
+	currentTarget, err := lookupTarget(ctx, client, status.TargetID, desiredTarget.Title)
 	switch {
 	case isDenied(err):
-		return controller.NewPermanentError(err)
+		markSyncFailed(status, "Unable to retrieve Target", err)
+		return nil, false, controller.NewPermanentError(err)
 
 	case err != nil:
-		status.MarkTargetNotSynced(v1alpha1.ZendeskReasonFailedSync, "Unable to list Targets")
-		return fmt.Errorf("retrieving Zendesk Targets: %w", err)
+		markSyncFailed(status, "Unable to retrieve Target", err)
+		return nil, false, fmt.Errorf("retrieving Zendesk Target: %w", err)
 	}
 
-	var currentTarget *zendesk.Target
-	for _, t := range targets {
-		if t.Title == title {
-			currentTarget = &t
-			break
+	if currentTarget == nil {
+		resp, err := client.CreateTarget(ctx, *desiredTarget)
+		if err != nil {
+			markSyncFailed(status, "Unable to create Target", err)
+			return nil, false, fmt.Errorf("creating Zendesk Target: %w", err)
 		}
+		return &resp, true, nil
 	}
 
-	// we only require the Target to exist, users can modify its contents
-	// after the integration is setup for the first time.
-	if currentTarget == nil {
-		desiredTarget := zendesk.Target{
-			Title:       title,
-			Type:        "http_target",
-			TargetURL:   url.String(),
-			Method:      "post",
-			Username:    spec.WebhookUsername,
-			Password:    webhookPassword,
-			ContentType: "application/json",
-		}
+	drift := targetDrift(desiredTarget, currentTarget)
+	if status.TargetCredentialsDigest != credsDigest {
+		drift = append(drift, "credentials")
+	}
+	if len(drift) == 0 {
+		return currentTarget, false, nil
+	}
+
+	resp, err := client.UpdateTarget(ctx, currentTarget.ID, *desiredTarget)
+	if err != nil {
+		markSyncFailed(status, "Unable to update Target", err)
+		return nil, false, fmt.Errorf("updating Zendesk Target: %w", err)
+	}
+	event.Normal(ctx, ReasonTargetUpdated, "Zendesk Target %q was updated to correct its %s",
+		desiredTarget.Title, strings.Join(drift, ", "))
+
+	return &resp, true, nil
+}
+
+// syncWebhook ensures the given Zendesk webhook of the given source exists
+// and matches its desired state, and returns it along with whether it was
+// modified. The webhook is retrieved by the given ID when it is known.
+func syncWebhook(ctx context.Context, whClient webhookAPI, src *v1alpha1.ZendeskSource,
+	id string, desiredWebhook *webhooks.Webhook) (*webhooks.Webhook, bool, error) {
+
+	status := &src.Status
+
+	currentWebhook, err := lookupWebhook(ctx, whClient, id, desiredWebhook.Name)
+	switch {
+	case isDenied(err):
+		markSyncFailed(status, "Unable to retrieve webhook", err)
+		return nil, false, controller.NewPermanentError(err)
+
+	case err != nil:
+		markSyncFailed(status, "Unable to retrieve webhook", err)
+		return nil, false, fmt.Errorf("retrieving Zendesk webhook: %w", err)
+	}
 
-		resp, err := client.CreateTarget(ctx, desiredTarget)
+	if currentWebhook == nil {
+		wh, err := whClient.CreateWebhook(ctx, *desiredWebhook)
 		if err != nil {
-			// TODO: It could happen that the target already exists
-			// but is in a different page. We will need to support
-			// pagination in a future release of this source.
-			status.MarkTargetNotSynced(v1alpha1.ZendeskReasonFailedSync, "Unable to create Target")
-			return fmt.Errorf("creating Zendesk Target: %w", err)
+			markSyncFailed(status, "Unable to create webhook", err)
+			return nil, false, fmt.Errorf("creating Zendesk webhook: %w", err)
 		}
-		currentTarget = &resp
+		return wh, true, nil
 	}
 
-	triggers, _, err := client.GetTriggers(ctx, &zendesk.TriggerListOptions{})
-	if err != nil {
-		status.MarkTargetNotSynced(v1alpha1.ZendeskReasonFailedSync, "Unable to list Triggers")
-		return fmt.Errorf("retrieving Zendesk Triggers: %w", err)
+	drift := webhookDrift(desiredWebhook, currentWebhook)
+	if len(drift) == 0 {
+		return currentWebhook, false, nil
 	}
 
-	var currentTrigger *zendesk.Trigger
-	for _, t := range triggers {
-		if t.Title == title {
-			currentTrigger = &t
-			break
+	if err := whClient.UpdateWebhook(ctx, currentWebhook.ID, *desiredWebhook); err != nil {
+		markSyncFailed(status, "Unable to update webhook", err)
+		return nil, false, fmt.Errorf("updating Zendesk webhook: %w", err)
+	}
+	event.Normal(ctx, ReasonTargetUpdated, "Zendesk webhook %q was updated to correct its %s",
+		desiredWebhook.Name, strings.Join(drift, ", "))
+
+	wh := *desiredWebhook
+	wh.ID = currentWebhook.ID
+	return &wh, true, nil
+}
+
+// syncTriggers ensures the Zendesk Triggers of the events the given source
+// subscribes to exist and match their desired state, and deletes the
+// Triggers of events the source no longer subscribes to. It returns whether
+// any Trigger was modified.
+// Triggers are created from the given template, and notify the recipient with
+// the given ID using the given action.
+// Triggers are retrieved by the IDs recorded in the source's status. All
+// Triggers are listed only when the ID of a Trigger is unknown or stale.
+func syncTriggers(ctx context.Context, client zendesk.TriggerAPI, src *v1alpha1.ZendeskSource,
+	tt *triggerTemplate, action, recipientID string) (bool, error) {
+
+	status := &src.Status
+
+	knownIDs := make(map[v1alpha1.ZendeskEvent]int64, len(status.Triggers))
+	for _, t := range status.Triggers {
+		knownIDs[t.Event] = t.ID
+	}
+
+	var triggers []zendesk.Trigger
+	var listed bool
+
+	var triggerStatuses []v1alpha1.ZendeskTriggerStatus
+	var modified bool
+
+	desiredTriggers := make(map[string]struct{})
+
+	for _, ev := range src.GetEvents() {
+		desiredTrigger, ok := newTrigger(src, ev, tt, action, recipientID)
+		if !ok {
+			continue
+		}
+		desiredTriggers[desiredTrigger.Title] = struct{}{}
+
+		var currentTrigger *zendesk.Trigger
+
+		if id, ok := knownIDs[ev]; ok {
+			t, err := client.GetTrigger(ctx, id)
+			switch {
+			case isNotFound(err):
+				// deleted outside of the reconciler, looked up by title below
+			case err != nil:
+				markSyncFailed(status, "Unable to retrieve Trigger", err)
+				return false, fmt.Errorf("retrieving Zendesk Trigger: %w", err)
+			case t.Title == desiredTrigger.Title:
+				currentTrigger = &t
+			}
 		}
+
+		if currentTrigger == nil {
+			if !listed {
+				var err error
+				if triggers, _, err = client.GetTriggers(ctx, &zendesk.TriggerListOptions{}); err != nil {
+					markSyncFailed(status, "Unable to list Triggers", err)
+					return false, fmt.Errorf("retrieving Zendesk Triggers: %w", err)
+				}
+				listed = true
+			}
+			currentTrigger = findTrigger(triggers, desiredTrigger.Title)
+		}
+
+		if currentTrigger == nil {
+			t, err := client.CreateTrigger(ctx, *desiredTrigger)
+			if err != nil {
+				markSyncFailed(status, "Unable to create Trigger", err)
+				return false, fmt.Errorf("creating Zendesk Trigger: %w", err)
+			}
+			modified = true
+			triggerStatuses = append(triggerStatuses, v1alpha1.ZendeskTriggerStatus{Event: ev, ID: t.ID})
+			continue
+		}
+
+		triggerStatuses = append(triggerStatuses, v1alpha1.ZendeskTriggerStatus{Event: ev, ID: currentTrigger.ID})
+
+		drift := triggerDrift(desiredTrigger, currentTrigger)
+		if len(drift) == 0 {
+			continue
+		}
+
+		if _, err := client.UpdateTrigger(ctx, currentTrigger.ID, *desiredTrigger); err != nil {
+			markSyncFailed(status, "Unable to update Trigger", err)
+			return false, fmt.Errorf("updating Zendesk Trigger: %w", err)
+		}
+		modified = true
+		event.Normal(ctx, ReasonTargetUpdated, "Zendesk Trigger %q was updated to correct its %s",
+			desiredTrigger.Title, strings.Join(drift, ", "))
 	}
 
-	// we only require the Trigger to exist, users can modify its contents
-	// after the integration is setup for the first time.
-	if currentTrigger == nil {
-		desiredTrigger := zendesk.Trigger{
-			Title: title,
-			Actions: []zendesk.TriggerAction{{
-				Field: "notification_target",
-				Value: []string{
-					strconv.FormatInt(currentTarget.ID, 10),
-					triggerPayloadJSON,
-				},
-			}},
+	// delete the Triggers of events the source no longer subscribes to
+	var staleTriggers []zendesk.Trigger
+	if listed {
+		for _, t := range triggers {
+			if _, desired := desiredTriggers[t.Title]; !desired && isTriggerOf(src, t.Title) {
+				staleTriggers = append(staleTriggers, t)
+			}
+		}
+	} else {
+		for _, t := range status.Triggers {
+			title := triggerTitle(src, t.Event)
+			if _, desired := desiredTriggers[title]; !desired {
+				staleTriggers = append(staleTriggers, zendesk.Trigger{ID: t.ID, Title: title})
+			}
+		}
+	}
+
+	for _, t := range staleTriggers {
+		switch err := client.DeleteTrigger(ctx, t.ID); {
+		case isNotFound(err):
+			// already deleted
+		case err != nil:
+			markSyncFailed(status, "Unable to delete Trigger", err)
+			return false, fmt.Errorf("deleting Zendesk Trigger: %w", err)
+		default:
+			modified = true
+			event.Normal(ctx, ReasonTargetDeleted, "Zendesk Trigger %q was deleted", t.Title)
+		}
+	}
+
+	status.Triggers = triggerStatuses
+
+	return modified, nil
+}
+
+// lookupTarget returns the Zendesk Target with the given title, if it exists.
+// The Target is retrieved by the given ID when it is known, and looked up in
+// the list of all Targets otherwise.
+func lookupTarget(ctx context.Context, client zendesk.TargetAPI, id, title string) (*zendesk.Target, error) {
+	if isTargetID(id) {
+		targetID, _ := strconv.ParseInt(id, 10, 64)
+
+		t, err := client.GetTarget(ctx, targetID)
+		switch {
+		case isNotFound(err):
+			// deleted outside of the reconciler, looked up by title below
+		case err != nil:
+			return nil, err
+		case t.Title == title:
+			return &t, nil
 		}
-		desiredTrigger.Conditions.All = []zendesk.TriggerCondition{{
-			Field:    "update_type",
-			Operator: "is",
-			Value:    "Create",
-		}}
-
-		if _, err := client.CreateTrigger(ctx, desiredTrigger); err != nil {
-			status.MarkTargetNotSynced(v1alpha1.ZendeskReasonFailedSync, "Unable to create Trigger")
-			return fmt.Errorf("creating Zendesk Trigger: %w", err)
+	}
+
+	targets, _, err := client.GetTargets(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return findTarget(targets, title), nil
+}
+
+// lookupWebhook returns the Zendesk webhook with the given name, if it
+// exists. The webhook is retrieved by the given ID when it is known, and
+// searched by name otherwise.
+func lookupWebhook(ctx context.Context, whClient webhookAPI, id, name string) (*webhooks.Webhook, error) {
+	if id != "" && !isTargetID(id) {
+		wh, err := whClient.GetWebhook(ctx, id)
+		switch {
+		case isNotFound(err):
+			// deleted outside of the reconciler, searched by name below
+		case err != nil:
+			return nil, err
+		case wh.Name == name:
+			return wh, nil
 		}
 	}
 
-	status.MarkTargetSynced()
+	return whClient.FindWebhook(ctx, name)
+}
+
+// isTargetID returns whether the given ID is the ID of a Zendesk Target.
+// IDs of Zendesk Targets are numeric, unlike the IDs of Zendesk webhooks.
+func isTargetID(id string) bool {
+	_, err := strconv.ParseInt(id, 10, 64)
+	return err == nil
+}
+
+// markSyncFailed sets the TargetSynced condition of the given status to False
+// with a reason matching the given error returned by the Zendesk API, and
+// records the details of that error.
+func markSyncFailed(status *v1alpha1.ZendeskSourceStatus, msg string, err error) {
+	reason := v1alpha1.ZendeskReasonFailedSync
+	switch {
+	case isDenied(err):
+		reason = v1alpha1.ZendeskReasonAPIAccessDenied
+	case apiStatusCode(err) == http.StatusTooManyRequests:
+		reason = v1alpha1.ZendeskReasonAPIRateLimited
+	}
+
+	// the error is recorded without a timestamp, so that repeated
+	// failures don't cause the status to be updated on every attempt
+	status.LastSyncError = &v1alpha1.ZendeskAPIError{
+		StatusCode: apiStatusCode(err),
+		Message:    err.Error(),
+	}
+	status.MarkTargetNotSynced(reason, msg)
+}
+
+// deleteTarget deletes the Zendesk Target with the given title, if it exists.
+func deleteTarget(ctx context.Context, client zendesk.TargetAPI, title string) error {
+	targets, _, err := client.GetTargets(ctx)
+	if err != nil {
+		return fmt.Errorf("retrieving Zendesk Targets: %w", err)
+	}
+
+	currentTarget := findTarget(targets, title)
+	if currentTarget == nil {
+		return nil
+	}
+
+	if err := client.DeleteTarget(ctx, currentTarget.ID); err != nil {
+		return fmt.Errorf("deleting Zendesk Target: %w", err)
+	}
+	event.Normal(ctx, ReasonTargetDeleted, "Zendesk Target %q was deleted", title)
+
+	return nil
+}
+
+// deleteWebhook deletes the Zendesk webhook with the given name, if it exists.
+func deleteWebhook(ctx context.Context, whClient webhookAPI, name string) error {
+	wh, err := whClient.FindWebhook(ctx, name)
+	if err != nil {
+		return fmt.Errorf("retrieving Zendesk webhooks: %w", err)
+	}
+	if wh == nil {
+		return nil
+	}
+
+	if err := whClient.DeleteWebhook(ctx, wh.ID); err != nil {
+		return fmt.Errorf("deleting Zendesk webhook: %w", err)
+	}
+	event.Normal(ctx, ReasonTargetDeleted, "Zendesk webhook %q was deleted", name)
 
 	return nil
 }
@@ -184,8 +610,9 @@ func (r *Reconciler) ensureNoZendeskTargetAndTrigger(ctx context.Context) error
 	title := targetTitle(src)
 
 	spec := src.(pkgapis.HasSpec).GetUntypedSpec().(v1alpha1.ZendeskSourceSpec)
+	typedSrc := src.(*v1alpha1.ZendeskSource)
 
-	apiToken, err := r.secretFrom(ctx, src.GetNamespace(), spec.Token.SecretKeyRef)
+	creds, err := r.apiCredentialsFor(ctx, typedSrc)
 	switch {
 	case apierrors.IsNotFound(err):
 		// the finalizer is unlikely to recover from a missing Secret,
@@ -194,19 +621,20 @@ func (r *Reconciler) ensureNoZendeskTargetAndTrigger(ctx context.Context) error
 			"Ignoring: %s", title, err)
 		return nil
 
+	case controller.IsPermanentError(err):
+		event.Warn(ctx, ReasonFailedTargetDelete, "Invalid credentials while finalizing Zendesk Target %q. "+
+			"Ignoring: %s", title, err)
+		return nil
+
 	case err != nil:
-		return fmt.Errorf("reading Zendesk API token: %w", err)
+		return fmt.Errorf("reading Zendesk API credentials: %w", err)
 	}
 
-	cred := zendesk.NewAPITokenCredential(spec.Email, apiToken)
-	client, err := zendesk.NewClient(nil)
+	clients, err := r.zendeskClients.clientsFor(sourceKey(typedSrc), spec.Subdomain, creds)
 	if err != nil {
-		return fmt.Errorf("creating Zendesk client: %w", err)
-	}
-	if err := client.SetSubdomain(spec.Subdomain); err != nil {
-		return fmt.Errorf("setting Zendesk subdomain: %w", err)
+		return err
 	}
-	client.SetCredential(cred)
+	client, whClient := clients.api, clients.webhooks
 
 	triggers, _, err := client.GetTriggers(ctx, &zendesk.TriggerListOptions{})
 	switch {
@@ -225,57 +653,352 @@ func (r *Reconciler) ensureNoZendeskTargetAndTrigger(ctx context.Context) error
 		return fmt.Errorf("%w", event)
 	}
 
-	var currentTrigger *zendesk.Trigger
 	for _, t := range triggers {
-		if t.Title == title {
-			currentTrigger = &t
-			break
+		if !isTriggerOf(src, t.Title) {
+			continue
 		}
-	}
 
-	if currentTrigger != nil {
-		if err := client.DeleteTrigger(ctx, currentTrigger.ID); err != nil {
+		if err := client.DeleteTrigger(ctx, t.ID); err != nil {
 			// wrap the error to fail the finalization
 			event := reconciler.NewEvent(corev1.EventTypeWarning, ReasonFailedTargetDelete,
-				"Error finalizing Zendesk Trigger %q: %s", title, err)
+				"Error finalizing Zendesk Trigger %q: %s", t.Title, err)
 			return fmt.Errorf("%w", event)
 		}
-		event.Normal(ctx, ReasonTargetDeleted, "Zendesk Trigger %q was deleted", title)
+		event.Normal(ctx, ReasonTargetDeleted, "Zendesk Trigger %q was deleted", t.Title)
 	}
 
-	targets, _, err := client.GetTargets(ctx)
-	if err != nil {
-		// wrap any other error to fail the finalization
+	// the source may have used either notification API in the past, so
+	// both the Target and the webhook are deleted if they exist
+	if err := deleteTarget(ctx, client, title); err != nil {
+		// wrap the error to fail the finalization
 		event := reconciler.NewEvent(corev1.EventTypeWarning, ReasonFailedTargetDelete,
-			"Error retrieving Zendesk Targets: %s", err)
+			"Error finalizing Zendesk Target %q: %s", title, err)
 		return fmt.Errorf("%w", event)
 	}
 
-	var currentTarget *zendesk.Target
-	for _, t := range targets {
-		if t.Title == title {
-			currentTarget = &t
-			break
-		}
-	}
-
-	if currentTarget != nil {
-		if err := client.DeleteTarget(ctx, currentTarget.ID); err != nil {
+	for _, name := range []string{title, eventsWebhookName(src)} {
+		if err := deleteWebhook(ctx, whClient, name); err != nil {
 			// wrap the error to fail the finalization
 			event := reconciler.NewEvent(corev1.EventTypeWarning, ReasonFailedTargetDelete,
-				"Error finalizing Zendesk Target %q: %s", title, err)
+				"Error finalizing Zendesk webhook %q: %s", name, err)
 			return fmt.Errorf("%w", event)
 		}
-		event.Normal(ctx, ReasonTargetDeleted, "Zendesk Target %q was deleted", title)
 	}
 
 	return nil
 }
 
+// newTarget returns a Zendesk Target which sends notifications to the given
+// URL using the given Basic Authentication credentials.
+func newTarget(src metav1.Object, url, username, password string) *zendesk.Target {
+	return &zendesk.Target{
+		Title:       targetTitle(src),
+		Type:        "http_target",
+		Active:      true,
+		TargetURL:   url,
+		Method:      "post",
+		Username:    username,
+		Password:    password,
+		ContentType: "application/json",
+	}
+}
+
+// newWebhook returns a Zendesk webhook which sends the notifications of
+// Triggers to the given URL.
+func newWebhook(src metav1.Object, url string) *webhooks.Webhook {
+	return &webhooks.Webhook{
+		Name:          targetTitle(src),
+		Status:        webhooks.StatusActive,
+		Endpoint:      url,
+		HTTPMethod:    webhooks.MethodPost,
+		RequestFormat: webhooks.RequestFormatJSON,
+		Subscriptions: []string{webhooks.SubscriptionConditionalTicketEvents},
+	}
+}
+
+// newEventsWebhook returns a Zendesk webhook which sends notifications about
+// the user and organization events the given source subscribes to to the
+// given URL, or nil if the source doesn't subscribe to any of these events.
+func newEventsWebhook(src *v1alpha1.ZendeskSource, url string) *webhooks.Webhook {
+	eventTypes := src.GetWebhookEventTypes()
+	if len(eventTypes) == 0 {
+		return nil
+	}
+
+	// the Zendesk API doesn't preserve the order of subscriptions
+	sort.Strings(eventTypes)
+
+	return &webhooks.Webhook{
+		Name:          eventsWebhookName(src),
+		Status:        webhooks.StatusActive,
+		Endpoint:      url,
+		HTTPMethod:    webhooks.MethodPost,
+		RequestFormat: webhooks.RequestFormatJSON,
+		Subscriptions: eventTypes,
+	}
+}
+
+// credentialsDigest returns a digest of the given Basic Authentication
+// credentials. The digest is keyed with the given secret of the Zendesk API
+// credentials, so that the credentials can't be guessed from it.
+func credentialsDigest(secret, username, password string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	_, _ = mac.Write([]byte(username + ":" + password))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// targetDrift returns the names of the attributes of the current Target which
+// differ from the desired Target. The password of a Target is never returned
+// by the Zendesk API, and therefore not compared.
+func targetDrift(desired, current *zendesk.Target) []string {
+	var drift []string
+
+	if current.Type != desired.Type {
+		drift = append(drift, "type")
+	}
+	if current.Active != desired.Active {
+		drift = append(drift, "active state")
+	}
+	if current.TargetURL != desired.TargetURL {
+		drift = append(drift, "URL")
+	}
+	if current.Method != desired.Method {
+		drift = append(drift, "method")
+	}
+	if current.Username != desired.Username {
+		drift = append(drift, "username")
+	}
+	if current.ContentType != desired.ContentType {
+		drift = append(drift, "content type")
+	}
+
+	return drift
+}
+
+// webhookDrift returns the names of the attributes of the current webhook
+// which differ from the desired webhook.
+func webhookDrift(desired, current *webhooks.Webhook) []string {
+	var drift []string
+
+	if current.Status != desired.Status {
+		drift = append(drift, "status")
+	}
+	if current.Endpoint != desired.Endpoint {
+		drift = append(drift, "endpoint")
+	}
+	if current.HTTPMethod != desired.HTTPMethod {
+		drift = append(drift, "method")
+	}
+	if current.RequestFormat != desired.RequestFormat {
+		drift = append(drift, "request format")
+	}
+	if !jsonEqual(sortedStrings(current.Subscriptions), sortedStrings(desired.Subscriptions)) {
+		drift = append(drift, "subscriptions")
+	}
+
+	return drift
+}
+
+// triggerDrift returns the names of the attributes of the current Trigger
+// which differ from the desired Trigger.
+func triggerDrift(desired, current *zendesk.Trigger) []string {
+	var drift []string
+
+	if current.Active != desired.Active {
+		drift = append(drift, "active state")
+	}
+	if !jsonEqual(current.Conditions.All, desired.Conditions.All) ||
+		!jsonEqual(current.Conditions.Any, desired.Conditions.Any) {
+
+		drift = append(drift, "conditions")
+	}
+	if !jsonEqual(current.Actions, desired.Actions) {
+		drift = append(drift, "actions")
+	}
+
+	return drift
+}
+
+// jsonEqual returns whether the JSON representations of the given lists are
+// equal. Empty and nil lists are considered equal.
+// Values decoded from Zendesk API responses have dynamic types (e.g.
+// []interface{} instead of []string), which only compare equal to the
+// values of the desired objects once serialized.
+func jsonEqual(a, b interface{}) bool {
+	aJSON, errA := json.Marshal(a)
+	bJSON, errB := json.Marshal(b)
+	if errA != nil || errB != nil {
+		return false
+	}
+
+	const null, empty = "null", "[]"
+	if string(aJSON) == null {
+		aJSON = []byte(empty)
+	}
+	if string(bJSON) == null {
+		bJSON = []byte(empty)
+	}
+
+	return string(aJSON) == string(bJSON)
+}
+
+// sortedStrings returns a sorted copy of the given list.
+func sortedStrings(l []string) []string {
+	sorted := append([]string(nil), l...)
+	sort.Strings(sorted)
+	return sorted
+}
+
+// findTarget returns the Target with the given title, if any.
+func findTarget(targets []zendesk.Target, title string) *zendesk.Target {
+	for i := range targets {
+		if targets[i].Title == title {
+			return &targets[i]
+		}
+	}
+	return nil
+}
+
+// titlePrefix is the prefix of the titles of all Zendesk Targets/Triggers
+// created for sources.
+const titlePrefix = "io.triggermesh.zendesksource."
+
 // targetTitle returns a Zendesk Target/Trigger title suitable for the given
 // source object.
 func targetTitle(src metav1.Object) string {
-	return "io.triggermesh.zendesksource." + src.GetNamespace() + "." + src.GetName()
+	return titlePrefix + src.GetNamespace() + "." + src.GetName()
+}
+
+// triggerTitle returns the title of the Zendesk Trigger of the given event
+// for the given source object.
+// The Trigger of the TicketCreated event is titled like the Target, which
+// was the only Trigger created for a source in prior releases.
+func triggerTitle(src metav1.Object, ev v1alpha1.ZendeskEvent) string {
+	if ev == v1alpha1.ZendeskTicketCreated {
+		return targetTitle(src)
+	}
+	// Kubernetes object names can't contain ':', which avoids collisions
+	// with the titles of other sources.
+	return targetTitle(src) + ":" + string(ev)
+}
+
+// eventsWebhookName returns the name of the Zendesk webhook which is
+// subscribed to the user and organization events of the given source object.
+func eventsWebhookName(src metav1.Object) string {
+	return targetTitle(src) + ":events"
+}
+
+// isTriggerOf returns whether the Zendesk Trigger with the given title belongs
+// to the given source object.
+func isTriggerOf(src metav1.Object, title string) bool {
+	t := targetTitle(src)
+	return title == t || strings.HasPrefix(title, t+":")
+}
+
+// findTrigger returns the Trigger with the given title, if any.
+func findTrigger(triggers []zendesk.Trigger, title string) *zendesk.Trigger {
+	for i := range triggers {
+		if triggers[i].Title == title {
+			return &triggers[i]
+		}
+	}
+	return nil
+}
+
+// newTrigger returns a Zendesk Trigger created from the given template, which
+// notifies the recipient (Target or webhook) with the given ID upon occurrences
+// of the given event, and whether the event is supported. The action
+// determines the type of recipient.
+func newTrigger(src metav1.Object, ev v1alpha1.ZendeskEvent, tt *triggerTemplate,
+	action, recipientID string) (*zendesk.Trigger, bool) {
+
+	eventType := ev.EventType()
+	conds, ok := triggerConditions[ev]
+	if eventType == "" || !ok {
+		return nil, false
+	}
+
+	trg := &zendesk.Trigger{
+		Title:  triggerTitle(src, ev),
+		Active: true,
+		Actions: []zendesk.TriggerAction{{
+			Field: action,
+			Value: []string{
+				recipientID,
+				triggerPayload(tt.payload, eventType),
+			},
+		}},
+	}
+	trg.Conditions.All = append(append([]zendesk.TriggerCondition(nil), conds.all...), tt.conditions.all...)
+	trg.Conditions.Any = append(append([]zendesk.TriggerCondition(nil), conds.any...), tt.conditions.any...)
+
+	return trg, true
+}
+
+// conditions are the conditions of a Zendesk Trigger. All the conditions in
+// "all" must be met, as well as at least one of the conditions in "any".
+// See: https://developer.zendesk.com/rest_api/docs/support/triggers#conditions-reference
+type conditions struct {
+	all []zendesk.TriggerCondition
+	any []zendesk.TriggerCondition
+}
+
+// updateTypeIs returns a Trigger condition on the type of update of a ticket
+// ("Create" or "Change").
+func updateTypeIs(typ string) zendesk.TriggerCondition {
+	return zendesk.TriggerCondition{Field: "update_type", Operator: "is", Value: typ}
+}
+
+// triggerConditions contains the conditions of the Trigger of each supported
+// Zendesk event.
+var triggerConditions = map[v1alpha1.ZendeskEvent]conditions{
+	v1alpha1.ZendeskTicketCreated: {
+		all: []zendesk.TriggerCondition{
+			updateTypeIs("Create"),
+		},
+	},
+	v1alpha1.ZendeskTicketUpdated: {
+		all: []zendesk.TriggerCondition{
+			updateTypeIs("Change"),
+		},
+	},
+	v1alpha1.ZendeskTicketSolved: {
+		all: []zendesk.TriggerCondition{
+			updateTypeIs("Change"),
+			// "value" means "changed to"
+			{Field: "status", Operator: "value", Value: "solved"},
+		},
+	},
+	v1alpha1.ZendeskTicketReopened: {
+		all: []zendesk.TriggerCondition{
+			updateTypeIs("Change"),
+			// "value_previous" means "changed from"
+			{Field: "status", Operator: "value_previous", Value: "solved"},
+			// solved tickets are closed automatically by Zendesk, which
+			// isn't a reopening
+			{Field: "status", Operator: "not_value", Value: "closed"},
+		},
+	},
+	v1alpha1.ZendeskTicketCommentAdded: {
+		all: []zendesk.TriggerCondition{
+			updateTypeIs("Change"),
+			// "not_relevant" means "present", either public or
+			// private, which leaves "any" to user-defined conditions
+			{Field: "comment_is_public", Operator: "is", Value: "not_relevant"},
+		},
+	},
+	v1alpha1.ZendeskTicketAssigneeChanged: {
+		all: []zendesk.TriggerCondition{
+			updateTypeIs("Change"),
+			{Field: "assignee_id", Operator: "changed"},
+		},
+	},
+	v1alpha1.ZendeskTicketSatisfactionRated: {
+		all: []zendesk.TriggerCondition{
+			updateTypeIs("Change"),
+			{Field: "satisfaction_score", Operator: "changed"},
+		},
+	},
 }
 
 // secretFrom retrieves a value from a Secret.
@@ -292,78 +1015,35 @@ func (r *Reconciler) secretFrom(ctx context.Context, namespace string, secretKey
 	return string(secretVal), nil
 }
 
+// isNotFound returns whether the given error indicates that a Zendesk object
+// does not exist.
+func isNotFound(err error) bool {
+	return apiStatusCode(err) == http.StatusNotFound
+}
+
+// apiStatusCode returns the HTTP status code of the Zendesk API response
+// which caused the given error, or 0 if no response was received.
+func apiStatusCode(err error) int {
+	var retrieveErr *oauth2.RetrieveError
+	if errors.As(err, &retrieveErr) && retrieveErr.Response != nil {
+		return retrieveErr.Response.StatusCode
+	}
+
+	// implemented by errors of both the Zendesk and Zendesk Webhooks APIs
+	var apiErr interface{ Status() int }
+	if errors.As(err, &apiErr) {
+		return apiErr.Status()
+	}
+	return 0
+}
+
 // isDenied returns whether the given error indicates that a request was denied
 // due to authentication issues.
 func isDenied(err error) bool {
-	if err, ok := err.(zendesk.Error); ok {
-		s := err.Status()
-		return s == http.StatusUnauthorized || s == http.StatusForbidden
-	}
-	return false
-}
-
-const triggerPayloadJSON = `{
-  "ticket": {
-    "id": {{ticket.id}},
-    "external_id": "{{ticket.external_id}}",
-    "title": "{{ticket.title}}",
-    "url": "{{ticket.url}}",
-    "description": "{{ticket.description}}",
-    "via": "{{ticket.via}}",
-    "status": "{{ticket.status}}",
-    "priority": "{{ticket.priority}}",
-    "ticket_type": "{{ticket.ticket_type}}",
-    "group_name": "{{ticket.group.name}}",
-    "brand_name": "{{ticket.brand.name}}",
-    "due_date": "{{ticket.due_date}}",
-    "account": "{{ticket.account}}",
-    "assignee": {
-      "email": "{{ticket.assignee.email}}",
-      "name": "{{ticket.assignee.name}}",
-      "first_name": "{{ticket.assignee.first_name}}",
-      "last_name": "{{ticket.assignee.last_name}}"
-    },
-    "requester": {
-      "name": "{{ticket.requester.name}}",
-      "first_name": "{{ticket.requester.first_name}}",
-      "last_name": "{{ticket.requester.last_name}}",
-      "email": "{{ticket.requester.email}}",
-      "language": "{{ticket.requester.language}}",
-      "phone": "{{ticket.requester.phone}}",
-      "external_id": "{{ticket.requester.external_id}}",
-      "field": "{{ticket.requester_field}}",
-      "details": "{{ticket.requester.details}}"
-    },
-    "organization": {
-      "name": "{{ticket.organization.name}}",
-      "external_id": "{{ticket.organization.external_id}}",
-      "details": "{{ticket.organization.details}}",
-      "notes": "{{ticket.organization.notes}}"
-    },
-    "ccs": "{{ticket.ccs}}",
-    "cc_names": "{{ticket.cc_names}}",
-    "tags": "{{ticket.tags}}",
-    "current_holiday_name": "{{ticket.current_holiday_name}}",
-    "ticket_field_id": "{{ticket.ticket_field_ID}}",
-    "ticket_field_option_title_id": "{{ticket.ticket_field_option_title_ID}}"
-  },
-  "current_user": {
-    "name": "{{current_user.name}}",
-    "first_name": "{{current_user.first_name}}",
-    "email": "{{current_user.email}}",
-    "organization": {
-      "name": "{{current_user.organization.name}}",
-      "notes": "{{current_user.organization.notes}}",
-      "details": "{{current_user.organization.details}}"
-    },
-    "external_id": "{{current_user.external_id}}",
-    "phone": "{{current_user.phone}}",
-    "details": "{{current_user.details}}",
-    "notes": "{{current_user.notes}}",
-    "language": "{{current_user.language}}"
-  },
-  "satisfaction": {
-    "current_rating": "{{satisfaction.current_rating}}",
-    "current_comment": "{{satisfaction.current_comment}}"
-  }
-}`
+	if isOAuthDenied(err) {
+		return true
+	}
+
+	s := apiStatusCode(err)
+	return s == http.StatusUnauthorized || s == http.StatusForbidden
+}
@@ -0,0 +1,997 @@
+/*
+Copyright (c) 2020 TriggerMesh Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package zendesksource
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/nukosuke/go-zendesk/zendesk"
+	"github.com/stretchr/testify/assert"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+
+	"knative.dev/pkg/controller"
+
+	"github.com/triggermesh/knative-sources/pkg/apis/sources/v1alpha1"
+	"github.com/triggermesh/knative-sources/pkg/zendesk/webhooks"
+)
+
+func TestNewTrigger(t *testing.T) {
+	src := &v1alpha1.ZendeskSource{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "test",
+			Name:      "my-source",
+		},
+	}
+
+	const targetID = "42"
+
+	tc := map[string]struct {
+		event v1alpha1.ZendeskEvent
+
+		expectTitle     string
+		expectEventType string
+	}{
+		"ticket created": {
+			event:           v1alpha1.ZendeskTicketCreated,
+			expectTitle:     "io.triggermesh.zendesksource.test.my-source",
+			expectEventType: v1alpha1.ZendeskTicketCreatedEventType,
+		},
+		"ticket solved": {
+			event:           v1alpha1.ZendeskTicketSolved,
+			expectTitle:     "io.triggermesh.zendesksource.test.my-source:TicketSolved",
+			expectEventType: v1alpha1.ZendeskTicketSolvedEventType,
+		},
+		"comment added": {
+			event:           v1alpha1.ZendeskTicketCommentAdded,
+			expectTitle:     "io.triggermesh.zendesksource.test.my-source:TicketCommentAdded",
+			expectEventType: v1alpha1.ZendeskTicketCommentAddedEventType,
+		},
+	}
+
+	for name, c := range tc {
+		t.Run(name, func(t *testing.T) {
+			trg, ok := newTrigger(src, c.event, defaultTriggerTemplate(), notificationTargetAction, targetID)
+			if !assert.True(t, ok, "event is not supported") {
+				return
+			}
+
+			assert.Equal(t, c.expectTitle, trg.Title)
+			assert.True(t, isTriggerOf(src, trg.Title), "Trigger does not belong to the source")
+			assert.NotEmpty(t, trg.Conditions.All, "Trigger has no condition")
+
+			actionValue := trg.Actions[0].Value.([]string)
+			assert.Equal(t, "42", actionValue[0], "Trigger does not notify the Target")
+
+			// placeholders are substituted by Zendesk, some of them
+			// with unquoted values
+			rendered := placeholderRegexp.ReplaceAllString(actionValue[1], "0")
+
+			payload := make(map[string]interface{})
+			assert.NoError(t, json.Unmarshal([]byte(rendered), &payload), "Trigger payload is not valid JSON")
+			assert.Equal(t, c.expectEventType, payload["event_type"])
+		})
+	}
+
+	_, ok := newTrigger(src, "Unknown", defaultTriggerTemplate(), notificationTargetAction, targetID)
+	assert.False(t, ok, "unsupported event should not have a Trigger")
+}
+
+// placeholderRegexp matches Zendesk placeholders.
+var placeholderRegexp = regexp.MustCompile(`{{[^}]+}}`)
+
+func TestIsTriggerOf(t *testing.T) {
+	src := &v1alpha1.ZendeskSource{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "test",
+			Name:      "my-source",
+		},
+	}
+
+	assert.True(t, isTriggerOf(src, "io.triggermesh.zendesksource.test.my-source"))
+	assert.True(t, isTriggerOf(src, "io.triggermesh.zendesksource.test.my-source:TicketUpdated"))
+	assert.False(t, isTriggerOf(src, "io.triggermesh.zendesksource.test.my-source.other"))
+	assert.False(t, isTriggerOf(src, "io.triggermesh.zendesksource.test.my-source-2:TicketUpdated"))
+	assert.False(t, isTriggerOf(src, "My own trigger"))
+}
+
+func TestSyncTargetAndTriggers(t *testing.T) {
+	const (
+		apiToken = "api-token"
+		username = "user"
+		password = "pass"
+
+		adapterURL = "https://zendesksource-my-source.test.example.com"
+	)
+
+	newSource := func() *v1alpha1.ZendeskSource {
+		src := &v1alpha1.ZendeskSource{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: "test",
+				Name:      "my-source",
+			},
+			Spec: v1alpha1.ZendeskSourceSpec{
+				Events: []v1alpha1.ZendeskEvent{
+					v1alpha1.ZendeskTicketCreated,
+					v1alpha1.ZendeskTicketSolved,
+				},
+			},
+		}
+		src.Status.TargetCredentialsDigest = credentialsDigest(apiToken, username, password)
+		return src
+	}
+
+	tc := map[string]struct {
+		// mutates the objects of a Zendesk API in sync with the source
+		drift func(api *fakeZendeskAPI)
+		// mutates the source
+		changeSource func(src *v1alpha1.ZendeskSource)
+
+		expectLists   []string
+		expectUpdates []string
+		expectEvents  []string
+	}{
+		"no drift": {},
+		"target URL changed": {
+			drift: func(api *fakeZendeskAPI) {
+				api.targets[0].TargetURL = "https://example.com/hijacked"
+			},
+			expectUpdates: []string{"PUT /targets/1.json"},
+			expectEvents: []string{
+				`Normal TargetUpdated Zendesk Target "io.triggermesh.zendesksource.test.my-source" ` +
+					`was updated to correct its URL`,
+			},
+		},
+		"target deactivated": {
+			drift: func(api *fakeZendeskAPI) {
+				api.targets[0].Active = false
+			},
+			expectUpdates: []string{"PUT /targets/1.json"},
+			expectEvents: []string{
+				`Normal TargetUpdated Zendesk Target "io.triggermesh.zendesksource.test.my-source" ` +
+					`was updated to correct its active state`,
+			},
+		},
+		"webhook password rotated": {
+			changeSource: func(src *v1alpha1.ZendeskSource) {
+				src.Status.TargetCredentialsDigest = credentialsDigest(apiToken, username, "old-pass")
+			},
+			expectUpdates: []string{"PUT /targets/1.json"},
+			expectEvents: []string{
+				`Normal TargetUpdated Zendesk Target "io.triggermesh.zendesksource.test.my-source" ` +
+					`was updated to correct its credentials`,
+			},
+		},
+		"trigger conditions and payload edited": {
+			drift: func(api *fakeZendeskAPI) {
+				trg := &api.triggers[1]
+				trg.Conditions.All = trg.Conditions.All[:1]
+				trg.Actions[0].Value = []interface{}{"1", "{}"}
+			},
+			expectUpdates: []string{"PUT /triggers/3.json"},
+			expectEvents: []string{
+				`Normal TargetUpdated Zendesk Trigger "io.triggermesh.zendesksource.test.my-source:TicketSolved" ` +
+					`was updated to correct its conditions, actions`,
+			},
+		},
+		"trigger deleted": {
+			drift: func(api *fakeZendeskAPI) {
+				api.triggers = api.triggers[:1]
+			},
+			expectLists:   []string{"GET /triggers.json"},
+			expectUpdates: []string{"POST /triggers.json"},
+		},
+		"target deleted": {
+			drift: func(api *fakeZendeskAPI) {
+				api.targets = nil
+			},
+			expectLists: []string{"GET /targets.json"},
+			expectUpdates: []string{
+				"POST /targets.json",
+				"PUT /triggers/2.json",
+				"PUT /triggers/3.json",
+			},
+			expectEvents: []string{
+				`Normal TargetUpdated Zendesk Trigger "io.triggermesh.zendesksource.test.my-source" ` +
+					`was updated to correct its actions`,
+				`Normal TargetUpdated Zendesk Trigger "io.triggermesh.zendesksource.test.my-source:TicketSolved" ` +
+					`was updated to correct its actions`,
+			},
+		},
+		"triggers beyond the first page": {
+			changeSource: func(src *v1alpha1.ZendeskSource) {
+				// forces the lookup of Triggers by title
+				src.Status.Triggers = nil
+			},
+			drift: func(api *fakeZendeskAPI) {
+				others := make([]zendesk.Trigger, 2*fakeDefaultPageSize+50)
+				for i := range others {
+					others[i].ID = int64(1000 + i)
+					others[i].Title = fmt.Sprintf("Some other trigger %d", i)
+				}
+				api.triggers = append(others, api.triggers...)
+			},
+			expectLists: []string{
+				"GET /triggers.json",
+				"GET /triggers.json",
+				"GET /triggers.json",
+			},
+		},
+		"migrated from webhook": {
+			changeSource: func(src *v1alpha1.ZendeskSource) {
+				src.Status.TargetID = "01WEBHOOK"
+			},
+			drift: func(api *fakeZendeskAPI) {
+				api.webhooks = append(api.webhooks, *newWebhook(newSource(), adapterURL))
+				api.webhooks[0].ID = "01WEBHOOK"
+				for i := range api.triggers {
+					api.triggers[i].Actions[0].Field = notificationWebhookAction
+					api.triggers[i].Actions[0].Value = []interface{}{"01WEBHOOK",
+						api.triggers[i].Actions[0].Value.([]interface{})[1]}
+				}
+			},
+			expectLists: []string{
+				"GET /targets.json",
+				"GET /webhooks",
+			},
+			expectUpdates: []string{
+				"PUT /triggers/2.json",
+				"PUT /triggers/3.json",
+				"DELETE /webhooks/01WEBHOOK",
+			},
+			expectEvents: []string{
+				`Normal TargetUpdated Zendesk Trigger "io.triggermesh.zendesksource.test.my-source" ` +
+					`was updated to correct its actions`,
+				`Normal TargetUpdated Zendesk Trigger "io.triggermesh.zendesksource.test.my-source:TicketSolved" ` +
+					`was updated to correct its actions`,
+				`Normal TargetDeleted Zendesk webhook "io.triggermesh.zendesksource.test.my-source" was deleted`,
+			},
+		},
+		"event unsubscribed": {
+			changeSource: func(src *v1alpha1.ZendeskSource) {
+				src.Spec.Events = src.Spec.Events[:1]
+			},
+			expectUpdates: []string{"DELETE /triggers/3.json"},
+			expectEvents: []string{
+				`Normal TargetDeleted Zendesk Trigger "io.triggermesh.zendesksource.test.my-source:TicketSolved" ` +
+					`was deleted`,
+			},
+		},
+	}
+
+	for name, c := range tc {
+		t.Run(name, func(t *testing.T) {
+			api := newFakeZendeskAPI()
+			srv := httptest.NewServer(api)
+			defer srv.Close()
+
+			client, whClient := newFakeZendeskClients(t, srv.URL, apiToken)
+
+			src := newSource()
+			desiredTarget := newTarget(src, adapterURL, username, password)
+			credsDigest := credentialsDigest(apiToken, username, password)
+
+			ctx := context.Background()
+
+			// populate the Zendesk API with objects in sync with the source
+			err := syncTargetAndTriggers(ctx, client, whClient, src, defaultTriggerTemplate(), desiredTarget, credsDigest)
+			if err != nil {
+				t.Fatalf("Error during initial sync: %s", err)
+			}
+			if len(api.targets) != 1 || len(api.triggers) != 2 {
+				t.Fatalf("Unexpected Zendesk objects after initial sync: %d Targets, %d Triggers",
+					len(api.targets), len(api.triggers))
+			}
+			api.resetRequests()
+
+			if c.drift != nil {
+				c.drift(api)
+			}
+			if c.changeSource != nil {
+				c.changeSource(src)
+			}
+
+			rec := record.NewFakeRecorder(10)
+			ctx = controller.WithEventRecorder(ctx, rec)
+			ctx = v1alpha1.WithSource(ctx, src)
+
+			err = syncTargetAndTriggers(ctx, client, whClient, src, defaultTriggerTemplate(), desiredTarget, credsDigest)
+			assert.NoError(t, err)
+
+			assert.Equal(t, c.expectLists, api.listRequests())
+			assert.Equal(t, c.expectUpdates, api.updateRequests())
+			assert.Equal(t, c.expectEvents, recordedEvents(rec))
+
+			assert.True(t, src.Status.GetCondition(v1alpha1.ZendeskConditionTargetSynced).IsTrue(),
+				"Target is not marked as synced")
+			assert.Equal(t, credsDigest, src.Status.TargetCredentialsDigest)
+			assert.Nil(t, src.Status.LastSyncError)
+			assertStatusIDs(t, api, src, fmt.Sprint(api.targets[0].ID))
+
+			// the drift is corrected
+			assert.Equal(t, desiredTarget.TargetURL, api.targets[0].TargetURL)
+			assert.True(t, api.targets[0].Active, "Target is not active")
+			assertTriggersInSync(t, api, src, notificationTargetAction, fmt.Sprint(api.targets[0].ID))
+		})
+	}
+}
+
+func TestSyncWebhookAndTriggers(t *testing.T) {
+	const (
+		apiToken = "api-token"
+
+		adapterURL = "https://zendesksource-my-source.test.example.com"
+	)
+
+	newSource := func() *v1alpha1.ZendeskSource {
+		return &v1alpha1.ZendeskSource{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: "test",
+				Name:      "my-source",
+			},
+			Spec: v1alpha1.ZendeskSourceSpec{
+				NotificationAPI: v1alpha1.ZendeskNotificationAPIWebhooks,
+			},
+		}
+	}
+
+	userEvents := []v1alpha1.ZendeskEvent{
+		v1alpha1.ZendeskTicketCreated,
+		v1alpha1.ZendeskUserCreated,
+		v1alpha1.ZendeskOrganizationUpdated,
+	}
+
+	tc := map[string]struct {
+		// populates the Zendesk API and the source's status before
+		// the sync
+		initAPI func(api *fakeZendeskAPI, src *v1alpha1.ZendeskSource)
+		events  []v1alpha1.ZendeskEvent
+
+		expectUpdates []string
+		expectEvents  []string
+	}{
+		"new source": {
+			expectUpdates: []string{
+				"POST /webhooks",
+				"POST /triggers.json",
+			},
+		},
+		"new source with user and organization events": {
+			events: userEvents,
+			expectUpdates: []string{
+				"POST /webhooks",
+				"POST /triggers.json",
+				"POST /webhooks",
+			},
+		},
+		"user and organization events unsubscribed": {
+			initAPI: func(api *fakeZendeskAPI, src *v1alpha1.ZendeskSource) {
+				wh := newWebhook(src, adapterURL)
+				wh.ID = "01WEBHOOK"
+				api.webhooks = append(api.webhooks, *wh)
+
+				trg, _ := newTrigger(src, v1alpha1.ZendeskTicketCreated, defaultTriggerTemplate(), notificationWebhookAction, wh.ID)
+				trg.ID = 1
+				api.triggers = append(api.triggers, *trg)
+
+				src.Spec.Events = userEvents
+				evWh := newEventsWebhook(src, adapterURL)
+				src.Spec.Events = nil
+				evWh.ID = "01EVENTSWEBHOOK"
+				api.webhooks = append(api.webhooks, *evWh)
+
+				src.Status.TargetID = wh.ID
+				src.Status.EventsWebhookID = evWh.ID
+			},
+			expectUpdates: []string{
+				"DELETE /webhooks/01EVENTSWEBHOOK",
+			},
+			expectEvents: []string{
+				`Normal TargetDeleted Zendesk webhook "io.triggermesh.zendesksource.test.my-source:events" was deleted`,
+			},
+		},
+		"webhook endpoint changed": {
+			initAPI: func(api *fakeZendeskAPI, _ *v1alpha1.ZendeskSource) {
+				wh := newWebhook(newSource(), "https://example.com/hijacked")
+				wh.ID = "01WEBHOOK"
+				api.webhooks = append(api.webhooks, *wh)
+
+				trg, _ := newTrigger(newSource(), v1alpha1.ZendeskTicketCreated, defaultTriggerTemplate(), notificationWebhookAction, wh.ID)
+				trg.ID = 1
+				api.triggers = append(api.triggers, *trg)
+			},
+			expectUpdates: []string{
+				"PUT /webhooks/01WEBHOOK",
+			},
+			expectEvents: []string{
+				`Normal TargetUpdated Zendesk webhook "io.triggermesh.zendesksource.test.my-source" ` +
+					`was updated to correct its endpoint`,
+			},
+		},
+		"migrated from target": {
+			initAPI: func(api *fakeZendeskAPI, _ *v1alpha1.ZendeskSource) {
+				tgt := newTarget(newSource(), adapterURL, "user", "")
+				tgt.ID = 1
+				api.targets = append(api.targets, *tgt)
+
+				trg, _ := newTrigger(newSource(), v1alpha1.ZendeskTicketCreated, defaultTriggerTemplate(), notificationTargetAction, "1")
+				trg.ID = 2
+				api.triggers = append(api.triggers, *trg)
+
+				api.lastID = 2
+			},
+			expectUpdates: []string{
+				"POST /webhooks",
+				"PUT /triggers/2.json",
+				"DELETE /targets/1.json",
+			},
+			expectEvents: []string{
+				`Normal TargetUpdated Zendesk Trigger "io.triggermesh.zendesksource.test.my-source" ` +
+					`was updated to correct its actions`,
+				`Normal TargetDeleted Zendesk Target "io.triggermesh.zendesksource.test.my-source" was deleted`,
+			},
+		},
+	}
+
+	for name, c := range tc {
+		t.Run(name, func(t *testing.T) {
+			src := newSource()
+			src.Status.TargetCredentialsDigest = "some-digest"
+
+			api := newFakeZendeskAPI()
+			if c.initAPI != nil {
+				c.initAPI(api, src)
+			}
+			src.Spec.Events = c.events
+
+			srv := httptest.NewServer(api)
+			defer srv.Close()
+
+			client, whClient := newFakeZendeskClients(t, srv.URL, apiToken)
+
+			rec := record.NewFakeRecorder(10)
+			ctx := controller.WithEventRecorder(context.Background(), rec)
+			ctx = v1alpha1.WithSource(ctx, src)
+
+			err := syncWebhookAndTriggers(ctx, client, whClient, src, defaultTriggerTemplate(),
+				newWebhook(src, adapterURL), newEventsWebhook(src, adapterURL))
+			assert.NoError(t, err)
+
+			assert.Equal(t, c.expectUpdates, api.updateRequests())
+			assert.Equal(t, c.expectEvents, recordedEvents(rec))
+
+			assert.True(t, src.Status.GetCondition(v1alpha1.ZendeskConditionTargetSynced).IsTrue(),
+				"Target is not marked as synced")
+			assert.Empty(t, src.Status.TargetCredentialsDigest, "credentials digest was not cleared")
+
+			assert.Empty(t, api.targets, "Target was not deleted")
+			if assert.NotEmpty(t, api.webhooks) {
+				assert.Equal(t, adapterURL, api.webhooks[0].Endpoint)
+				assertTriggersInSync(t, api, src, notificationWebhookAction, api.webhooks[0].ID)
+				assertStatusIDs(t, api, src, api.webhooks[0].ID)
+			}
+
+			if evWh := newEventsWebhook(src, adapterURL); evWh != nil {
+				if assert.Len(t, api.webhooks, 2) {
+					assert.Equal(t, src.Status.EventsWebhookID, api.webhooks[1].ID)
+					assert.Equal(t, evWh.Subscriptions, api.webhooks[1].Subscriptions)
+				}
+			} else {
+				assert.Len(t, api.webhooks, 1, "events webhook was not deleted")
+				assert.Empty(t, src.Status.EventsWebhookID)
+			}
+
+			// subsequent syncs retrieve the objects by the IDs
+			// recorded in the status
+			api.resetRequests()
+			err = syncWebhookAndTriggers(ctx, client, whClient, src, defaultTriggerTemplate(),
+				newWebhook(src, adapterURL), newEventsWebhook(src, adapterURL))
+			assert.NoError(t, err)
+			assert.Empty(t, api.listRequests())
+			assert.Empty(t, api.updateRequests())
+		})
+	}
+}
+
+func TestMarkSyncFailed(t *testing.T) {
+	tc := map[string]struct {
+		err          error
+		expectReason string
+		expectCode   int
+	}{
+		"access denied": {
+			err:          statusError(http.StatusForbidden),
+			expectReason: v1alpha1.ZendeskReasonAPIAccessDenied,
+			expectCode:   http.StatusForbidden,
+		},
+		"rate limited": {
+			err:          fmt.Errorf("wrapped: %w", statusError(http.StatusTooManyRequests)),
+			expectReason: v1alpha1.ZendeskReasonAPIRateLimited,
+			expectCode:   http.StatusTooManyRequests,
+		},
+		"server error": {
+			err:          statusError(http.StatusInternalServerError),
+			expectReason: v1alpha1.ZendeskReasonFailedSync,
+			expectCode:   http.StatusInternalServerError,
+		},
+		"no response": {
+			err:          errors.New("connection refused"),
+			expectReason: v1alpha1.ZendeskReasonFailedSync,
+		},
+	}
+
+	for name, c := range tc {
+		//nolint:scopelint
+		t.Run(name, func(t *testing.T) {
+			status := &v1alpha1.ZendeskSourceStatus{}
+			status.MarkTargetSyncedAt(metav1.Now(), true)
+
+			markSyncFailed(status, "Unable to do things", c.err)
+
+			cond := status.GetCondition(v1alpha1.ZendeskConditionTargetSynced)
+			assert.True(t, cond.IsFalse(), "Target is not marked as not synced")
+			assert.Equal(t, c.expectReason, cond.Reason)
+
+			if assert.NotNil(t, status.LastSyncError) {
+				assert.Equal(t, c.expectCode, status.LastSyncError.StatusCode)
+				assert.Equal(t, c.err.Error(), status.LastSyncError.Message)
+			}
+
+			status.MarkTargetSyncedAt(metav1.Now(), false)
+			assert.Nil(t, status.LastSyncError, "sync error was not cleared")
+		})
+	}
+}
+
+// statusError is an error which carries the status code of an API response.
+type statusError int
+
+func (e statusError) Error() string { return http.StatusText(int(e)) }
+func (e statusError) Status() int   { return int(e) }
+
+// newFakeZendeskClients returns clients of the Zendesk APIs which send their
+// requests to the given URL.
+func newFakeZendeskClients(t *testing.T, url, apiToken string) (*apiClient, *webhooks.Client) {
+	t.Helper()
+
+	creds := &apiCredentials{
+		email:    "me@example.com",
+		apiToken: apiToken,
+	}
+
+	clients, err := newZendeskClients(creds, "example")
+	if err != nil {
+		t.Fatalf("Error creating Zendesk clients: %s", err)
+	}
+	if err := clients.setEndpointURL(url); err != nil {
+		t.Fatalf("Error setting Zendesk endpoint URL: %s", err)
+	}
+
+	return clients.api, clients.webhooks
+}
+
+// assertTriggersInSync asserts that the Triggers of the given fake Zendesk API
+// match their desired state.
+func assertTriggersInSync(t *testing.T, api *fakeZendeskAPI, src *v1alpha1.ZendeskSource,
+	action, recipientID string) {
+
+	t.Helper()
+
+	for _, trg := range api.triggers {
+		if !isTriggerOf(src, trg.Title) {
+			continue
+		}
+		ev := v1alpha1.ZendeskEvent(strings.TrimPrefix(trg.Title, targetTitle(src)+":"))
+		if trg.Title == targetTitle(src) {
+			ev = v1alpha1.ZendeskTicketCreated
+		}
+		desiredTrigger, _ := newTrigger(src, ev, defaultTriggerTemplate(), action, recipientID)
+		trg := trg
+		assert.Empty(t, triggerDrift(desiredTrigger, &trg), "Trigger %q is not in sync", trg.Title)
+	}
+}
+
+// assertStatusIDs asserts that the given source's status records the IDs of
+// the Triggers of the given fake Zendesk API, and the given Target ID.
+func assertStatusIDs(t *testing.T, api *fakeZendeskAPI, src *v1alpha1.ZendeskSource, targetID string) {
+	t.Helper()
+
+	assert.Equal(t, targetID, src.Status.TargetID)
+	assert.NotNil(t, src.Status.LastSyncTime, "last sync time is not set")
+
+	triggerIDs := make(map[string]int64, len(api.triggers))
+	for _, trg := range api.triggers {
+		if isTriggerOf(src, trg.Title) {
+			triggerIDs[trg.Title] = trg.ID
+		}
+	}
+	statusIDs := make(map[string]int64, len(src.Status.Triggers))
+	for _, trg := range src.Status.Triggers {
+		statusIDs[triggerTitle(src, trg.Event)] = trg.ID
+	}
+	assert.Equal(t, triggerIDs, statusIDs)
+}
+
+// recordedEvents returns the events recorded by the given recorder.
+func recordedEvents(rec *record.FakeRecorder) []string {
+	var events []string
+	for {
+		select {
+		case e := <-rec.Events:
+			events = append(events, e)
+		default:
+			return events
+		}
+	}
+}
+
+// fakeZendeskAPI is a minimal in-memory implementation of the Zendesk
+// Targets, Triggers and Webhooks APIs.
+type fakeZendeskAPI struct {
+	mu sync.Mutex
+
+	targets  []zendesk.Target
+	triggers []zendesk.Trigger
+	webhooks []webhooks.Webhook
+	lastID   int64
+
+	requests []string
+}
+
+func newFakeZendeskAPI() *fakeZendeskAPI {
+	return &fakeZendeskAPI{}
+}
+
+// resetRequests forgets all requests received so far.
+func (a *fakeZendeskAPI) resetRequests() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.requests = nil
+}
+
+// updateRequests returns the requests received so far which are not read-only.
+func (a *fakeZendeskAPI) updateRequests() []string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	var reqs []string
+	for _, r := range a.requests {
+		if !strings.HasPrefix(r, http.MethodGet+" ") {
+			reqs = append(reqs, r)
+		}
+	}
+	return reqs
+}
+
+var (
+	fakeZendeskPathRegexp        = regexp.MustCompile(`^/(targets|triggers)(?:/(\d+))?\.json$`)
+	fakeZendeskWebhookPathRegexp = regexp.MustCompile(`^/(webhooks)(?:/([^/]+))?$`)
+)
+
+// listRequests returns the requests received so far which list objects.
+func (a *fakeZendeskAPI) listRequests() []string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	var reqs []string
+	for _, r := range a.requests {
+		path := strings.TrimPrefix(r, http.MethodGet+" ")
+		if path == r {
+			continue
+		}
+		if m := fakeZendeskPathRegexp.FindStringSubmatch(path); m != nil && m[2] == "" {
+			reqs = append(reqs, r)
+		}
+		if m := fakeZendeskWebhookPathRegexp.FindStringSubmatch(path); m != nil && m[2] == "" {
+			reqs = append(reqs, r)
+		}
+	}
+	return reqs
+}
+
+// ServeHTTP implements http.Handler.
+func (a *fakeZendeskAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.requests = append(a.requests, r.Method+" "+r.URL.Path)
+
+	m := fakeZendeskPathRegexp.FindStringSubmatch(r.URL.Path)
+	if m == nil {
+		m = fakeZendeskWebhookPathRegexp.FindStringSubmatch(r.URL.Path)
+	}
+	if m == nil {
+		http.NotFound(w, r)
+		return
+	}
+	collection, id := m[1], m[2]
+
+	switch {
+	case r.Method == http.MethodGet && id == "":
+		a.list(w, r, collection)
+	case r.Method == http.MethodGet && id != "":
+		a.get(w, collection, id)
+	case r.Method == http.MethodPost && id == "":
+		a.create(w, r, collection)
+	case r.Method == http.MethodPut && id != "":
+		a.update(w, r, collection, id)
+	case r.Method == http.MethodDelete && id != "":
+		a.delete(w, collection, id)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (a *fakeZendeskAPI) list(w http.ResponseWriter, r *http.Request, collection string) {
+	body := map[string]interface{}{}
+	switch collection {
+	case "targets":
+		start, end := offsetPage(r, body, len(a.targets))
+		body["targets"] = a.targets[start:end]
+	case "triggers":
+		start, end := offsetPage(r, body, len(a.triggers))
+		body["triggers"] = a.triggers[start:end]
+	case "webhooks":
+		filter := r.URL.Query().Get("filter[name_contains]")
+		whs := make([]webhooks.Webhook, 0, len(a.webhooks))
+		for _, wh := range a.webhooks {
+			if strings.Contains(wh.Name, filter) {
+				whs = append(whs, wh)
+			}
+		}
+		start, end := cursorPage(r, body, len(whs))
+		body["webhooks"] = whs[start:end]
+	}
+	writeJSON(w, http.StatusOK, body)
+}
+
+// fakeDefaultPageSize is the number of objects per page when a request
+// doesn't specify a page size, like in the Zendesk API.
+const fakeDefaultPageSize = 100
+
+// offsetPage returns the range of the n objects of a collection requested by
+// the given request using offset pagination, and sets the pagination
+// attributes of the given response body.
+func offsetPage(r *http.Request, body map[string]interface{}, n int) (int, int) {
+	page, perPage := 1, fakeDefaultPageSize
+	if p, err := strconv.Atoi(r.URL.Query().Get("page")); err == nil {
+		page = p
+	}
+	if p, err := strconv.Atoi(r.URL.Query().Get("per_page")); err == nil {
+		perPage = p
+	}
+
+	start, end := pageRange((page-1)*perPage, perPage, n)
+
+	body["count"] = n
+	body["next_page"] = nil
+	if end < n {
+		body["next_page"] = fmt.Sprintf("http://%s%s?page=%d&per_page=%d", r.Host, r.URL.Path, page+1, perPage)
+	}
+
+	return start, end
+}
+
+// cursorPage returns the range of the n objects of a collection requested by
+// the given request using cursor pagination, and sets the pagination
+// attributes of the given response body. Cursors are offsets.
+func cursorPage(r *http.Request, body map[string]interface{}, n int) (int, int) {
+	offset, size := 0, fakeDefaultPageSize
+	if o, err := strconv.Atoi(r.URL.Query().Get("page[after]")); err == nil {
+		offset = o
+	}
+	if s, err := strconv.Atoi(r.URL.Query().Get("page[size]")); err == nil {
+		size = s
+	}
+
+	start, end := pageRange(offset, size, n)
+
+	body["meta"] = map[string]interface{}{
+		"has_more":     end < n,
+		"after_cursor": strconv.Itoa(end),
+	}
+
+	return start, end
+}
+
+// pageRange returns the range of a page of the given size starting at the
+// given offset in a collection of n objects.
+func pageRange(offset, size, n int) (int, int) {
+	start := offset
+	if start > n {
+		start = n
+	}
+	end := start + size
+	if end > n {
+		end = n
+	}
+	return start, end
+}
+
+func (a *fakeZendeskAPI) get(w http.ResponseWriter, collection, id string) {
+	switch collection {
+	case "targets":
+		for _, t := range a.targets {
+			if fmt.Sprint(t.ID) == id {
+				writeJSON(w, http.StatusOK, map[string]interface{}{"target": t})
+				return
+			}
+		}
+
+	case "triggers":
+		for _, t := range a.triggers {
+			if fmt.Sprint(t.ID) == id {
+				writeJSON(w, http.StatusOK, map[string]interface{}{"trigger": t})
+				return
+			}
+		}
+
+	case "webhooks":
+		for _, wh := range a.webhooks {
+			if wh.ID == id {
+				writeJSON(w, http.StatusOK, map[string]interface{}{"webhook": wh})
+				return
+			}
+		}
+	}
+
+	w.WriteHeader(http.StatusNotFound)
+}
+
+func (a *fakeZendeskAPI) create(w http.ResponseWriter, r *http.Request, collection string) {
+	a.lastID++
+
+	switch collection {
+	case "targets":
+		var body struct{ Target zendesk.Target }
+		if !readJSON(w, r, &body) {
+			return
+		}
+		body.Target.ID = a.lastID
+		body.Target.Password = "" // write-only
+		a.targets = append(a.targets, body.Target)
+		writeJSON(w, http.StatusCreated, map[string]interface{}{"target": body.Target})
+
+	case "triggers":
+		var body struct{ Trigger zendesk.Trigger }
+		if !readJSON(w, r, &body) {
+			return
+		}
+		body.Trigger.ID = a.lastID
+		a.triggers = append(a.triggers, body.Trigger)
+		writeJSON(w, http.StatusCreated, map[string]interface{}{"trigger": body.Trigger})
+
+	case "webhooks":
+		var body struct{ Webhook webhooks.Webhook }
+		if !readJSON(w, r, &body) {
+			return
+		}
+		body.Webhook.ID = fmt.Sprintf("01WEBHOOK%d", a.lastID)
+		a.webhooks = append(a.webhooks, body.Webhook)
+		writeJSON(w, http.StatusCreated, map[string]interface{}{"webhook": body.Webhook})
+	}
+}
+
+func (a *fakeZendeskAPI) update(w http.ResponseWriter, r *http.Request, collection, id string) {
+	switch collection {
+	case "targets":
+		var body struct{ Target zendesk.Target }
+		if !readJSON(w, r, &body) {
+			return
+		}
+		for i := range a.targets {
+			if fmt.Sprint(a.targets[i].ID) == id {
+				body.Target.ID = a.targets[i].ID
+				body.Target.Password = "" // write-only
+				a.targets[i] = body.Target
+				writeJSON(w, http.StatusOK, map[string]interface{}{"target": body.Target})
+				return
+			}
+		}
+
+	case "triggers":
+		var body struct{ Trigger zendesk.Trigger }
+		if !readJSON(w, r, &body) {
+			return
+		}
+		for i := range a.triggers {
+			if fmt.Sprint(a.triggers[i].ID) == id {
+				body.Trigger.ID = a.triggers[i].ID
+				a.triggers[i] = body.Trigger
+				writeJSON(w, http.StatusOK, map[string]interface{}{"trigger": body.Trigger})
+				return
+			}
+		}
+
+	case "webhooks":
+		var body struct{ Webhook webhooks.Webhook }
+		if !readJSON(w, r, &body) {
+			return
+		}
+		for i := range a.webhooks {
+			if a.webhooks[i].ID == id {
+				body.Webhook.ID = id
+				a.webhooks[i] = body.Webhook
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+		}
+	}
+
+	w.WriteHeader(http.StatusNotFound)
+}
+
+func (a *fakeZendeskAPI) delete(w http.ResponseWriter, collection, id string) {
+	switch collection {
+	case "targets":
+		for i := range a.targets {
+			if fmt.Sprint(a.targets[i].ID) == id {
+				a.targets = append(a.targets[:i], a.targets[i+1:]...)
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+		}
+
+	case "triggers":
+		for i := range a.triggers {
+			if fmt.Sprint(a.triggers[i].ID) == id {
+				a.triggers = append(a.triggers[:i], a.triggers[i+1:]...)
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+		}
+
+	case "webhooks":
+		for i := range a.webhooks {
+			if a.webhooks[i].ID == id {
+				a.webhooks = append(a.webhooks[:i], a.webhooks[i+1:]...)
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+		}
+	}
+
+	w.WriteHeader(http.StatusNotFound)
+}
+
+// readJSON decodes the body of the given request into v, and responds with
+// an error if the body is invalid.
+func readJSON(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return false
+	}
+	return true
+}
+
+// writeJSON responds with the JSON representation of v.
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(v)
+}
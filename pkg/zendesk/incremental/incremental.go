@@ -0,0 +1,138 @@
+/*
+Copyright (c) 2020 TriggerMesh Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package incremental implements a client for the Zendesk Incremental Exports
+// API, which is not supported by github.com/nukosuke/go-zendesk.
+// See: https://developer.zendesk.com/api-reference/ticketing/ticket-management/incremental_exports/
+package incremental
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/triggermesh/knative-sources/pkg/zendesk/rest"
+)
+
+// Client is a client for the Zendesk Incremental Exports API.
+type Client struct {
+	rest *rest.Client
+}
+
+// NewClient returns a Client for the Incremental Exports API of the given
+// Zendesk subdomain, authenticated with the given API token.
+func NewClient(subdomain, email, apiToken string) *Client {
+	return &Client{
+		rest: rest.NewClient(subdomain, email, apiToken),
+	}
+}
+
+// SetEndpointURL overrides the base URL of the Zendesk API.
+func (c *Client) SetEndpointURL(u string) {
+	c.rest.SetEndpointURL(u)
+}
+
+// TicketEvent is an event which occurred on a ticket, such as an update.
+// Its child events describe the individual changes made to the ticket.
+type TicketEvent struct {
+	ID          int64                    `json:"id"`
+	TicketID    int64                    `json:"ticket_id"`
+	Timestamp   int64                    `json:"timestamp"`
+	EventType   string                   `json:"event_type"`
+	ChildEvents []map[string]interface{} `json:"child_events"`
+
+	// Raw is the JSON representation of the event, as returned by the
+	// Zendesk API.
+	Raw json.RawMessage `json:"-"`
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (e *TicketEvent) UnmarshalJSON(b []byte) error {
+	type ticketEvent TicketEvent
+	if err := json.Unmarshal(b, (*ticketEvent)(e)); err != nil {
+		return err
+	}
+	e.Raw = append(json.RawMessage(nil), b...)
+	return nil
+}
+
+// TicketEventsPage is a page of exported ticket events.
+type TicketEventsPage struct {
+	TicketEvents []TicketEvent `json:"ticket_events"`
+	// EndTime is the start time of the next page.
+	EndTime int64 `json:"end_time"`
+	// EndOfStream indicates that no ticket events occurred after this
+	// page at the time of the export.
+	EndOfStream bool `json:"end_of_stream"`
+}
+
+// TicketEvents returns the page of ticket events which occurred at, or after,
+// the given Unix time. The end time of each page is the start time of the
+// next page, and therefore acts as a cursor over the stream of events.
+// Comments are included in the child events of ticket events.
+func (c *Client) TicketEvents(ctx context.Context, startTime int64) (*TicketEventsPage, error) {
+	q := url.Values{
+		"start_time": {strconv.FormatInt(startTime, 10)},
+		"include":    {"comment_events"},
+	}
+
+	page := &TicketEventsPage{}
+	if err := c.rest.Do(ctx, http.MethodGet, "/incremental/ticket_events.json?"+q.Encode(),
+		nil, http.StatusOK, page); err != nil {
+
+		return nil, err
+	}
+	return page, nil
+}
+
+// MaxTicketsPerRequest is the maximum number of tickets which can be
+// requested at once.
+const MaxTicketsPerRequest = 100
+
+// Tickets returns the JSON representation of the tickets with the given IDs,
+// indexed by ID. Deleted tickets are omitted.
+func (c *Client) Tickets(ctx context.Context, ids []int64) (map[int64]json.RawMessage, error) {
+	strIDs := make([]string, len(ids))
+	for i, id := range ids {
+		strIDs[i] = strconv.FormatInt(id, 10)
+	}
+
+	var resp struct {
+		Tickets []json.RawMessage `json:"tickets"`
+	}
+
+	q := url.Values{"ids": {strings.Join(strIDs, ",")}}
+	if err := c.rest.Do(ctx, http.MethodGet, "/tickets/show_many.json?"+q.Encode(),
+		nil, http.StatusOK, &resp); err != nil {
+
+		return nil, err
+	}
+
+	tickets := make(map[int64]json.RawMessage, len(resp.Tickets))
+	for _, t := range resp.Tickets {
+		var ticket struct {
+			ID int64 `json:"id"`
+		}
+		if err := json.Unmarshal(t, &ticket); err != nil {
+			continue
+		}
+		tickets[ticket.ID] = t
+	}
+	return tickets, nil
+}
@@ -0,0 +1,136 @@
+/*
+Copyright (c) 2020 TriggerMesh Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package rest implements a minimal client for the Zendesk REST API, used by
+// the API clients which are not supported by github.com/nukosuke/go-zendesk.
+package rest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+)
+
+// Client sends requests to the Zendesk REST API.
+type Client struct {
+	httpClient *http.Client
+	baseURL    string
+
+	email    string
+	apiToken string
+}
+
+// NewClient returns a Client for the REST API of the given Zendesk subdomain,
+// authenticated with the given API token.
+func NewClient(subdomain, email, apiToken string) *Client {
+	return &Client{
+		httpClient: http.DefaultClient,
+		baseURL:    "https://" + subdomain + ".zendesk.com/api/v2",
+		email:      email,
+		apiToken:   apiToken,
+	}
+}
+
+// NewClientWithHTTPClient returns a Client for the REST API of the given
+// Zendesk subdomain, which sends requests using the given HTTP client. The
+// HTTP client is expected to authenticate requests, e.g. with an OAuth access
+// token.
+func NewClientWithHTTPClient(subdomain string, httpClient *http.Client) *Client {
+	return &Client{
+		httpClient: httpClient,
+		baseURL:    "https://" + subdomain + ".zendesk.com/api/v2",
+	}
+}
+
+// SetEndpointURL overrides the base URL of the Zendesk API.
+func (c *Client) SetEndpointURL(u string) {
+	c.baseURL = u
+}
+
+// SetHTTPClient sets the HTTP client used to send requests.
+func (c *Client) SetHTTPClient(httpClient *http.Client) {
+	c.httpClient = httpClient
+}
+
+// Error is an error response from the Zendesk API.
+type Error struct {
+	status int
+	body   string
+}
+
+// Error implements the error interface.
+func (e *Error) Error() string {
+	return fmt.Sprintf("%d %s: %s", e.status, http.StatusText(e.status), e.body)
+}
+
+// Status returns the HTTP status code of the response.
+func (e *Error) Status() int {
+	return e.status
+}
+
+// Do sends a request to the Zendesk API with the given JSON body, and decodes
+// the JSON response into out if the response has the expected status code.
+// The given path is relative to the base URL of the API.
+func (c *Client) Do(ctx context.Context, method, path string, body interface{},
+	expectStatus int, out interface{}) error {
+
+	var reqBody io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("serializing request body: %w", err)
+		}
+		reqBody = bytes.NewReader(b)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, reqBody)
+	if err != nil {
+		return fmt.Errorf("creating request: %w", err)
+	}
+	if c.apiToken != "" {
+		req.SetBasicAuth(c.email+"/token", c.apiToken)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("reading response body: %w", err)
+	}
+
+	if resp.StatusCode != expectStatus {
+		return &Error{status: resp.StatusCode, body: string(respBody)}
+	}
+
+	if out == nil {
+		return nil
+	}
+	if err := json.Unmarshal(respBody, out); err != nil {
+		return fmt.Errorf("deserializing response body: %w", err)
+	}
+	return nil
+}
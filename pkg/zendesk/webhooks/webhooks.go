@@ -0,0 +1,193 @@
+/*
+Copyright (c) 2020 TriggerMesh Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package webhooks implements a client for the Zendesk Webhooks API, which is
+// not supported by github.com/nukosuke/go-zendesk.
+// See: https://developer.zendesk.com/api-reference/event-connectors/webhooks/webhooks/
+package webhooks
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/triggermesh/knative-sources/pkg/zendesk/rest"
+)
+
+// Attribute values of Zendesk webhooks.
+const (
+	StatusActive = "active"
+
+	MethodPost = "POST"
+
+	RequestFormatJSON = "json"
+
+	// Webhooks with this subscription are notified by Zendesk Triggers.
+	SubscriptionConditionalTicketEvents = "conditional_ticket_events"
+)
+
+// Webhook is a Zendesk webhook.
+type Webhook struct {
+	ID            string   `json:"id,omitempty"`
+	Name          string   `json:"name"`
+	Status        string   `json:"status"`
+	Endpoint      string   `json:"endpoint"`
+	HTTPMethod    string   `json:"http_method"`
+	RequestFormat string   `json:"request_format"`
+	Subscriptions []string `json:"subscriptions,omitempty"`
+}
+
+// pageSize is the number of webhooks requested per page of results.
+const pageSize = 100
+
+// Client is a client for the Zendesk Webhooks API.
+type Client struct {
+	rest *rest.Client
+}
+
+// NewClient returns a Client for the Webhooks API of the given Zendesk
+// subdomain, authenticated with the given API token.
+func NewClient(subdomain, email, apiToken string) *Client {
+	return &Client{
+		rest: rest.NewClient(subdomain, email, apiToken),
+	}
+}
+
+// NewClientWithHTTPClient returns a Client for the Webhooks API of the given
+// Zendesk subdomain, which sends requests using the given HTTP client. The
+// HTTP client is expected to authenticate requests.
+func NewClientWithHTTPClient(subdomain string, httpClient *http.Client) *Client {
+	return &Client{
+		rest: rest.NewClientWithHTTPClient(subdomain, httpClient),
+	}
+}
+
+// SetEndpointURL overrides the base URL of the Zendesk API.
+func (c *Client) SetEndpointURL(u string) {
+	c.rest.SetEndpointURL(u)
+}
+
+// SetHTTPClient sets the HTTP client used to send requests.
+func (c *Client) SetHTTPClient(httpClient *http.Client) {
+	c.rest.SetHTTPClient(httpClient)
+}
+
+// FindWebhook returns the webhook with the given name, or nil if no such
+// webhook exists.
+func (c *Client) FindWebhook(ctx context.Context, name string) (*Webhook, error) {
+	whs, err := c.ListWebhooks(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range whs {
+		if whs[i].Name == name {
+			return &whs[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// ListWebhooks returns the webhooks which names contain the given string,
+// from all pages of results.
+func (c *Client) ListWebhooks(ctx context.Context, nameContains string) ([]Webhook, error) {
+	query := url.Values{
+		"filter[name_contains]": {nameContains},
+		"page[size]":            {strconv.Itoa(pageSize)},
+	}
+
+	var whs []Webhook
+
+	for {
+		var resp struct {
+			Webhooks []Webhook `json:"webhooks"`
+			Meta     struct {
+				HasMore     bool   `json:"has_more"`
+				AfterCursor string `json:"after_cursor"`
+			} `json:"meta"`
+		}
+
+		if err := c.rest.Do(ctx, http.MethodGet, "/webhooks?"+query.Encode(), nil, http.StatusOK, &resp); err != nil {
+			return nil, err
+		}
+
+		whs = append(whs, resp.Webhooks...)
+
+		if !resp.Meta.HasMore || resp.Meta.AfterCursor == "" {
+			return whs, nil
+		}
+		query.Set("page[after]", resp.Meta.AfterCursor)
+	}
+}
+
+// GetWebhook returns the webhook with the given ID.
+func (c *Client) GetWebhook(ctx context.Context, id string) (*Webhook, error) {
+	var resp struct {
+		Webhook Webhook `json:"webhook"`
+	}
+
+	if err := c.rest.Do(ctx, http.MethodGet, "/webhooks/"+url.PathEscape(id), nil, http.StatusOK, &resp); err != nil {
+		return nil, err
+	}
+	return &resp.Webhook, nil
+}
+
+// CreateWebhook creates the given webhook, and returns the created webhook.
+func (c *Client) CreateWebhook(ctx context.Context, wh Webhook) (*Webhook, error) {
+	var resp struct {
+		Webhook Webhook `json:"webhook"`
+	}
+
+	req := struct {
+		Webhook Webhook `json:"webhook"`
+	}{Webhook: wh}
+
+	if err := c.rest.Do(ctx, http.MethodPost, "/webhooks", req, http.StatusCreated, &resp); err != nil {
+		return nil, err
+	}
+	return &resp.Webhook, nil
+}
+
+// UpdateWebhook replaces the webhook with the given ID.
+func (c *Client) UpdateWebhook(ctx context.Context, id string, wh Webhook) error {
+	req := struct {
+		Webhook Webhook `json:"webhook"`
+	}{Webhook: wh}
+
+	return c.rest.Do(ctx, http.MethodPut, "/webhooks/"+url.PathEscape(id), req, http.StatusNoContent, nil)
+}
+
+// DeleteWebhook deletes the webhook with the given ID.
+func (c *Client) DeleteWebhook(ctx context.Context, id string) error {
+	return c.rest.Do(ctx, http.MethodDelete, "/webhooks/"+url.PathEscape(id), nil, http.StatusNoContent, nil)
+}
+
+// GetSigningSecret returns the secret Zendesk uses to sign the requests sent
+// by the webhook with the given ID.
+func (c *Client) GetSigningSecret(ctx context.Context, id string) (string, error) {
+	var resp struct {
+		SigningSecret struct {
+			Secret string `json:"secret"`
+		} `json:"signing_secret"`
+	}
+
+	path := "/webhooks/" + url.PathEscape(id) + "/signing_secret"
+	if err := c.rest.Do(ctx, http.MethodGet, path, nil, http.StatusOK, &resp); err != nil {
+		return "", err
+	}
+	return resp.SigningSecret.Secret, nil
+}